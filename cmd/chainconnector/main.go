@@ -2,10 +2,20 @@ package main
 
 import (
 	"ChainConnector/internal/app"
+	"context"
+	"fmt"
+	"os"
 
 	"go.uber.org/fx"
 )
 
 func main() {
-	fx.New(app.Modules).Run()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.RunMigrate(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fx.New(app.Modules, app.Workers).Run()
 }
@@ -8,9 +8,9 @@ import (
 )
 
 type job struct {
-	handler ports.EventHandler
-	payload interface{}
-	ctx     context.Context
+	handlers []ports.EventHandler
+	payload  interface{}
+	ctx      context.Context
 }
 
 type InMemoryBus struct {
@@ -45,7 +45,9 @@ func NewInMemoryBus(workerCount, queueSize int) *InMemoryBus {
 			for {
 				select {
 				case j := <-b.jobs:
-					_ = j.handler(j.ctx, j.payload)
+					for _, h := range j.handlers {
+						_ = h(j.ctx, j.payload)
+					}
 				case <-b.stop:
 					return
 				}
@@ -73,33 +75,27 @@ func (b *InMemoryBus) Subscribe(topic string, h ports.EventHandler) func() {
 	}
 }
 
-// Publish enfileira jobs para os handlers; se a fila estiver cheia, faz fallback para goroutine.
-func (b *InMemoryBus) Publish(ctx context.Context, topic string, payload interface{}) {
+// Publish enfileira um job com todos os handlers do tópico. Se a fila estiver
+// cheia o evento é descartado e Publish retorna ports.ErrBusFull, para que
+// nenhum handler o receba pela metade e o chamador possa tentar de novo.
+func (b *InMemoryBus) Publish(ctx context.Context, topic string, payload interface{}) error {
 	b.mu.RLock()
-	handlersMap := b.subs[topic]
-	b.mu.RUnlock()
-
-	if handlersMap == nil {
-		return
-	}
-
 	// snapshot handlers
-	handlers := make([]ports.EventHandler, 0, len(handlersMap))
-	for _, h := range handlersMap {
+	handlers := make([]ports.EventHandler, 0, len(b.subs[topic]))
+	for _, h := range b.subs[topic] {
 		handlers = append(handlers, h)
 	}
+	b.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		return nil
+	}
 
-	for _, h := range handlers {
-		j := job{handler: h, payload: payload, ctx: ctx}
-		select {
-		case b.jobs <- j:
-			// enqueued
-		default:
-			// fila cheia: fallback para execução imediata em goroutine (fire-and-forget)
-			go func(h ports.EventHandler, ctx context.Context, payload interface{}) {
-				_ = h(ctx, payload)
-			}(h, ctx, payload)
-		}
+	select {
+	case b.jobs <- job{handlers: handlers, payload: payload, ctx: ctx}:
+		return nil
+	default:
+		return ports.ErrBusFull
 	}
 }
 
@@ -1,7 +1,9 @@
 package eventbus
 
 import (
+	"ChainConnector/internal/domain/ports"
 	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -87,8 +89,8 @@ func TestUnsubscribeStopsReceiving(t *testing.T) {
 	}
 }
 
-func TestPublishDoesNotBlockWhenQueueFull(t *testing.T) {
-	// small queue and slow handler to force fallback path
+func TestPublishReturnsErrBusFullWhenQueueFull(t *testing.T) {
+	// one worker and a queue of one: a third event has nowhere to go
 	b := NewInMemoryBus(1, 1)
 	defer b.Close()
 
@@ -104,23 +106,54 @@ func TestPublishDoesNotBlockWhenQueueFull(t *testing.T) {
 	defer u()
 
 	start := time.Now()
-	// first publish will occupy the queue/worker
-	b.Publish(context.Background(), "topic-busy", 1)
-	// second publish should not block even if queue is full (fallback goroutine)
-	b.Publish(context.Background(), "topic-busy", 2)
-	elapsed := time.Since(start)
-	if elapsed > 200*time.Millisecond {
+	// the first event occupies the worker, the second the queue
+	if err := b.Publish(context.Background(), "topic-busy", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for atomic.LoadInt32(&handled) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := b.Publish(context.Background(), "topic-busy", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Publish(context.Background(), "topic-busy", 3); !errors.Is(err, ports.ErrBusFull) {
+		t.Fatalf("expected ErrBusFull, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
 		t.Fatalf("Publish appears to block when queue full (took %v)", elapsed)
 	}
 
-	// allow handlers to finish
 	close(wait)
-
-	// give some time for handler goroutines to finish
 	time.Sleep(100 * time.Millisecond)
 
-	if atomic.LoadInt32(&handled) < 2 {
-		t.Fatalf("expected at least 2 handled messages, got %d", handled)
+	if n := atomic.LoadInt32(&handled); n != 2 {
+		t.Fatalf("expected the 2 accepted events to be handled, got %d", n)
+	}
+}
+
+func TestPublishDeliversToEveryHandlerOrNone(t *testing.T) {
+	b := NewInMemoryBus(1, 1)
+	defer b.Close()
+
+	var handled int32
+	for i := 0; i < 3; i++ {
+		u := b.Subscribe("fanout", func(ctx context.Context, payload interface{}) error {
+			atomic.AddInt32(&handled, 1)
+			return nil
+		})
+		defer u()
+	}
+	if err := b.Publish(context.Background(), "fanout", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.After(500 * time.Millisecond)
+	for atomic.LoadInt32(&handled) < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected 3 handlers to run from one queue slot, got %d", handled)
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
 	}
 }
 
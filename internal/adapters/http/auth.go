@@ -0,0 +1,239 @@
+package http
+
+import (
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// apiKeyHeader carries API keys; a key may also be sent as a bearer token.
+const apiKeyHeader = "X-API-Key"
+
+// jwtLeeway tolerates clock skew when checking exp and nbf.
+const jwtLeeway = 30 * time.Second
+
+// principalKey stores the authenticated *entity.Principal in fiber.Ctx.Locals.
+const principalKey = "principal"
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+// authenticator resolves API keys and JWTs to principals and enforces the
+// scope of each route. When auth is disabled every request passes without a
+// principal.
+type authenticator struct {
+	enabled  bool
+	keys     map[string]entity.APIKey // by hex SHA-256 of the key
+	store    ports.APIKeyStore
+	secret   []byte
+	rsaKey   *rsa.PublicKey
+	issuer   string
+	audience string
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// newAuthenticator builds the authenticator of cfg. store may be nil when
+// keys are only configured statically.
+func newAuthenticator(cfg config.AuthConfig, store ports.APIKeyStore, logger *zap.Logger) *authenticator {
+	a := &authenticator{
+		enabled:  cfg.Enabled,
+		keys:     map[string]entity.APIKey{},
+		store:    store,
+		secret:   []byte(cfg.JWT.HS256Secret),
+		issuer:   cfg.JWT.Issuer,
+		audience: cfg.JWT.Audience,
+		logger:   logger,
+		now:      time.Now,
+	}
+	for _, k := range cfg.APIKeys {
+		hash := strings.ToLower(k.SHA256)
+		a.keys[hash] = entity.APIKey{Name: k.Name, KeyHash: hash, Scopes: k.Scopes}
+	}
+	if cfg.JWT.RS256PublicKey != "" {
+		key, err := config.ParseRSAPublicKey(cfg.JWT.RS256PublicKey)
+		if err != nil {
+			// Config.Validate rejects this; without a key RS256 tokens fail
+			logger.Error("invalid jwt.rs256_public_key", zap.Error(err))
+		}
+		a.rsaKey = key
+	}
+	return a
+}
+
+// require admits callers granted scope and stores their principal for the
+// handler. Missing or invalid credentials get 401, a missing scope 403.
+func (a *authenticator) require(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !a.enabled {
+			return c.Next()
+		}
+		p, err := a.authenticate(c)
+		switch {
+		case errors.Is(err, errUnauthenticated):
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="chainconnector"`)
+			return sendProblem(c, fiber.StatusUnauthorized, err.Error())
+		case err != nil:
+			a.logger.Error("authentication failed", zap.Error(err))
+			return sendProblem(c, fiber.StatusInternalServerError, "Authentication failed")
+		case !p.HasScope(scope):
+			return sendProblem(c, fiber.StatusForbidden, fmt.Sprintf("%s lacks the %s scope", p.Subject, scope))
+		}
+		c.Locals(principalKey, p)
+		return c.Next()
+	}
+}
+
+// principalOf returns the principal that require stored, or nil.
+func principalOf(c *fiber.Ctx) *entity.Principal {
+	p, _ := c.Locals(principalKey).(*entity.Principal)
+	return p
+}
+
+// authenticate reads the X-API-Key header or a bearer token. Bearer tokens
+// that are not JWTs are treated as API keys.
+func (a *authenticator) authenticate(c *fiber.Ctx) (*entity.Principal, error) {
+	if key := c.Get(apiKeyHeader); key != "" {
+		return a.apiKey(c, key)
+	}
+	scheme, token, _ := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, fmt.Errorf("%w: an X-API-Key header or a bearer token is required", errUnauthenticated)
+	}
+	if strings.Count(token, ".") == 2 {
+		return a.verifyJWT(token)
+	}
+	return a.apiKey(c, token)
+}
+
+func (a *authenticator) apiKey(c *fiber.Ctx, key string) (*entity.Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+	found, ok := a.keys[hash]
+	if !ok && a.store != nil {
+		stored, err := a.store.FindAPIKey(c.UserContext(), hash)
+		if err != nil {
+			return nil, fmt.Errorf("find api key: %w", err)
+		}
+		if stored != nil {
+			found, ok = *stored, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", errUnauthenticated)
+	}
+	return &entity.Principal{Subject: "api-key:" + found.Name, Scopes: found.Scopes}, nil
+}
+
+// jwtClaims are the registered claims checked by verifyJWT plus the scopes.
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	Scope     string   `json:"scope"`
+	Scopes    []string `json:"scopes"`
+}
+
+// audience accepts the aud claim as a string or a list of strings.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*aud = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return errors.New("aud must be a string or a list of strings")
+	}
+	*aud = many
+	return nil
+}
+
+// verifyJWT checks the signature (HS256 or RS256), exp, nbf, iss and aud of
+// token. exp is required.
+func (a *authenticator) verifyJWT(token string) (*entity.Principal, error) {
+	invalid := func(reason string) (*entity.Principal, error) {
+		return nil, fmt.Errorf("%w: invalid token: %s", errUnauthenticated, reason)
+	}
+	parts := strings.Split(token, ".")
+	rawHeader, err1 := base64.RawURLEncoding.DecodeString(parts[0])
+	rawClaims, err2 := base64.RawURLEncoding.DecodeString(parts[1])
+	sig, err3 := base64.RawURLEncoding.DecodeString(parts[2])
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return invalid("malformed encoding")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return invalid("malformed header")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Alg {
+	case "HS256":
+		if len(a.secret) == 0 {
+			return invalid("HS256 is not accepted")
+		}
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return invalid("bad signature")
+		}
+	case "RS256":
+		if a.rsaKey == nil {
+			return invalid("RS256 is not accepted")
+		}
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(a.rsaKey, crypto.SHA256, digest[:], sig) != nil {
+			return invalid("bad signature")
+		}
+	default:
+		return invalid(fmt.Sprintf("unsupported alg %q", header.Alg))
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return invalid("malformed claims")
+	}
+	now := a.now()
+	switch {
+	case claims.Subject == "":
+		return invalid("sub is required")
+	case claims.ExpiresAt == nil:
+		return invalid("exp is required")
+	case now.After(unixTime(*claims.ExpiresAt).Add(jwtLeeway)):
+		return invalid("expired")
+	case claims.NotBefore != nil && now.Add(jwtLeeway).Before(unixTime(*claims.NotBefore)):
+		return invalid("not valid yet")
+	case a.issuer != "" && claims.Issuer != a.issuer:
+		return invalid("unexpected issuer")
+	case a.audience != "" && !slices.Contains(claims.Audience, a.audience):
+		return invalid("unexpected audience")
+	}
+	scopes := append(strings.Fields(claims.Scope), claims.Scopes...)
+	return &entity.Principal{Subject: "jwt:" + claims.Subject, Scopes: scopes}, nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
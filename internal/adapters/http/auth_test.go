@@ -0,0 +1,260 @@
+package http
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/service"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// keyStore is an in-memory ports.APIKeyStore.
+type keyStore struct {
+	keys map[string]*entity.APIKey
+	err  error
+}
+
+func (s keyStore) FindAPIKey(_ context.Context, hash string) (*entity.APIKey, error) {
+	return s.keys[hash], s.err
+}
+
+// signJWT encodes claims with HS256 when key is a []byte and RS256 when it is
+// an *rsa.PrivateKey.
+func signJWT(t *testing.T, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "HS256"
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		alg = "RS256"
+	}
+	enc := func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": alg, "typ": "JWT"}) + "." + enc(claims)
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// authApp serves a route requiring scope that echoes the principal subject.
+func authApp(a *authenticator, scope string) *fiber.App {
+	app := fiber.New()
+	app.Get("/", a.require(scope), func(c *fiber.Ctx) error {
+		if p := principalOf(c); p != nil {
+			return c.SendString(p.Subject)
+		}
+		return c.SendString("anonymous")
+	})
+	return app
+}
+
+func call(t *testing.T, app *fiber.App, header, value string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test error: %v", err)
+	}
+	var body bytes.Buffer
+	_, _ = body.ReadFrom(resp.Body)
+	return resp.StatusCode, body.String()
+}
+
+func TestAuthenticatorAPIKeys(t *testing.T) {
+	cfg := config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+		{Name: "reader", SHA256: strings.ToUpper(keyHash("r-secret")), Scopes: []string{entity.ScopeTxRead}},
+		{Name: "root", SHA256: keyHash("a-secret"), Scopes: []string{entity.ScopeAdmin}},
+	}}
+	store := keyStore{keys: map[string]*entity.APIKey{
+		keyHash("db-secret"): {Name: "backoffice", Scopes: []string{entity.ScopeTxWrite}},
+	}}
+	a := newAuthenticator(cfg, store, zap.NewNop())
+	read, write := authApp(a, entity.ScopeTxRead), authApp(a, entity.ScopeTxWrite)
+
+	for _, tc := range []struct {
+		app           *fiber.App
+		header, value string
+		status        int
+		body          string
+	}{
+		{read, apiKeyHeader, "r-secret", http.StatusOK, "api-key:reader"},
+		{read, "Authorization", "Bearer r-secret", http.StatusOK, "api-key:reader"},
+		{write, apiKeyHeader, "r-secret", http.StatusForbidden, ""},
+		{write, apiKeyHeader, "a-secret", http.StatusOK, "api-key:root"},
+		{write, apiKeyHeader, "db-secret", http.StatusOK, "api-key:backoffice"},
+		{read, apiKeyHeader, "wrong", http.StatusUnauthorized, ""},
+		{read, "Authorization", "Basic cjpy", http.StatusUnauthorized, ""},
+		{read, "", "", http.StatusUnauthorized, ""},
+	} {
+		status, body := call(t, tc.app, tc.header, tc.value)
+		if status != tc.status || (tc.body != "" && body != tc.body) {
+			t.Errorf("%s %q: expected %d %q, got %d %q", tc.header, tc.value, tc.status, tc.body, status, body)
+		}
+	}
+
+	failing := newAuthenticator(cfg, keyStore{err: errors.New("db down")}, zap.NewNop())
+	if status, _ := call(t, authApp(failing, entity.ScopeTxRead), apiKeyHeader, "unknown"); status != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the key store fails, got %d", status)
+	}
+	if status, body := call(t, authApp(newAuthenticator(config.AuthConfig{}, nil, zap.NewNop()), entity.ScopeAdmin), "", ""); status != http.StatusOK || body != "anonymous" {
+		t.Fatalf("expected disabled auth to let requests through, got %d %q", status, body)
+	}
+}
+
+func TestAuthenticatorJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	secret := []byte("hs-secret")
+	cfg := config.AuthConfig{Enabled: true, JWT: config.JWTConfig{
+		HS256Secret:    string(secret),
+		RS256PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Issuer:         "https://issuer.example",
+		Audience:       "chainconnector",
+	}}
+	now := time.Unix(1_700_000_000, 0)
+	a := newAuthenticator(cfg, nil, zap.NewNop())
+	a.now = func() time.Time { return now }
+	app := authApp(a, entity.ScopeTxWrite)
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "user-42", "iss": cfg.JWT.Issuer, "aud": []string{"other", "chainconnector"},
+			"exp": now.Add(time.Minute).Unix(), "scope": "tx:read tx:write",
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	bearer := func(key interface{}, changes map[string]interface{}) string {
+		return "Bearer " + signJWT(t, key, claims(changes))
+	}
+
+	for name, tc := range map[string]struct {
+		token  string
+		status int
+	}{
+		"hs256":          {bearer(secret, nil), http.StatusOK},
+		"rs256":          {bearer(rsaKey, nil), http.StatusOK},
+		"scopes claim":   {bearer(secret, map[string]interface{}{"scope": nil, "scopes": []string{"tx:write"}}), http.StatusOK},
+		"aud string":     {bearer(secret, map[string]interface{}{"aud": "chainconnector"}), http.StatusOK},
+		"within leeway":  {bearer(secret, map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}), http.StatusOK},
+		"expired":        {bearer(secret, map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}), http.StatusUnauthorized},
+		"no exp":         {bearer(secret, map[string]interface{}{"exp": nil}), http.StatusUnauthorized},
+		"not yet valid":  {bearer(secret, map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), http.StatusUnauthorized},
+		"wrong issuer":   {bearer(secret, map[string]interface{}{"iss": "https://evil.example"}), http.StatusUnauthorized},
+		"wrong audience": {bearer(secret, map[string]interface{}{"aud": "other"}), http.StatusUnauthorized},
+		"no sub":         {bearer(secret, map[string]interface{}{"sub": nil}), http.StatusUnauthorized},
+		"wrong secret":   {bearer([]byte("guess"), nil), http.StatusUnauthorized},
+		"read only":      {bearer(rsaKey, map[string]interface{}{"scope": "tx:read"}), http.StatusForbidden},
+		"alg none":       {"Bearer eyJhbGciOiJub25lIn0." + strings.Split(signJWT(t, secret, claims(nil)), ".")[1] + ".", http.StatusUnauthorized},
+	} {
+		status, body := call(t, app, "Authorization", tc.token)
+		if status != tc.status {
+			t.Errorf("%s: expected %d, got %d %s", name, tc.status, status, body)
+		}
+		if status == http.StatusOK && body != "jwt:user-42" {
+			t.Errorf("%s: unexpected principal %q", name, body)
+		}
+	}
+}
+
+// TestAuthProtectsDocumentedRoutes checks every route against the scope the
+// OpenAPI document gives it.
+func TestAuthProtectsDocumentedRoutes(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth = config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+		{Name: "reader", SHA256: keyHash("r-secret"), Scopes: []string{entity.ScopeTxRead}},
+	}}
+	app := NewFiberServer(cfg, zap.NewNop(), &service.TransactionService{}, nil, nil).app.(*fiber.App)
+
+	for _, r := range apiRoutes() {
+		url := strings.NewReplacer(":id", "x", ":hash", "0x1").Replace(r.Path)
+		req, _ := http.NewRequest(r.Method, url, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", r.Method, url, err)
+		}
+		if unauthorized := resp.StatusCode == http.StatusUnauthorized; unauthorized != (r.Scope != "") {
+			t.Errorf("%s %s: scope %q but answered %d without credentials", r.Method, r.Path, r.Scope, resp.StatusCode)
+		}
+		if r.Scope == "" {
+			continue
+		}
+		if resp.Header.Get("WWW-Authenticate") == "" || resp.Header.Get("Content-Type") != problemContentType {
+			t.Errorf("%s %s: expected a problem with WWW-Authenticate, got %v", r.Method, r.Path, resp.Header)
+		}
+		if r.Scope == entity.ScopeTxWrite {
+			req, _ := http.NewRequest(r.Method, url, nil)
+			req.Header.Set(apiKeyHeader, "r-secret")
+			if resp, _ := app.Test(req); resp.StatusCode != http.StatusForbidden {
+				t.Errorf("%s %s: expected 403 for a read-only key, got %d", r.Method, r.Path, resp.StatusCode)
+			}
+		}
+	}
+}
+
+func TestHandlerTransactionRecordsPrincipal(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth = config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+		{Name: "payouts", SHA256: keyHash("w-secret"), Scopes: []string{entity.ScopeTxWrite}},
+	}}
+	repo := postgres.NewInMemoryTxRepository()
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	app := NewFiberServer(cfg, zap.NewNop(), txSvc, nil, nil).app.(*fiber.App)
+
+	req, _ := http.NewRequest("POST", "/transaction", strings.NewReader(`{"to":"`+lowerAddr+`","amount":"1","client_id":"k1"}`))
+	req.Header.Set(apiKeyHeader, "w-secret")
+	resp, err := app.Test(req)
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %v %v", resp, err)
+	}
+	if tx, _ := repo.FindByIdempotencyKey(context.Background(), "k1"); tx == nil || tx.RequestedBy != "api-key:payouts" {
+		t.Fatalf("expected the principal on the transaction, got %q", tx.RequestedBy)
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"ChainConnector/internal/domain/service"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// limitsView reports the rate limits and their current usage. Only buckets
+// that are not full are listed.
+type limitsView struct {
+	PerKey    rateView     `json:"per_key"`
+	PerSender rateView     `json:"per_sender"`
+	Keys      []bucketView `json:"keys"`
+	Senders   []bucketView `json:"senders"`
+	Quotas    []quotaView  `json:"quotas"`
+}
+
+// rateView is a token bucket policy; a zero per_second means unlimited.
+type rateView struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+// bucketView is the tokens left to one API key or sender.
+type bucketView struct {
+	Key    string  `json:"key"`
+	Tokens float64 `json:"tokens"`
+}
+
+// quotaView is the value in wei a sender submitted on a chain today.
+type quotaView struct {
+	Chain  string `json:"chain"`
+	Sender string `json:"sender"`
+	Day    string `json:"day"`
+	Spent  string `json:"spent"`
+	Limit  string `json:"limit"`
+}
+
+// limitKey rate limits requests per API client: the authenticated principal,
+// or the remote address when auth is disabled. It runs after require.
+func (f *FiberServer) limitKey(c *fiber.Ctx) error {
+	key := "ip:" + c.IP()
+	if p := principalOf(c); p != nil {
+		key = p.Subject
+	}
+	if err := f.limits.AllowKey(key); err != nil {
+		return sendLimited(c, err)
+	}
+	return c.Next()
+}
+
+// sendLimited answers 429 with a Retry-After header for a
+// *service.LimitError; other errors are answered 500.
+func sendLimited(c *fiber.Ctx, err error) error {
+	var limitErr *service.LimitError
+	if !errors.As(err, &limitErr) {
+		return sendProblem(c, fiber.StatusInternalServerError, "Rate limiting failed")
+	}
+	c.Set(fiber.HeaderRetryAfter, retryAfter(limitErr.RetryAfter))
+	return sendProblem(c, fiber.StatusTooManyRequests, limitErr.Error())
+}
+
+// retryAfter renders d as whole seconds, rounded up and at least 1.
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
+
+// handlerLimits reports the rate limits and quotas and their usage.
+func (f *FiberServer) handlerLimits(c *fiber.Ctx) error {
+	u := f.limits.Usage()
+	view := limitsView{
+		PerKey:    rateView{PerSecond: u.PerKey.PerSecond, Burst: u.PerKey.Burst},
+		PerSender: rateView{PerSecond: u.PerSender.PerSecond, Burst: u.PerSender.Burst},
+		Keys:      bucketViews(u.Keys),
+		Senders:   bucketViews(u.Senders),
+		Quotas:    make([]quotaView, 0, len(u.Quotas)),
+	}
+	for _, q := range u.Quotas {
+		view.Quotas = append(view.Quotas, quotaView{
+			Chain:  q.Chain,
+			Sender: q.Sender,
+			Day:    q.Day,
+			Spent:  decimal(q.Spent),
+			Limit:  decimal(q.Limit),
+		})
+	}
+	return c.JSON(view)
+}
+
+func bucketViews(buckets []service.BucketUsage) []bucketView {
+	out := make([]bucketView, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, bucketView{Key: b.Key, Tokens: b.Tokens})
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/service"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func submit(t *testing.T, app *fiber.App, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/transaction", strings.NewReader(body))
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test error: %v", err)
+	}
+	return resp
+}
+
+func TestRateLimitPerKey(t *testing.T) {
+	txSvc := service.NewTransactionService(postgres.NewInMemoryTxRepository(), nil, nil, zap.NewNop())
+	limits := service.NewLimiter(service.LimitPolicy{PerKey: service.Rate{PerSecond: 0.1, Burst: 2}})
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, limits).app.(*fiber.App)
+
+	var resp *http.Response
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "/v1/transactions", nil)
+		resp, _ = app.Test(req)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "10" {
+		t.Fatalf("expected 429 with Retry-After 10, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if resp.Header.Get("Content-Type") != problemContentType {
+		t.Fatalf("expected a problem, got %s", resp.Header.Get("Content-Type"))
+	}
+	req, _ := http.NewRequest("GET", "/health", nil)
+	if resp, _ := app.Test(req); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected public routes to be unlimited, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitPerSenderAndQuota(t *testing.T) {
+	limits := service.NewLimiter(service.LimitPolicy{
+		PerSender:  service.Rate{PerSecond: 1, Burst: 3},
+		DailyValue: map[string]*big.Int{"ETH": big.NewInt(10)},
+	})
+	repo := &failingRepo{TxRepositoryPort: postgres.NewInMemoryTxRepository()}
+	txSvc := service.NewTransactionService(repo, nil, stubSigner{}, zap.NewNop())
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, limits).app.(*fiber.App)
+	body := func(from, amount string) string {
+		return `{"from":"` + from + `","to":"` + lowerAddr + `","amount":"` + amount + `"}`
+	}
+
+	// without a from the signer's address is the sender
+	if resp := submit(t, app, `{"to":"`+lowerAddr+`","amount":"6"}`); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	resp := submit(t, app, body(strings.ToLower(checksummed), "5"))
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the quota to be exceeded, got %d", resp.StatusCode)
+	}
+	if wait, _ := time.ParseDuration(resp.Header.Get("Retry-After") + "s"); wait <= 0 || wait > 24*time.Hour {
+		t.Fatalf("expected to retry by midnight, got %q", resp.Header.Get("Retry-After"))
+	}
+
+	// a transaction that is not stored gives its value back
+	repo.err = errors.New("database down")
+	if resp := submit(t, app, body(checksummed, "4")); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the transaction is not stored, got %d", resp.StatusCode)
+	}
+	repo.err = nil
+	if resp := submit(t, app, body(checksummed, "4")); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	// the sender used its burst of 3 on the reservations; the quota refusal
+	// took no token
+	otherSvc := service.NewTransactionService(repo, nil, otherSigner{}, zap.NewNop())
+	other := NewFiberServer(config.Default(), zap.NewNop(), otherSvc, nil, limits).app.(*fiber.App)
+	if resp := submit(t, other, body(lowerAddr, "0")); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected other senders to be unaffected, got %d", resp.StatusCode)
+	}
+	resp = submit(t, app, body(checksummed, "0"))
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("expected the sender to be rate limited, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestQuotaReleasedOnReplay(t *testing.T) {
+	limits := service.NewLimiter(service.LimitPolicy{DailyValue: map[string]*big.Int{"ETH": big.NewInt(10)}})
+	repo := &failingRepo{TxRepositoryPort: postgres.NewInMemoryTxRepository()}
+	txSvc := service.NewTransactionService(repo, nil, stubSigner{}, zap.NewNop())
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, limits).app.(*fiber.App)
+	keyed := `{"to":"` + lowerAddr + `","amount":"4","client_id":"k1"}`
+
+	if resp := submit(t, app, keyed); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	// a concurrent retry misses the key on lookup, reserves its value and
+	// loses the insert to the first request
+	repo.misses = 2
+	if resp := submit(t, app, keyed); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the retry to be replayed, got %d", resp.StatusCode)
+	}
+	// only the stored transaction counts against the quota
+	if resp := submit(t, app, `{"to":"`+lowerAddr+`","amount":"6"}`); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected the replay to give its value back, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth = config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+		{Name: "ops", SHA256: keyHash("a-secret"), Scopes: []string{entity.ScopeAdmin}},
+		{Name: "payouts", SHA256: keyHash("w-secret"), Scopes: []string{entity.ScopeTxWrite}},
+	}}
+	limits := service.NewLimiter(service.LimitPolicy{
+		PerKey:     service.Rate{PerSecond: 1, Burst: 5},
+		DailyValue: map[string]*big.Int{"ETH": big.NewInt(100)},
+	})
+	txSvc := service.NewTransactionService(postgres.NewInMemoryTxRepository(), nil, nil, zap.NewNop())
+	app := NewFiberServer(cfg, zap.NewNop(), txSvc, nil, limits).app.(*fiber.App)
+
+	req, _ := http.NewRequest("POST", "/transaction", strings.NewReader(`{"from":"`+checksummed+`","to":"`+lowerAddr+`","amount":"30"}`))
+	req.Header.Set(apiKeyHeader, "w-secret")
+	if resp, _ := app.Test(req); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	req, _ = http.NewRequest("GET", "/v1/admin/limits", nil)
+	req.Header.Set(apiKeyHeader, "w-secret")
+	if resp, _ := app.Test(req); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without the admin scope, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/admin/limits", nil)
+	req.Header.Set(apiKeyHeader, "a-secret")
+	resp, _ := app.Test(req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var view limitsView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if view.PerKey.Burst != 5 || len(view.Keys) != 2 || view.Keys[0].Key != "api-key:ops" || view.Keys[1].Key != "api-key:payouts" {
+		t.Fatalf("unexpected key usage %+v", view)
+	}
+	if len(view.Quotas) != 1 || view.Quotas[0].Sender != strings.ToLower(checksummed) || view.Quotas[0].Spent != "30" || view.Quotas[0].Limit != "100" {
+		t.Fatalf("unexpected quota usage %+v", view.Quotas)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for d, want := range map[time.Duration]string{0: "1", 100 * time.Millisecond: "1", 1500 * time.Millisecond: "2", time.Hour: "3600"} {
+		if got := retryAfter(d); got != want {
+			t.Errorf("%s: expected %s, got %s", d, want, got)
+		}
+	}
+}
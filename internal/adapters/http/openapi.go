@@ -0,0 +1,420 @@
+package http
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"go.uber.org/zap"
+)
+
+// apiRoute documents one registered route. Bodies are example values of the
+// structs the handlers decode and encode; their schemas are derived from the
+// json tags, so the document follows the handlers.
+type apiRoute struct {
+	Method     string
+	Path       string // as registered with fiber, e.g. /transactions/:id
+	Summary    string
+	Deprecated bool
+	Scope      string // required when auth is enabled; empty for public routes
+	Params     []apiParam
+	Body       interface{}
+	Responses  []apiResponse
+}
+
+type apiParam struct {
+	Name        string
+	In          string // path, query or header
+	Description string
+}
+
+// apiResponse describes one status of a route. A string Body is sent as
+// text/plain, an htmlPage as text/html, a problem as
+// application/problem+json and anything else as JSON; a nil Body means no
+// content.
+type apiResponse struct {
+	Status      int
+	Description string
+	Body        interface{}
+}
+
+// htmlPage marks a text/html response body.
+type htmlPage string
+
+// docsAsset marks a Swagger UI file, sent as text/css or text/javascript.
+type docsAsset string
+
+var (
+	idParam  = apiParam{Name: "id", In: "path", Description: "Transaction ID"}
+	notFound = apiResponse{Status: http.StatusNotFound, Description: "Unknown transaction", Body: ""}
+	failed   = apiResponse{Status: http.StatusInternalServerError, Description: "Repository or node failure", Body: ""}
+	conflict = apiResponse{Status: http.StatusConflict, Description: "Not allowed in the current status or a concurrent write won", Body: ""}
+	noSigner = apiResponse{Status: http.StatusServiceUnavailable, Description: "No wallet signer configured", Body: ""}
+	// authResponses are added to every route with a scope
+	authResponses = []apiResponse{
+		{Status: http.StatusUnauthorized, Description: "Missing or invalid credentials", Body: problem{}},
+		{Status: http.StatusForbidden, Description: "The credentials lack the required scope", Body: problem{}},
+		{Status: http.StatusTooManyRequests, Description: "Rate limit or daily value quota exceeded, see the Retry-After header", Body: problem{}},
+	}
+	listQuery = []apiParam{
+		{Name: "status", In: "query", Description: "Comma separated statuses"},
+		{Name: "chain", In: "query", Description: "Chain name, any case"},
+		{Name: "from", In: "query", Description: "Sender address, any case"},
+		{Name: "to", In: "query", Description: "Recipient address, any case"},
+		{Name: "created_after", In: "query", Description: "RFC 3339 time, inclusive"},
+		{Name: "created_before", In: "query", Description: "RFC 3339 time, exclusive"},
+		{Name: "limit", In: "query", Description: "Page size, 50 by default and at most 500"},
+		{Name: "cursor", In: "query", Description: "next_cursor of the previous page"},
+	}
+)
+
+// transactionRoutes documents the query and cancel routes served both
+// unversioned and under /v1.
+func transactionRoutes(prefix string) []apiRoute {
+	legacy := prefix == ""
+	return []apiRoute{
+		{Method: "GET", Path: prefix + "/transactions", Scope: entity.ScopeTxRead, Summary: "List transactions, newest first", Deprecated: legacy,
+			Params: listQuery,
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "One page", Body: pageView{}},
+				{Status: http.StatusBadRequest, Description: "Invalid filter or cursor", Body: ""},
+				failed,
+			}},
+		{Method: "GET", Path: prefix + "/transactions/:id", Scope: entity.ScopeTxRead, Summary: "Get a transaction", Deprecated: legacy,
+			Params: []apiParam{idParam},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "The transaction", Body: transactionView{}},
+				notFound, failed,
+			}},
+		{Method: "GET", Path: prefix + "/transactions/:id/history", Scope: entity.ScopeTxRead, Summary: "List the status changes of a transaction, oldest first", Deprecated: legacy,
+			Params: []apiParam{idParam},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "The status changes", Body: historyView{}},
+				notFound, failed,
+			}},
+		{Method: "POST", Path: prefix + "/transactions/:id/cancel", Scope: entity.ScopeTxWrite, Summary: "Cancel a pending or sent transaction", Deprecated: legacy,
+			Params: []apiParam{idParam},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "The pending transaction was cancelled", Body: cancelView{}},
+				{Status: http.StatusAccepted, Description: "A cancelling replacement was broadcast", Body: cancelView{}},
+				notFound, conflict, noSigner, failed,
+			}},
+	}
+}
+
+// apiRoutes documents every route registered by router.
+func apiRoutes() []apiRoute {
+	routes := []apiRoute{
+		{Method: "GET", Path: "/health", Summary: "Liveness check",
+			Responses: []apiResponse{{Status: http.StatusOK, Description: "The server is up", Body: "OK"}}},
+		{Method: "GET", Path: "/openapi.json", Summary: "This document",
+			Responses: []apiResponse{{Status: http.StatusOK, Description: "OpenAPI 3 document", Body: map[string]interface{}{}}}},
+		{Method: "GET", Path: "/docs", Summary: "Swagger UI for this document",
+			Responses: []apiResponse{{Status: http.StatusOK, Description: "HTML page", Body: htmlPage("")}}},
+		{Method: "GET", Path: "/docs/:file", Summary: "Swagger UI files loaded by /docs",
+			Params: []apiParam{{Name: "file", In: "path", Description: "swagger-ui.css or swagger-ui-bundle.js"}},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "The file", Body: docsAsset("")},
+				{Status: http.StatusNotFound, Description: "Unknown file", Body: ""},
+			}},
+		{Method: "POST", Path: "/transaction", Scope: entity.ScopeTxWrite, Summary: "Submit a transaction",
+			Params: []apiParam{{Name: "Idempotency-Key", In: "header", Description: "Replays the original answer for a retried request; client_id in the body is equivalent"}},
+			Body:   transaction{},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "Replay of an earlier request with the same idempotency key", Body: transactionView{}},
+				{Status: http.StatusAccepted, Description: "Accepted, see the Location header", Body: acceptedView{}},
+				{Status: http.StatusBadRequest, Description: "Invalid request", Body: problem{}},
+				{Status: http.StatusConflict, Description: "Idempotency key used by a different request", Body: problem{}},
+				{Status: http.StatusInternalServerError, Description: "Repository failure", Body: problem{}},
+			}},
+	}
+	routes = append(routes, transactionRoutes("")...)
+	routes = append(routes, transactionRoutes("/v1")...)
+	return append(routes,
+		apiRoute{Method: "GET", Path: "/v1/transactions/by-hash/:hash", Scope: entity.ScopeTxRead, Summary: "Find the transaction that broadcast a hash, including replaced attempts",
+			Params: []apiParam{{Name: "hash", In: "path", Description: "0x-prefixed transaction hash"}},
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "The transaction", Body: transactionView{}},
+				notFound, failed,
+			}},
+		apiRoute{Method: "POST", Path: "/v1/transactions/:id/speedup", Scope: entity.ScopeTxWrite, Summary: "Re-broadcast a sent transaction with higher fees",
+			Params: []apiParam{idParam},
+			Responses: []apiResponse{
+				{Status: http.StatusAccepted, Description: "The transaction with its new attempt", Body: transactionView{}},
+				notFound, conflict, noSigner, failed,
+			}},
+		apiRoute{Method: "GET", Path: "/v1/admin/limits", Scope: entity.ScopeAdmin, Summary: "Report the rate limits and daily value quotas and their current usage",
+			Responses: []apiResponse{
+				{Status: http.StatusOK, Description: "Buckets that are not full and quotas used today", Body: limitsView{}},
+			}},
+	)
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath turns a fiber path into an OpenAPI one: /a/:id becomes /a/{id}.
+func openAPIPath(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// openAPIDocument builds the OpenAPI 3 document of routes.
+func openAPIDocument(routes []apiRoute) map[string]interface{} {
+	schemas := schemaRegistry{}
+	paths := map[string]map[string]interface{}{}
+	for _, r := range routes {
+		op := map[string]interface{}{
+			"summary":     r.Summary,
+			"operationId": operationID(r),
+		}
+		if r.Deprecated {
+			op["deprecated"] = true
+		}
+		responseList := r.Responses
+		if r.Scope != "" {
+			op["description"] = "Requires the " + r.Scope + " scope when authentication is enabled."
+			op["security"] = []map[string][]string{{"ApiKeyAuth": {}}, {"BearerAuth": {}}}
+			responseList = append(append([]apiResponse{}, r.Responses...), authResponses...)
+		}
+		var params []map[string]interface{}
+		for _, p := range r.Params {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.In == "path",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if r.Body != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  schemas.content(r.Body),
+			}
+		}
+		responses := map[string]interface{}{}
+		for _, resp := range responseList {
+			entry := map[string]interface{}{"description": resp.Description}
+			if resp.Body != nil {
+				entry["content"] = schemas.content(resp.Body)
+			}
+			responses[strconv.Itoa(resp.Status)] = entry
+		}
+		op["responses"] = responses
+
+		path := openAPIPath(r.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(r.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "ChainConnector API",
+			"version":     "1.0.0",
+			"description": "Submit EVM transactions and follow them until they are final. Amounts are decimal strings in wei.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"ApiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"BearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT",
+					"description": "HS256 or RS256 JWT with exp and a scope or scopes claim; an API key is also accepted"},
+			},
+		},
+	}
+}
+
+// operationID names an operation after its method and path, e.g.
+// getV1TransactionsById.
+func operationID(r apiRoute) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.Method))
+	for _, part := range strings.FieldsFunc(r.Path, func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != ':' }) {
+		if name, ok := strings.CutPrefix(part, ":"); ok {
+			b.WriteString("By")
+			part = name
+		}
+		b.WriteString(exported(part))
+	}
+	return b.String()
+}
+
+// schemaRegistry collects the component schemas of named struct types.
+type schemaRegistry map[string]interface{}
+
+// content describes a body of the type of example per media type.
+func (s schemaRegistry) content(example interface{}) map[string]interface{} {
+	mediaType := fiber.MIMEApplicationJSON
+	switch example.(type) {
+	case string:
+		mediaType = fiber.MIMETextPlain
+	case htmlPage:
+		mediaType = fiber.MIMETextHTML
+	case problem:
+		mediaType = problemContentType
+	case docsAsset:
+		file := map[string]interface{}{"schema": s.schema(reflect.TypeOf(example))}
+		return map[string]interface{}{"text/css": file, fiber.MIMETextJavaScript: file}
+	}
+	return map[string]interface{}{
+		mediaType: map[string]interface{}{"schema": s.schema(reflect.TypeOf(example))},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the JSON schema of values of t as encoding/json writes
+// them. Named structs become components and are referenced.
+func (s schemaRegistry) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return s.schema(t.Elem())
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := exported(t.Name())
+		if _, ok := s[name]; !ok {
+			s[name] = nil // placeholder against recursion
+			s[name] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": true}
+	case reflect.Struct:
+		return s.object(t)
+	}
+	return map[string]interface{}{}
+}
+
+// object describes a struct from its json tags. Fields without omitempty
+// are always written and therefore required.
+func (s schemaRegistry) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = s.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	obj := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if required != nil {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// exported upper-cases the first letter of name.
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// openAPIJSON is the encoded document of apiRoutes, built once.
+var openAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	return json.Marshal(openAPIDocument(apiRoutes()))
+})
+
+func (f *FiberServer) handlerOpenAPI(c *fiber.Ctx) error {
+	doc, err := openAPIJSON()
+	if err != nil {
+		f.logger.Error("encode openapi document failed", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("OpenAPI document unavailable")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(doc)
+}
+
+// swaggerUI renders /openapi.json with the Swagger UI bundle served under
+// /docs, so the page loads no code from third parties.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>ChainConnector API</title>
+  <link rel="stylesheet" href="/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>
+`
+
+// docsAssets maps the Swagger UI files the docs page loads to their content
+// types. They are embedded from swagger-ui-dist 5.18.2, pinned by the
+// github.com/swaggo/files/v2 version in go.mod.
+var docsAssets = map[string]string{
+	"swagger-ui.css":       "text/css; charset=utf-8",
+	"swagger-ui-bundle.js": fiber.MIMETextJavaScriptCharsetUTF8,
+}
+
+// handlerDocsAsset serves one of docsAssets.
+func (f *FiberServer) handlerDocsAsset(c *fiber.Ctx) error {
+	name := c.Params("file")
+	contentType, ok := docsAssets[name]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+	asset, err := fs.ReadFile(swaggerFiles.FS, name)
+	if err != nil {
+		f.logger.Error("read docs asset failed", zap.String("file", name), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Docs asset unavailable")
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(asset)
+}
+
+func (f *FiberServer) handlerDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(swaggerUI)
+}
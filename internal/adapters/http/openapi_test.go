@@ -0,0 +1,298 @@
+package http
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type jsonObject = map[string]interface{}
+
+func fetchOpenAPI(t *testing.T, app *fiber.App) jsonObject {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	resp, err := app.Test(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /openapi.json: %v %v", resp, err)
+	}
+	var doc jsonObject
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	return doc
+}
+
+func operation(doc jsonObject, method, route string) jsonObject {
+	ops, _ := doc["paths"].(jsonObject)[openAPIPath(route)].(jsonObject)
+	op, _ := ops[strings.ToLower(method)].(jsonObject)
+	return op
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	s := NewFiberServer(config.Default(), zap.NewNop(), &service.TransactionService{}, nil, nil)
+	app := s.app.(*fiber.App)
+	doc := fetchOpenAPI(t, app)
+	if doc["openapi"] != "3.0.3" {
+		t.Fatalf("unexpected version %v", doc["openapi"])
+	}
+
+	registered := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		registered[r.Method+" "+openAPIPath(r.Path)] = true
+		if operation(doc, r.Method, r.Path) == nil {
+			t.Errorf("%s %s is registered but missing from the OpenAPI document", r.Method, r.Path)
+		}
+	}
+	ids := map[string]bool{}
+	for path, ops := range doc["paths"].(jsonObject) {
+		for method, op := range ops.(jsonObject) {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", method, path)
+			}
+			id := op.(jsonObject)["operationId"].(string)
+			if ids[id] {
+				t.Errorf("duplicate operationId %s", id)
+			}
+			ids[id] = true
+		}
+	}
+
+	// the request schema follows the struct the handler decodes
+	body := doc["components"].(jsonObject)["schemas"].(jsonObject)["Transaction"].(jsonObject)
+	if req := body["required"].([]interface{}); len(req) != 2 || req[0] != "to" || req[1] != "amount" {
+		t.Fatalf("unexpected required fields %v", req)
+	}
+	if len(body["properties"].(jsonObject)) != 9 || body["additionalProperties"] != false {
+		t.Fatalf("unexpected request schema %v", body)
+	}
+}
+
+// TestOpenAPIResponsesMatchHandlers calls every documented route and checks
+// that the status, media type and body of each answer are documented.
+func TestOpenAPIResponsesMatchHandlers(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	ctx := context.Background()
+	to := lowerAddr
+	for _, tx := range []*entity.Transaction{
+		{ID: "sent", Chain: "ETH", From: lowerAddr, To: &to, Value: big.NewInt(1), GasPrice: big.NewInt(10),
+			TxHash: "0xa", Status: entity.TxStatusSent, Attempts: []entity.TxAttempt{{TxHash: "0xa", GasPrice: big.NewInt(10)}}},
+		{ID: "sent2", Chain: "ETH", From: lowerAddr, TxHash: "0xb", GasPrice: big.NewInt(10), Status: entity.TxStatusSent},
+		{ID: "pending", Chain: "ETH", Status: entity.TxStatusPending},
+		{ID: "pending2", Chain: "ETH", Status: entity.TxStatusPending},
+	} {
+		if err := repo.Save(ctx, tx); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	txSvc := service.NewTransactionService(repo, &stubChain{hash: "0xc"}, stubSigner{}, zap.NewNop())
+	// generous limits, so that the usage report lists buckets and a quota
+	limits := service.NewLimiter(service.LimitPolicy{
+		PerKey:     service.Rate{PerSecond: 1, Burst: 100},
+		PerSender:  service.Rate{PerSecond: 1, Burst: 100},
+		DailyValue: map[string]*big.Int{"ETH": big.NewInt(1000)},
+	})
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, limits).app.(*fiber.App)
+	doc := fetchOpenAPI(t, app)
+
+	submit := `{"to":"` + lowerAddr + `","amount":"5","client_id":"k1"}`
+	calls := []struct {
+		method, route, url, body string
+	}{
+		{"GET", "/health", "/health", ""},
+		{"GET", "/openapi.json", "/openapi.json", ""},
+		{"GET", "/docs", "/docs", ""},
+		{"GET", "/docs/:file", "/docs/swagger-ui.css", ""},
+		{"GET", "/docs/:file", "/docs/swagger-ui-bundle.js", ""},
+		{"GET", "/docs/:file", "/docs/index.html", ""},
+		{"POST", "/transaction", "/transaction", submit},
+		{"POST", "/transaction", "/transaction", `{"to":"0x1"}`},
+		{"POST", "/transaction", "/transaction", submit},
+		{"POST", "/transaction", "/transaction", strings.Replace(submit, `"5"`, `"6"`, 1)},
+	}
+	for _, prefix := range []string{"", "/v1"} {
+		cancelled := map[string]string{"": "pending", "/v1": "pending2"}[prefix]
+		replaced := map[string]string{"": "sent", "/v1": "sent2"}[prefix]
+		calls = append(calls, []struct{ method, route, url, body string }{
+			{"GET", prefix + "/transactions", prefix + "/transactions?limit=2", ""},
+			{"GET", prefix + "/transactions", prefix + "/transactions?limit=0", ""},
+			{"GET", prefix + "/transactions/:id", prefix + "/transactions/sent", ""},
+			{"GET", prefix + "/transactions/:id", prefix + "/transactions/missing", ""},
+			{"POST", prefix + "/transactions/:id/cancel", prefix + "/transactions/" + cancelled + "/cancel", ""},
+			{"POST", prefix + "/transactions/:id/cancel", prefix + "/transactions/" + replaced + "/cancel", ""},
+			{"POST", prefix + "/transactions/:id/cancel", prefix + "/transactions/" + cancelled + "/cancel", ""},
+			{"POST", prefix + "/transactions/:id/cancel", prefix + "/transactions/missing/cancel", ""},
+			{"GET", prefix + "/transactions/:id/history", prefix + "/transactions/" + cancelled + "/history", ""},
+			{"GET", prefix + "/transactions/:id/history", prefix + "/transactions/missing/history", ""},
+		}...)
+	}
+	calls = append(calls, []struct{ method, route, url, body string }{
+		{"POST", "/v1/transactions/:id/speedup", "/v1/transactions/sent/speedup", ""},
+		{"POST", "/v1/transactions/:id/speedup", "/v1/transactions/pending/speedup", ""},
+		{"POST", "/v1/transactions/:id/speedup", "/v1/transactions/missing/speedup", ""},
+		{"GET", "/v1/transactions/by-hash/:hash", "/v1/transactions/by-hash/0xa", ""},
+		{"GET", "/v1/transactions/by-hash/:hash", "/v1/transactions/by-hash/0xnone", ""},
+		{"GET", "/v1/admin/limits", "/v1/admin/limits", ""},
+	}...)
+
+	exercised := map[string]bool{}
+	for _, call := range calls {
+		name := call.method + " " + call.url
+		op := operation(doc, call.method, call.route)
+		if op == nil {
+			t.Fatalf("%s: %s %s is not documented", name, call.method, call.route)
+		}
+		exercised[call.method+" "+call.route] = true
+
+		req, _ := http.NewRequest(call.method, call.url, bytes.NewReader([]byte(call.body)))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		documented, _ := op["responses"].(jsonObject)[strconv.Itoa(resp.StatusCode)].(jsonObject)
+		if documented == nil {
+			t.Errorf("%s: status %d is not documented", name, resp.StatusCode)
+			continue
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		content, _ := documented["content"].(jsonObject)
+		mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+		media, _ := content[mediaType].(jsonObject)
+		if media == nil {
+			t.Errorf("%s: %d answered %s, documented %v", name, resp.StatusCode, mediaType, content)
+			continue
+		}
+		if mediaType == fiber.MIMEApplicationJSON || mediaType == problemContentType {
+			var body interface{}
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("%s: decode: %v", name, err)
+			}
+			checkSchema(t, doc, media["schema"].(jsonObject), body, name)
+		}
+	}
+	for _, r := range apiRoutes() {
+		if !exercised[r.Method+" "+r.Path] {
+			t.Errorf("%s %s is not exercised", r.Method, r.Path)
+		}
+	}
+}
+
+// checkSchema reports where v does not follow schema.
+func checkSchema(t *testing.T, doc jsonObject, schema jsonObject, v interface{}, at string) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		schema = doc["components"].(jsonObject)["schemas"].(jsonObject)[name].(jsonObject)
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(jsonObject)
+		if !ok {
+			t.Errorf("%s: expected an object, got %v", at, v)
+			return
+		}
+		props, _ := schema["properties"].(jsonObject)
+		for key, val := range obj {
+			prop, ok := props[key].(jsonObject)
+			switch {
+			case ok:
+				checkSchema(t, doc, prop, val, at+"."+key)
+			case schema["additionalProperties"] == false:
+				t.Errorf("%s: undocumented property %q", at, key)
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := obj[key.(string)]; !ok {
+				t.Errorf("%s: missing required property %q", at, key)
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			t.Errorf("%s: expected an array, got %v", at, v)
+			return
+		}
+		for i, item := range arr {
+			checkSchema(t, doc, schema["items"].(jsonObject), item, at+"["+strconv.Itoa(i)+"]")
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			t.Errorf("%s: expected a string, got %v", at, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != math.Trunc(n) {
+			t.Errorf("%s: expected an integer, got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			t.Errorf("%s: expected a boolean, got %v", at, v)
+		}
+	}
+}
+
+func TestDocsServeEmbeddedAssets(t *testing.T) {
+	app := NewFiberServer(config.Default(), zap.NewNop(), &service.TransactionService{}, nil, nil).app.(*fiber.App)
+	get := func(url string) (*http.Response, string) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, page := get("/docs")
+	if strings.Contains(page, "https://") {
+		t.Fatalf("expected the docs page to load nothing from other hosts:\n%s", page)
+	}
+	for file, contentType := range docsAssets {
+		if !strings.Contains(page, `"/docs/`+file+`"`) {
+			t.Errorf("expected the docs page to load %s", file)
+		}
+		resp, body := get("/docs/" + file)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != contentType || len(body) == 0 {
+			t.Errorf("%s: got %d %q with %d bytes", file, resp.StatusCode, resp.Header.Get("Content-Type"), len(body))
+		}
+	}
+	if resp, _ := get("/docs/swagger-initializer.js"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected only the docs assets to be served, got %d", resp.StatusCode)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	s := schemaRegistry{}
+	ref := s.schema(reflect.TypeOf(historyView{}))
+	if ref["$ref"] != "#/components/schemas/HistoryView" {
+		t.Fatalf("expected a component reference, got %v", ref)
+	}
+	change := s["StatusChange"].(map[string]interface{})
+	props := change["properties"].(map[string]interface{})
+	if props["at"].(map[string]interface{})["format"] != "date-time" {
+		t.Fatalf("expected times as date-time strings, got %v", props["at"])
+	}
+	if req := change["required"].([]string); len(req) != 4 {
+		t.Fatalf("expected reason to be optional, got %v", req)
+	}
+	if id := operationID(apiRoute{Method: "GET", Path: "/v1/transactions/:id/history"}); id != "getV1TransactionsByIdHistory" {
+		t.Fatalf("unexpected operationId %s", id)
+	}
+}
@@ -1,26 +1,157 @@
 package http
 
 import (
+	"ChainConnector/internal/config"
 	"ChainConnector/internal/domain/entity"
 	"ChainConnector/internal/domain/ports"
 	"ChainConnector/internal/domain/service"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+// transaction is the body of POST /transaction. Fields marked omitempty are
+// optional.
 type transaction struct {
-	From     string `json:"from"`
+	From     string `json:"from,omitempty"`
 	To       string `json:"to"`
-	Chain    string `json:"chain"`
+	Chain    string `json:"chain,omitempty"`
 	Amount   string `json:"amount"`
-	Gas      string `json:"gas"`
-	GasPrice string `json:"gas_price"`
+	Gas      string `json:"gas,omitempty"`
+	GasPrice string `json:"gas_price,omitempty"`
+	Data     string `json:"data,omitempty"`
+	FeeTier  string `json:"fee_tier,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header and client_id field.
+const maxIdempotencyKeyLen = 255
+
+// statusChange is the JSON form of an entity.StatusChange with statuses as text.
+type statusChange struct {
+	At     time.Time `json:"at"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Actor  string    `json:"actor"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// acceptedView answers a submitted transaction.
+type acceptedView struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// cancelView answers a cancellation.
+type cancelView struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	TxHash string `json:"tx_hash"`
+}
+
+// historyView lists the status changes of a transaction.
+type historyView struct {
+	ID     string         `json:"id"`
+	Events []statusChange `json:"events"`
+}
+
+// pageView is one page of a transaction listing.
+type pageView struct {
+	Transactions []transactionView `json:"transactions"`
+	NextCursor   string            `json:"next_cursor"`
+}
+
+// transactionView is the JSON form of an entity.Transaction returned by the
+// query endpoints. Amounts are decimal strings so clients keep full precision.
+type transactionView struct {
+	ID                   string        `json:"id"`
+	Chain                string        `json:"chain,omitempty"`
+	From                 string        `json:"from,omitempty"`
+	To                   string        `json:"to,omitempty"`
+	Value                string        `json:"value,omitempty"`
+	Nonce                uint64        `json:"nonce"`
+	Gas                  uint64        `json:"gas"`
+	GasPrice             string        `json:"gas_price,omitempty"`
+	MaxFeePerGas         string        `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string        `json:"max_priority_fee_per_gas,omitempty"`
+	FeeTier              string        `json:"fee_tier,omitempty"`
+	TxHash               string        `json:"tx_hash,omitempty"`
+	Status               string        `json:"status"`
+	ErrorMessage         string        `json:"error_message,omitempty"`
+	RequestedBy          string        `json:"requested_by,omitempty"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
+	SentAt               *time.Time    `json:"sent_at,omitempty"`
+	ConfirmedAt          *time.Time    `json:"confirmed_at,omitempty"`
+	Attempts             []attemptView `json:"attempts,omitempty"`
+}
+
+// attemptView is the JSON form of an entity.TxAttempt.
+type attemptView struct {
+	TxHash               string    `json:"tx_hash"`
+	GasPrice             string    `json:"gas_price,omitempty"`
+	MaxFeePerGas         string    `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string    `json:"max_priority_fee_per_gas,omitempty"`
+	SentAt               time.Time `json:"sent_at"`
+	Status               string    `json:"status"`
+	Cancel               bool      `json:"cancel,omitempty"`
+}
+
+func newTransactionView(tx *entity.Transaction) transactionView {
+	v := transactionView{
+		ID:                   tx.ID,
+		Chain:                tx.Chain,
+		From:                 tx.From,
+		Value:                decimal(tx.Value),
+		Nonce:                tx.Nonce,
+		Gas:                  tx.Gas,
+		GasPrice:             decimal(tx.GasPrice),
+		MaxFeePerGas:         decimal(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: decimal(tx.MaxPriorityFeePerGas),
+		FeeTier:              string(tx.FeeTier),
+		TxHash:               tx.TxHash,
+		Status:               tx.Status.String(),
+		RequestedBy:          tx.RequestedBy,
+		CreatedAt:            tx.CreatedAt,
+		UpdatedAt:            tx.UpdatedAt,
+		SentAt:               tx.SentAt,
+		ConfirmedAt:          tx.ConfirmedAt,
+	}
+	if tx.To != nil {
+		v.To = *tx.To
+	}
+	if tx.ErrorMessage != nil {
+		v.ErrorMessage = *tx.ErrorMessage
+	}
+	for _, a := range tx.Attempts {
+		v.Attempts = append(v.Attempts, attemptView{
+			TxHash:               a.TxHash,
+			GasPrice:             decimal(a.GasPrice),
+			MaxFeePerGas:         decimal(a.MaxFeePerGas),
+			MaxPriorityFeePerGas: decimal(a.MaxPriorityFeePerGas),
+			SentAt:               a.SentAt,
+			Status:               a.Status.String(),
+			Cancel:               a.Cancel,
+		})
+	}
+	return v
+}
+
+func decimal(b *big.Int) string {
+	if b == nil {
+		return ""
+	}
+	return b.String()
 }
 
 // FiberServer is an fx-friendly wrapper that contains the Fiber app and
@@ -31,14 +162,17 @@ type fiberApp interface {
 	Shutdown() error
 	Get(string, ...fiber.Handler) fiber.Router
 	Post(string, ...fiber.Handler) fiber.Router
+	Group(string, ...fiber.Handler) fiber.Router
 }
 
 type FiberServer struct {
-	app    fiberApp
-	addr   string
-	txSvc  *service.TransactionService
-	logger *zap.Logger
-	bus    ports.EventBus
+	app       fiberApp
+	addr      string
+	txSvc     *service.TransactionService
+	logger    *zap.Logger
+	validator txValidator
+	auth      *authenticator
+	limits    *service.Limiter
 }
 
 func CreateFiberServer() *fiber.App {
@@ -50,11 +184,17 @@ func CreateFiberServer() *fiber.App {
 	return app
 }
 
-// NewFiberServer constructs a FiberServer for fx. It accepts a zap.Logger
-// and sets a default address. Modify to read config when available.
-func NewFiberServer(logger *zap.Logger, txSvc *service.TransactionService, bus ports.EventBus) *FiberServer {
+// NewFiberServer constructs a FiberServer for fx, listening on cfg.Server.Addr.
+// keys looks up API keys that are not in the configuration and may be nil; a
+// nil limiter admits every request.
+func NewFiberServer(cfg *config.Config, logger *zap.Logger, txSvc *service.TransactionService,
+	keys ports.APIKeyStore, limits *service.Limiter) *FiberServer {
+	if limits == nil {
+		limits = service.NewLimiter(service.LimitPolicy{})
+	}
 	app := CreateFiberServer()
-	srv := &FiberServer{app: app, addr: ":3000", logger: logger, txSvc: txSvc, bus: bus}
+	srv := &FiberServer{app: app, addr: cfg.Server.Addr, logger: logger, txSvc: txSvc,
+		validator: newTxValidator(cfg), auth: newAuthenticator(cfg.Auth, keys, logger), limits: limits}
 	// register routes so router() is used
 	srv.router()
 	return srv
@@ -86,9 +226,36 @@ func (f *FiberServer) Start(lc fx.Lifecycle) {
 	})
 }
 
+// router registers the routes. Health and documentation are public; the
+// transaction routes require the tx:read or tx:write scope and the limits
+// route the admin scope when auth is enabled. Every scoped route is rate
+// limited per API client.
 func (f *FiberServer) router() {
+	read := []fiber.Handler{f.auth.require(entity.ScopeTxRead), f.limitKey}
+	write := []fiber.Handler{f.auth.require(entity.ScopeTxWrite), f.limitKey}
+	admin := []fiber.Handler{f.auth.require(entity.ScopeAdmin), f.limitKey}
+	with := func(guards []fiber.Handler, h fiber.Handler) []fiber.Handler {
+		return append(append([]fiber.Handler{}, guards...), h)
+	}
+
 	f.app.Get("/health", f.handlerHeatlCheck)
-	f.app.Post("/transaction", f.handlerTransaction)
+	f.app.Get("/openapi.json", f.handlerOpenAPI)
+	f.app.Get("/docs", f.handlerDocs)
+	f.app.Get("/docs/:file", f.handlerDocsAsset)
+	f.app.Post("/transaction", with(write, f.handlerTransaction)...)
+	f.app.Get("/transactions", with(read, f.handlerListTransactions)...)
+	f.app.Get("/transactions/:id", with(read, f.handlerGetTransaction)...)
+	f.app.Post("/transactions/:id/cancel", with(write, f.handlerCancelTransaction)...)
+	f.app.Get("/transactions/:id/history", with(read, f.handlerTransactionHistory)...)
+
+	v1 := f.app.Group("/v1")
+	v1.Get("/transactions", with(read, f.handlerListTransactions)...)
+	v1.Get("/transactions/by-hash/:hash", with(read, f.handlerTransactionByHash)...)
+	v1.Get("/transactions/:id", with(read, f.handlerGetTransaction)...)
+	v1.Get("/transactions/:id/history", with(read, f.handlerTransactionHistory)...)
+	v1.Post("/transactions/:id/speedup", with(write, f.handlerSpeedUpTransaction)...)
+	v1.Post("/transactions/:id/cancel", with(write, f.handlerCancelTransaction)...)
+	v1.Get("/admin/limits", with(admin, f.handlerLimits)...)
 }
 
 // HANDLERS
@@ -96,35 +263,281 @@ func (f *FiberServer) handlerHeatlCheck(c *fiber.Ctx) error {
 	return c.SendString("OK")
 }
 
+// handlerTransaction validates a submitted transaction and stores it as
+// pending; the service signs and broadcasts it once its TxCreated event is
+// published. Invalid requests are answered with an RFC 7807 problem listing
+// every rejected field.
+//
+// The transaction is stored before the request is answered, so its
+// idempotency key is claimed by then: a retry is answered with the stored
+// transaction and a different request with the same key with 409, however
+// quickly either follows.
 func (f *FiberServer) handlerTransaction(c *fiber.Ctx) error {
-	var body transaction
+	body, errs := f.validator.decode(c.Body())
+	if errs != nil {
+		return sendProblem(c, fiber.StatusBadRequest, "Invalid request body", errs...)
+	}
+	tx, errs := f.validator.validate(body)
+	// without a From the signer's address is the sender; without a signer
+	// the transaction fails later and is limited under its From or the
+	// empty sender
+	sender, err := f.txSvc.Sender(c.UserContext(), tx)
+	if errors.Is(err, service.ErrForeignSender) && !slices.ContainsFunc(errs, func(e fieldError) bool { return e.Field == "from" }) {
+		errs = append(errs, fieldError{Field: "from", Message: "must be the signer's address " + sender})
+	}
+	key, err := idempotencyKey(c.Get("Idempotency-Key"), body.ClientID)
+	if err != nil {
+		errs = append(errs, fieldError{Field: "client_id", Message: err.Error()})
+	}
+	if errs != nil {
+		return sendProblem(c, fiber.StatusBadRequest, "Invalid transaction", errs...)
+	}
+	tx.ID = uuid.NewString()
+	tx.IdempotencyKey = key
+	if p := principalOf(c); p != nil {
+		tx.RequestedBy = p.Subject
+	}
+
+	// a retried request answers with the transaction it created the first time
+	existing, err := f.txSvc.FindIdempotent(c.UserContext(), tx)
+	if err != nil || existing != nil {
+		return f.sendIdempotent(c, existing, err)
+	}
 
-	err := json.Unmarshal(c.Body(), &body)
+	release, err := f.limits.Reserve(sender, tx.Chain, tx.Value)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+		return sendLimited(c, err)
 	}
 
-	value := new(big.Int)
-	value.SetString(body.Amount, 10)
+	// a concurrent request with the same key may have been stored since the
+	// lookup; CreateTransaction then replaces tx with it
+	id := tx.ID
+	if err := f.txSvc.CreateTransaction(c.UserContext(), tx); err != nil {
+		release()
+		if errors.Is(err, service.ErrIdempotencyKeyReused) {
+			return f.sendIdempotent(c, nil, err)
+		}
+		f.logger.Error("create transaction failed", zap.String("tx_id", id), zap.Error(err))
+		return sendProblem(c, fiber.StatusInternalServerError, "Transaction not accepted")
+	}
+	if tx.ID != id {
+		// nothing new was stored, so the reservation is not spent
+		release()
+		return f.sendIdempotent(c, tx, nil)
+	}
 
-	gasPrice := new(big.Int)
-	gasPrice.SetString(body.GasPrice, 10)
+	c.Location("/transactions/" + tx.ID)
+	return c.Status(fiber.StatusAccepted).JSON(acceptedView{
+		ID:     tx.ID,
+		Status: entity.TxStatusPending.String(),
+	})
+}
 
-	gas, err := strconv.ParseUint(body.Gas, 10, 64)
+// sendIdempotent answers a request whose idempotency key is already taken:
+// with existing when it was created by the same request, with 409 when err
+// is ErrIdempotencyKeyReused and with 500 for other lookup errors.
+func (f *FiberServer) sendIdempotent(c *fiber.Ctx, existing *entity.Transaction, err error) error {
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		return sendProblem(c, fiber.StatusConflict, err.Error())
+	case err != nil:
+		f.logger.Error("idempotency lookup failed", zap.Error(err))
+		return sendProblem(c, fiber.StatusInternalServerError, "Transaction lookup failed")
+	}
+	c.Location("/transactions/" + existing.ID)
+	return c.JSON(newTransactionView(existing))
+}
+
+// handlerGetTransaction returns a single transaction.
+func (f *FiberServer) handlerGetTransaction(c *fiber.Ctx) error {
+	tx, err := f.txSvc.GetTransaction(c.UserContext(), c.Params("id"))
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).SendString("Transaction not found")
+	case err != nil:
+		f.logger.Error("get transaction failed", zap.String("tx_id", c.Params("id")), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Get transaction failed")
+	}
+	return c.JSON(newTransactionView(tx))
+}
+
+// idempotencyKey picks the request's idempotency key from the header or,
+// failing that, the client_id field. Both may be given if they agree.
+func idempotencyKey(header, clientID string) (string, error) {
+	header, clientID = strings.TrimSpace(header), strings.TrimSpace(clientID)
+	if header != "" && clientID != "" && header != clientID {
+		return "", errors.New("Idempotency-Key header and client_id differ")
+	}
+	key := header
+	if key == "" {
+		key = clientID
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return "", fmt.Errorf("idempotency key longer than %d characters", maxIdempotencyKeyLen)
+	}
+	return key, nil
+}
+
+// handlerTransactionByHash returns the transaction that broadcast a hash,
+// including replaced attempts.
+func (f *FiberServer) handlerTransactionByHash(c *fiber.Ctx) error {
+	tx, err := f.txSvc.FindByHash(c.UserContext(), c.Params("hash"))
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).SendString("Transaction not found")
+	case err != nil:
+		f.logger.Error("find by hash failed", zap.String("tx_hash", c.Params("hash")), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Find by hash failed")
+	}
+	return c.JSON(newTransactionView(tx))
+}
+
+// handlerSpeedUpTransaction re-broadcasts a sent transaction with higher fees
+// and answers 202 with the transaction and its new attempt.
+func (f *FiberServer) handlerSpeedUpTransaction(c *fiber.Ctx) error {
+	ctx, id := c.UserContext(), c.Params("id")
+	err := f.txSvc.SpeedUp(ctx, id)
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).SendString("Transaction not found")
+	case errors.Is(err, service.ErrNotSent), errors.Is(err, entity.ErrInvalidTransition),
+		errors.Is(err, ports.ErrConflict):
+		return c.Status(fiber.StatusConflict).SendString(err.Error())
+	case errors.Is(err, service.ErrSignerUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	case err != nil:
+		f.logger.Error("speed up failed", zap.String("tx_id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Speed up failed")
+	}
+
+	tx, err := f.txSvc.GetTransaction(ctx, id)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid gas value")
+		f.logger.Error("get transaction failed", zap.String("tx_id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Get transaction failed")
 	}
+	return c.Status(fiber.StatusAccepted).JSON(newTransactionView(tx))
+}
 
-	tx := &entity.Transaction{
-		To:       &body.To,
-		Chain:    body.Chain,
-		Value:    value,
-		Gas:      gas,
-		GasPrice: gasPrice,
+// handlerCancelTransaction cancels a pending transaction (200) or broadcasts a
+// cancelling replacement for a sent one (202).
+func (f *FiberServer) handlerCancelTransaction(c *fiber.Ctx) error {
+	tx, err := f.txSvc.Cancel(c.UserContext(), c.Params("id"))
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).SendString("Transaction not found")
+	case errors.Is(err, service.ErrNotCancellable), errors.Is(err, entity.ErrInvalidTransition),
+		errors.Is(err, ports.ErrConflict):
+		return c.Status(fiber.StatusConflict).SendString(err.Error())
+	case errors.Is(err, service.ErrSignerUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	case err != nil:
+		f.logger.Error("cancel failed", zap.String("tx_id", c.Params("id")), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("Cancel failed")
 	}
 
-	f.bus.Publish(context.Background(), "transaction.created", tx)
+	status := fiber.StatusOK
+	if tx.Status != entity.TxStatusCancelled {
+		status = fiber.StatusAccepted
+	}
+	return c.Status(status).JSON(cancelView{
+		ID:     tx.ID,
+		Status: tx.Status.String(),
+		TxHash: tx.TxHash,
+	})
+}
 
-	return c.SendStatus(fiber.StatusAccepted)
+// handlerTransactionHistory returns the status changes of a transaction,
+// oldest first.
+func (f *FiberServer) handlerTransactionHistory(c *fiber.Ctx) error {
+	changes, err := f.txSvc.History(c.UserContext(), c.Params("id"))
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).SendString("Transaction not found")
+	case err != nil:
+		f.logger.Error("history failed", zap.String("tx_id", c.Params("id")), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("History failed")
+	}
+
+	events := make([]statusChange, 0, len(changes))
+	for _, ch := range changes {
+		events = append(events, statusChange{
+			At:     ch.At,
+			From:   ch.From.String(),
+			To:     ch.To.String(),
+			Actor:  ch.Actor,
+			Reason: ch.Reason,
+		})
+	}
+	return c.JSON(historyView{
+		ID:     c.Params("id"),
+		Events: events,
+	})
+}
 
+// handlerListTransactions returns one page of transactions, newest first.
+// Query parameters: status (comma separated), chain, from, to,
+// created_after and created_before (RFC 3339), limit and cursor.
+func (f *FiberServer) handlerListTransactions(c *fiber.Ctx) error {
+	filter, err := parseTxFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := f.txSvc.List(c.UserContext(), filter)
+	switch {
+	case errors.Is(err, ports.ErrInvalidCursor):
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	case err != nil:
+		f.logger.Error("list failed", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).SendString("List failed")
+	}
+
+	items := make([]transactionView, 0, len(page.Transactions))
+	for _, tx := range page.Transactions {
+		items = append(items, newTransactionView(tx))
+	}
+	return c.JSON(pageView{
+		Transactions: items,
+		NextCursor:   page.NextCursor,
+	})
+}
+
+func parseTxFilter(c *fiber.Ctx) (ports.TxFilter, error) {
+	filter := ports.TxFilter{
+		Chain:  c.Query("chain"),
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		Cursor: c.Query("cursor"),
+	}
+	if statuses := c.Query("status"); statuses != "" {
+		for _, name := range strings.Split(statuses, ",") {
+			status, err := entity.ParseTxStatus(name)
+			if err != nil {
+				return filter, err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: expected an RFC 3339 time", p.name)
+			}
+			*p.dst = t
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
 }
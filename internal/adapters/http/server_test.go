@@ -1,13 +1,20 @@
 package http
 
 import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
 	"ChainConnector/internal/domain/ports"
 	"ChainConnector/internal/domain/service"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
@@ -18,10 +25,11 @@ var capturedHook fx.Hook
 
 type fakeApp struct{}
 
-func (f *fakeApp) Listen(_ string) error                          { return nil }
-func (f *fakeApp) Shutdown() error                                { return nil }
-func (f *fakeApp) Get(_ string, _ ...fiber.Handler) fiber.Router  { return nil }
-func (f *fakeApp) Post(_ string, _ ...fiber.Handler) fiber.Router { return nil }
+func (f *fakeApp) Listen(_ string) error                           { return nil }
+func (f *fakeApp) Shutdown() error                                 { return nil }
+func (f *fakeApp) Get(_ string, _ ...fiber.Handler) fiber.Router   { return nil }
+func (f *fakeApp) Post(_ string, _ ...fiber.Handler) fiber.Router  { return nil }
+func (f *fakeApp) Group(_ string, _ ...fiber.Handler) fiber.Router { return nil }
 
 type fakeLc struct{}
 
@@ -48,7 +56,7 @@ func TestFiberServer_StartRegistersHooks(t *testing.T) {
 	// here to avoid lifecycle initialization complexity in unit tests.
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	s := NewFiberServer(logger, txSvc, nil)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 
 	// Use zero-value lifecycle; Start should handle nil Append without panicking.
 	var lc fx.Lifecycle
@@ -58,19 +66,22 @@ func TestFiberServer_StartRegistersHooks(t *testing.T) {
 func TestNewFiberServer_ConstructsWithLogger(t *testing.T) {
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	s := NewFiberServer(logger, txSvc, nil)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	if s == nil || s.app == nil {
 		t.Fatalf("expected non-nil FiberServer and app")
 	}
 	if s.logger == nil {
 		t.Fatalf("expected logger to be set on FiberServer")
 	}
+	if s.addr != ":3000" {
+		t.Fatalf("expected addr from config, got %s", s.addr)
+	}
 }
 
 func TestFiberServer_HookExecution(t *testing.T) {
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	s := NewFiberServer(logger, txSvc, nil)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	// inject fake app to avoid real network Listen
 	s.app = &fakeApp{}
 
@@ -91,29 +102,40 @@ func TestFiberServer_HookExecution(t *testing.T) {
 	}
 }
 
-type fakeBus struct {
-	lastTopic   string
-	lastPayload interface{}
+// failingRepo fails Save with err when it is set and answers the next misses
+// idempotency key lookups as if the key were unused.
+type failingRepo struct {
+	ports.TxRepositoryPort
+	err    error
+	misses int
+}
+
+func (r *failingRepo) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	if r.misses > 0 {
+		r.misses--
+		return nil, nil
+	}
+	return r.TxRepositoryPort.FindByIdempotencyKey(ctx, key)
 }
 
-func (f *fakeBus) Publish(ctx context.Context, topic string, payload interface{}) {
-	f.lastTopic = topic
-	f.lastPayload = payload
+func (r *failingRepo) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.TxRepositoryPort.Save(ctx, tx, events...)
 }
-func (f *fakeBus) Subscribe(topic string, handler ports.EventHandler) func() { return func() {} }
-func (f *fakeBus) Close() error                                              { return nil }
 
-func TestHandlerTransactionPublishes(t *testing.T) {
+func TestHandlerTransactionStores(t *testing.T) {
 	logger := zap.NewNop()
-	txSvc := &service.TransactionService{}
-	bus := &fakeBus{}
-	s := NewFiberServer(logger, txSvc, bus)
+	repo := postgres.NewInMemoryTxRepository()
+	txSvc := service.NewTransactionService(repo, nil, nil, logger)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	app := s.app.(*fiber.App)
 
 	body := map[string]string{
-		"from":      "0xfrom",
-		"to":        "0xto",
-		"chain":     "sepolia",
+		"from":      "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"to":        "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+		"chain":     "eth",
 		"amount":    "10",
 		"gas":       "21000",
 		"gas_price": "1",
@@ -129,19 +151,30 @@ func TestHandlerTransactionPublishes(t *testing.T) {
 	if resp.StatusCode != http.StatusAccepted {
 		t.Fatalf("expected 202, got %d", resp.StatusCode)
 	}
-	if bus.lastTopic != "transaction.created" {
-		t.Fatalf("expected topic transaction.created, got %s", bus.lastTopic)
+	var accepted struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if accepted.Status != "pending" {
+		t.Fatalf("unexpected response %+v", accepted)
 	}
-	if bus.lastPayload == nil {
-		t.Fatalf("expected payload, got nil")
+	// stored before the answer, so the Location can be fetched right away
+	tx, _ := repo.FindByID(context.Background(), accepted.ID)
+	if tx == nil || tx.Status != entity.TxStatusPending || tx.From != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Fatalf("expected the pending transaction to be stored, got %+v", tx)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/transactions/"+tx.ID {
+		t.Fatalf("unexpected Location %q", loc)
 	}
 }
 
 func TestHandlerTransactionInvalidJSON(t *testing.T) {
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	bus := &fakeBus{}
-	s := NewFiberServer(logger, txSvc, bus)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	app := s.app.(*fiber.App)
 
 	req, _ := http.NewRequest("POST", "/transaction", bytes.NewReader([]byte("not json")))
@@ -158,14 +191,13 @@ func TestHandlerTransactionInvalidJSON(t *testing.T) {
 func TestHandlerTransactionInvalidGas(t *testing.T) {
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	bus := &fakeBus{}
-	s := NewFiberServer(logger, txSvc, bus)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	app := s.app.(*fiber.App)
 
 	body := map[string]string{
-		"from":      "0xfrom",
-		"to":        "0xto",
-		"chain":     "sepolia",
+		"from":      "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"to":        "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+		"chain":     "eth",
 		"amount":    "10",
 		"gas":       "notanumber",
 		"gas_price": "1",
@@ -186,7 +218,7 @@ func TestHandlerTransactionInvalidGas(t *testing.T) {
 func TestHandlerHeatlCheckMethod(t *testing.T) {
 	logger := zap.NewNop()
 	txSvc := &service.TransactionService{}
-	s := NewFiberServer(logger, txSvc, nil)
+	s := NewFiberServer(config.Default(), logger, txSvc, nil, nil)
 	app := s.app.(*fiber.App)
 
 	// register a route that uses the method receiver so we invoke handlerHeatlCheck
@@ -200,3 +232,369 @@ func TestHandlerHeatlCheckMethod(t *testing.T) {
 		t.Fatalf("expected 200, got %d", resp.StatusCode)
 	}
 }
+
+func TestHandlerCancelTransaction(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	ctx := context.Background()
+	for _, tx := range []*entity.Transaction{
+		{ID: "pending", Status: entity.TxStatusPending},
+		{ID: "sent", Status: entity.TxStatusSent, TxHash: "0xsent"},
+		{ID: "done", Status: entity.TxStatusConfirmed},
+	} {
+		if err := repo.Save(ctx, tx); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"pending", http.StatusOK},
+		{"sent", http.StatusServiceUnavailable}, // no signer to build the replacement
+		{"done", http.StatusConflict},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		req, _ := http.NewRequest("POST", "/transactions/"+tc.id+"/cancel", nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test error: %v", err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.id, tc.want, resp.StatusCode)
+		}
+	}
+	if got, _ := repo.FindByID(ctx, "pending"); got.Status != entity.TxStatusCancelled {
+		t.Fatalf("expected pending tx cancelled, got %v", got.Status)
+	}
+}
+
+func TestHandlerTransactionHistory(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	ctx := context.Background()
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", Status: entity.TxStatusPending}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	if _, err := txSvc.Cancel(ctx, "t1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	req, _ := http.NewRequest("GET", "/transactions/t1/history", nil)
+	resp, err := app.Test(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v %v", resp, err)
+	}
+	var body struct {
+		ID     string `json:"id"`
+		Events []struct {
+			From   string `json:"from"`
+			To     string `json:"to"`
+			Actor  string `json:"actor"`
+			Reason string `json:"reason"`
+		} `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.ID != "t1" || len(body.Events) != 2 {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+	if e := body.Events[1]; e.From != "pending" || e.To != "cancelled" || e.Actor != "api" || e.Reason == "" {
+		t.Fatalf("unexpected cancel entry: %+v", e)
+	}
+
+	req, _ = http.NewRequest("GET", "/transactions/missing/history", nil)
+	if resp, _ := app.Test(req); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerListTransactions(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	huge, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	for i, tx := range []*entity.Transaction{
+		{ID: "a", Chain: "ETH", From: "0xabc", Value: huge, Status: entity.TxStatusPending},
+		{ID: "b", Chain: "ETH", From: "0xabc", Status: entity.TxStatusSent},
+		{ID: "c", Chain: "BSC", From: "0xdef", Status: entity.TxStatusSent},
+	} {
+		tx.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Save(ctx, tx); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	type page struct {
+		Transactions []struct {
+			ID     string `json:"id"`
+			Value  string `json:"value"`
+			Status string `json:"status"`
+		} `json:"transactions"`
+		NextCursor string `json:"next_cursor"`
+	}
+	get := func(query string) (int, page) {
+		req, _ := http.NewRequest("GET", "/transactions"+query, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test error: %v", err)
+		}
+		var body page
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		}
+		return resp.StatusCode, body
+	}
+
+	code, body := get("?chain=eth&status=pending,SENT&limit=1")
+	if code != http.StatusOK || len(body.Transactions) != 1 || body.Transactions[0].ID != "b" || body.NextCursor == "" {
+		t.Fatalf("unexpected first page %d %+v", code, body)
+	}
+	code, body = get("?chain=eth&status=pending,SENT&limit=1&cursor=" + body.NextCursor)
+	if code != http.StatusOK || len(body.Transactions) != 1 || body.NextCursor != "" {
+		t.Fatalf("unexpected second page %d %+v", code, body)
+	}
+	if tx := body.Transactions[0]; tx.ID != "a" || tx.Value != huge.String() || tx.Status != "pending" {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+	if code, body = get("?from=0xDEF&created_after=2024-05-01T00:01:00Z"); code != http.StatusOK || len(body.Transactions) != 1 {
+		t.Fatalf("unexpected filtered page %d %+v", code, body)
+	}
+
+	for _, query := range []string{"?status=lost", "?limit=0", "?created_before=yesterday", "?cursor=bogus"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
+
+func TestHandlerTransactionIdempotencyKey(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	post := func(key string, body map[string]string) (int, string) {
+		b, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/transaction", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test error: %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get("Location")
+	}
+	body := map[string]string{"to": "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "chain": "ETH", "amount": "10", "gas": "21000", "gas_price": "1"}
+
+	code, created := post("k1", body)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	// the key is claimed before the 202, so an immediate retry replays it
+	tx, _ := repo.FindByIdempotencyKey(context.Background(), "k1")
+	if tx == nil || created != "/transactions/"+tx.ID {
+		t.Fatalf("expected the keyed transaction at %q, got %+v", created, tx)
+	}
+	if code, loc := post("k1", body); code != http.StatusOK || loc != created {
+		t.Fatalf("expected a 200 replay of %s, got %d %s", created, code, loc)
+	}
+	body["client_id"] = "k1"
+	if code, _ := post("", body); code != http.StatusOK {
+		t.Fatalf("expected client_id to replay, got %d", code)
+	}
+	if code, _ := post("k2", body); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for differing keys, got %d", code)
+	}
+	delete(body, "client_id")
+	body["amount"] = "11"
+	if code, _ := post("k1", body); code != http.StatusConflict {
+		t.Fatalf("expected 409 for a reused key, got %d", code)
+	}
+	if code, _ := post(strings.Repeat("k", 256), body); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a long key, got %d", code)
+	}
+	if page, _ := repo.List(context.Background(), ports.TxFilter{}); len(page.Transactions) != 1 {
+		t.Fatalf("expected a single stored transaction, got %d", len(page.Transactions))
+	}
+}
+
+func TestHandlerTransactionConcurrentKey(t *testing.T) {
+	repo := &failingRepo{TxRepositoryPort: postgres.NewInMemoryTxRepository()}
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil).app.(*fiber.App)
+	post := func(amount string) *http.Response {
+		req, _ := http.NewRequest("POST", "/transaction", strings.NewReader(`{"to":"`+lowerAddr+`","amount":"`+amount+`","client_id":"k1"}`))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test error: %v", err)
+		}
+		return resp
+	}
+
+	first := post("10")
+	if first.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", first.StatusCode)
+	}
+	// concurrent requests miss the key on lookup and lose the insert, so
+	// they are answered from the stored transaction
+	repo.misses = 2
+	if resp := post("10"); resp.StatusCode != http.StatusOK || resp.Header.Get("Location") != first.Header.Get("Location") {
+		t.Fatalf("expected a 200 replay of %s, got %d %s", first.Header.Get("Location"), resp.StatusCode, resp.Header.Get("Location"))
+	}
+	repo.misses = 2
+	if resp := post("11"); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for a reused key, got %d", resp.StatusCode)
+	}
+	if page, _ := repo.List(context.Background(), ports.TxFilter{}); len(page.Transactions) != 1 {
+		t.Fatalf("expected a single stored transaction, got %d", len(page.Transactions))
+	}
+}
+
+func TestHandlerGetTransaction(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	to := "0xto"
+	value, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	if err := repo.Save(context.Background(), &entity.Transaction{ID: "t1", Chain: "ETH", To: &to, Value: value, Status: entity.TxStatusPending}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	txSvc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	req, _ := http.NewRequest("GET", "/transactions/t1", nil)
+	resp, err := app.Test(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v %v", resp, err)
+	}
+	var view transactionView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if view.ID != "t1" || view.To != to || view.Value != value.String() || view.Status != "pending" {
+		t.Fatalf("unexpected transaction %+v", view)
+	}
+
+	req, _ = http.NewRequest("GET", "/transactions/missing", nil)
+	if resp, _ := app.Test(req); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+// stubChain broadcasts every transaction as hash and has no fee estimate.
+type stubChain struct {
+	ports.BlockchainPort
+	hash string
+}
+
+func (c *stubChain) EstimateFees(context.Context, string, entity.FeeTier) (*big.Int, *big.Int, error) {
+	return nil, nil, errors.New("no estimate")
+}
+func (c *stubChain) SendRawTransaction(context.Context, string, []byte) (string, error) {
+	return c.hash, nil
+}
+
+type stubSigner struct{ ports.WalletSignerPort }
+
+func (stubSigner) SignTransaction(context.Context, *entity.Transaction) ([]byte, string, error) {
+	return []byte{1}, "", nil
+}
+
+func (stubSigner) Address(context.Context) (string, error) { return checksummed, nil }
+
+// otherSigner signs for a second sender.
+type otherSigner struct{ stubSigner }
+
+func (otherSigner) Address(context.Context) (string, error) { return lowerAddr, nil }
+
+func TestV1Routes(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	ctx := context.Background()
+	to := "0xto"
+	sent := &entity.Transaction{
+		ID: "t1", Chain: "ETH", From: "0xfrom", To: &to, Value: big.NewInt(5), GasPrice: big.NewInt(100),
+		TxHash: "0xold", Status: entity.TxStatusSent,
+		Attempts: []entity.TxAttempt{{TxHash: "0xold", GasPrice: big.NewInt(100)}},
+	}
+	if err := repo.Save(ctx, sent); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t2", Chain: "BSC", Status: entity.TxStatusPending}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	txSvc := service.NewTransactionService(repo, &stubChain{hash: "0xnew"}, stubSigner{}, zap.NewNop())
+	s := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil)
+	app := s.app.(*fiber.App)
+
+	do := func(method, path string) (int, transactionView) {
+		req, _ := http.NewRequest(method, path, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test error: %v", err)
+		}
+		var view transactionView
+		if resp.StatusCode < 300 {
+			_ = json.NewDecoder(resp.Body).Decode(&view)
+		}
+		return resp.StatusCode, view
+	}
+
+	if code, view := do("GET", "/v1/transactions/t1"); code != http.StatusOK || view.Value != "5" || view.Attempts[0].GasPrice != "100" {
+		t.Fatalf("unexpected get %d %+v", code, view)
+	}
+	code, view := do("POST", "/v1/transactions/t1/speedup")
+	if code != http.StatusAccepted || view.TxHash != "0xnew" || len(view.Attempts) != 2 || view.Attempts[1].GasPrice != "110" {
+		t.Fatalf("unexpected speed up %d %+v", code, view)
+	}
+	for _, hash := range []string{"0xold", "0xnew"} {
+		if code, view := do("GET", "/v1/transactions/by-hash/"+hash); code != http.StatusOK || view.ID != "t1" {
+			t.Fatalf("%s: unexpected lookup %d %+v", hash, code, view)
+		}
+	}
+	for path, want := range map[string]int{
+		"/v1/transactions/by-hash/0xnone":   http.StatusNotFound,
+		"/v1/transactions/missing":          http.StatusNotFound,
+		"/v1/transactions/missing/speedup":  http.StatusNotFound,
+		"/v1/transactions/t2/speedup":       http.StatusConflict,
+		"/v1/transactions/t2/cancel":        http.StatusOK,
+		"/v1/transactions/t2/history":       http.StatusOK,
+		"/v1/transactions?chain=bsc&from=x": http.StatusOK,
+	} {
+		method := "GET"
+		if strings.HasSuffix(path, "speedup") || strings.HasSuffix(path, "cancel") {
+			method = "POST"
+		}
+		if code, _ := do(method, path); code != want {
+			t.Errorf("%s %s: expected %d, got %d", method, path, want, code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/transactions?status=sent&chain=eth&from=0xFROM", nil)
+	resp, _ := app.Test(req)
+	var page struct {
+		Transactions []transactionView `json:"transactions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil || len(page.Transactions) != 1 || page.Transactions[0].ID != "t1" {
+		t.Fatalf("unexpected list %+v %v", page, err)
+	}
+
+	unsigned := NewFiberServer(config.Default(), zap.NewNop(), service.NewTransactionService(repo, nil, nil, zap.NewNop()), nil, nil)
+	req, _ = http.NewRequest("POST", "/v1/transactions/t1/speedup", nil)
+	if resp, _ := unsigned.app.(*fiber.App).Test(req); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a signer, got %d", resp.StatusCode)
+	}
+}
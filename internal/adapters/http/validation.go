@@ -0,0 +1,239 @@
+package http
+
+import (
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bounds on the gas limit of a submitted transaction: a plain transfer costs
+// 21000 and no block of a supported chain holds more than 30M.
+const (
+	minGas = 21000
+	maxGas = 30_000_000
+)
+
+// maxUint256 is the largest amount an EVM transaction can carry.
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// problemContentType is the media type of RFC 7807 error responses.
+const problemContentType = "application/problem+json"
+
+// problem is an RFC 7807 error response. Errors lists the offending request
+// fields of a validation failure.
+type problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError explains why one request field was rejected.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// sendProblem answers with an RFC 7807 problem for status.
+func sendProblem(c *fiber.Ctx, status int, detail string, errs ...fieldError) error {
+	body, err := json.Marshal(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Path(),
+		Errors:   errs,
+	})
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, problemContentType)
+	return c.Status(status).Send(body)
+}
+
+// txValidator turns a submitted transaction body into an entity.Transaction,
+// checking it against the chains of the configuration.
+type txValidator struct {
+	defaultChain string
+	chains       map[string]bool
+}
+
+func newTxValidator(cfg *config.Config) txValidator {
+	v := txValidator{defaultChain: config.NormalizeChain(cfg.DefaultChain), chains: map[string]bool{}}
+	for name := range cfg.Chains {
+		v.chains[config.NormalizeChain(name)] = true
+	}
+	return v
+}
+
+// decode parses a request body, rejecting unknown fields.
+func (v txValidator) decode(raw []byte) (transaction, []fieldError) {
+	var body transaction
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		return body, []fieldError{{Field: "body", Message: jsonError(err)}}
+	}
+	if dec.More() {
+		return body, []fieldError{{Field: "body", Message: "unexpected data after the JSON object"}}
+	}
+	return body, nil
+}
+
+// validate checks every field of body and reports all problems at once.
+// Amounts and the gas limit may be decimal or 0x-prefixed hex, an empty
+// chain selects the default chain, an empty fee tier the standard one and an
+// empty gas limit is estimated by the service for plain transfers.
+func (v txValidator) validate(body transaction) (*entity.Transaction, []fieldError) {
+	var errs []fieldError
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	tx := &entity.Transaction{}
+
+	chain := config.NormalizeChain(body.Chain)
+	if chain == "" {
+		chain = v.defaultChain
+	}
+	if !v.chains[chain] {
+		fail("chain", "unknown chain %q", body.Chain)
+	}
+	tx.Chain = chain
+
+	if body.From != "" {
+		if err := checkAddress(body.From); err != nil {
+			fail("from", "%v", err)
+		}
+		tx.From = body.From
+	}
+	if body.To == "" {
+		fail("to", "is required")
+	} else if err := checkAddress(body.To); err != nil {
+		fail("to", "%v", err)
+	} else {
+		to := body.To
+		tx.To = &to
+	}
+
+	var err error
+	if body.Amount == "" {
+		fail("amount", "is required")
+	} else if tx.Value, err = parseAmount(body.Amount); err != nil {
+		fail("amount", "%v", err)
+	}
+	if body.GasPrice != "" {
+		if tx.GasPrice, err = parseAmount(body.GasPrice); err != nil {
+			fail("gas_price", "%v", err)
+		}
+	}
+	if tx.FeeTier, err = entity.ParseFeeTier(body.FeeTier); err != nil {
+		fail("fee_tier", "must be slow, standard or fast")
+	}
+
+	if body.Data != "" {
+		if tx.Data, err = parseHex(body.Data); err != nil {
+			fail("data", "%v", err)
+		}
+	}
+	switch {
+	case body.Gas != "":
+		if tx.Gas, err = parseGas(body.Gas); err != nil {
+			fail("gas", "%v", err)
+		}
+	case len(tx.Data) > 0:
+		fail("gas", "is required for transactions with data")
+	}
+
+	return tx, errs
+}
+
+// checkAddress accepts a 0x-prefixed 20-byte hex address in lower case,
+// upper case or with a valid EIP-55 checksum.
+func checkAddress(addr string) error {
+	digits, ok := strings.CutPrefix(addr, "0x")
+	if !ok || len(digits) != 40 {
+		return errors.New("must be a 0x-prefixed 40 digit hex address")
+	}
+	raw, err := hex.DecodeString(digits)
+	if err != nil {
+		return errors.New("must be a 0x-prefixed 40 digit hex address")
+	}
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if want := entity.ChecksumAddress(raw); addr != want {
+		return fmt.Errorf("has an invalid EIP-55 checksum, expected %s", want)
+	}
+	return nil
+}
+
+// parseAmount reads a decimal or 0x-prefixed hex amount in wei that fits in
+// 256 bits.
+func parseAmount(s string) (*big.Int, error) {
+	n, ok := new(big.Int), false
+	if digits, isHex := strings.CutPrefix(s, "0x"); isHex {
+		_, ok = n.SetString(digits, 16)
+	} else {
+		_, ok = n.SetString(s, 10)
+	}
+	switch {
+	case !ok:
+		return nil, errors.New("must be a decimal or 0x-prefixed hex integer")
+	case n.Sign() < 0:
+		return nil, errors.New("must not be negative")
+	case n.Cmp(maxUint256) > 0:
+		return nil, errors.New("exceeds 256 bits")
+	}
+	return n, nil
+}
+
+// parseGas reads a decimal or 0x-prefixed hex gas limit within the bounds.
+func parseGas(s string) (uint64, error) {
+	base := 10
+	if digits, isHex := strings.CutPrefix(s, "0x"); isHex {
+		s, base = digits, 16
+	}
+	gas, err := strconv.ParseUint(s, base, 64)
+	if err != nil {
+		return 0, errors.New("must be a decimal or 0x-prefixed hex integer")
+	}
+	if gas < minGas || gas > maxGas {
+		return 0, fmt.Errorf("must be between %d and %d", minGas, maxGas)
+	}
+	return gas, nil
+}
+
+// parseHex decodes 0x-prefixed calldata.
+func parseHex(s string) ([]byte, error) {
+	digits, ok := strings.CutPrefix(s, "0x")
+	if !ok {
+		return nil, errors.New("must be 0x-prefixed hex")
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, errors.New("must be hex with an even number of digits")
+	}
+	return b, nil
+}
+
+// jsonError describes a decoding failure without Go type names.
+func jsonError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		// every field of the body is a string
+		return fmt.Sprintf("field %q must be a string", typeErr.Field)
+	}
+	return "invalid JSON: " + strings.TrimPrefix(err.Error(), "json: ")
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	lowerAddr   = "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"
+)
+
+func TestCheckAddress(t *testing.T) {
+	for addr, ok := range map[string]bool{
+		checksummed:                                  true,
+		strings.ToLower(checksummed):                 true,
+		"0x" + strings.ToUpper(checksummed[2:]):      true,
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD": false, // last letter flipped
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed":   false,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea":   false,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz": false,
+	} {
+		if err := checkAddress(addr); (err == nil) != ok {
+			t.Errorf("%s: expected valid=%v, got %v", addr, ok, err)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for in, want := range map[string]string{"0": "0", "1000": "1000", "0xff": "255", "0x0": "0"} {
+		if got, err := parseAmount(in); err != nil || got.String() != want {
+			t.Errorf("%s: expected %s, got %v %v", in, want, got, err)
+		}
+	}
+	tooBig := "0x1" + strings.Repeat("0", 64)
+	for _, in := range []string{"abc", "-1", "1.5", "0x", "0xzz", "", tooBig} {
+		if _, err := parseAmount(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestTxValidator(t *testing.T) {
+	cfg := config.Default()
+	cfg.Chains["POLYGON"] = config.ChainConfig{}
+	v := newTxValidator(cfg)
+
+	tx, errs := v.validate(transaction{To: lowerAddr, Amount: "0x10", Data: "0xa9059cbb", Gas: "0x186a0", Chain: " polygon "})
+	if errs != nil {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+	if tx.Chain != "POLYGON" || tx.Value.Int64() != 16 || tx.Gas != 100000 || len(tx.Data) != 4 || *tx.To != lowerAddr {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+	if tx, _ := v.validate(transaction{To: lowerAddr, Amount: "1"}); tx.Chain != "ETH" || tx.Gas != 0 || tx.FeeTier != entity.FeeTierStandard {
+		t.Fatalf("expected the default chain, an estimated gas limit and the standard tier, got %+v", tx)
+	}
+
+	if tx, _ := v.validate(transaction{To: lowerAddr, Amount: "1", FeeTier: "fast"}); tx.FeeTier != entity.FeeTierFast {
+		t.Fatalf("expected the fast tier, got %q", tx.FeeTier)
+	}
+
+	_, errs = v.validate(transaction{
+		From: "0xnope", Chain: "SOLANA", Amount: "ten", Gas: "20999", GasPrice: "-1", Data: "0xabc", FeeTier: "urgent",
+	})
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	sort.Strings(fields)
+	if strings.Join(fields, ",") != "amount,chain,data,fee_tier,from,gas,gas_price,to" {
+		t.Fatalf("expected every field to be reported, got %+v", errs)
+	}
+
+	if _, errs := v.validate(transaction{To: lowerAddr, Amount: "1", Data: "0x00"}); len(errs) != 1 || errs[0].Field != "gas" {
+		t.Fatalf("expected gas to be required with data, got %+v", errs)
+	}
+	if _, errs := v.decode([]byte(`{"to": 1}`)); len(errs) != 1 || !strings.Contains(errs[0].Message, "must be a string") {
+		t.Fatalf("unexpected type error %+v", errs)
+	}
+	if _, errs := v.decode([]byte(`{"value": "1"}`)); errs == nil {
+		t.Fatalf("expected unknown fields to be rejected")
+	}
+}
+
+func TestHandlerTransactionProblem(t *testing.T) {
+	s := NewFiberServer(config.Default(), zap.NewNop(), &service.TransactionService{}, nil, nil)
+	app := s.app.(*fiber.App)
+
+	b, _ := json.Marshal(map[string]string{"to": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "amount": "abc"})
+	req, _ := http.NewRequest("POST", "/transaction", bytes.NewReader(b))
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Content-Type") != problemContentType {
+		t.Fatalf("expected a 400 problem, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var p problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Instance != "/transaction" || len(p.Errors) != 2 {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+	if p.Errors[0].Field != "to" || !strings.Contains(p.Errors[0].Message, checksummed) {
+		t.Fatalf("expected the checksum error to suggest the address, got %+v", p.Errors[0])
+	}
+}
+
+func TestHandlerTransactionForeignSender(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	txSvc := service.NewTransactionService(repo, nil, stubSigner{}, zap.NewNop())
+	app := NewFiberServer(config.Default(), zap.NewNop(), txSvc, nil, nil).app.(*fiber.App)
+
+	resp := submit(t, app, `{"from":"`+lowerAddr+`","to":"`+lowerAddr+`","amount":"1"}`)
+	var p problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "from" ||
+		!strings.Contains(p.Errors[0].Message, checksummed) {
+		t.Fatalf("expected a foreign from to be rejected, got %d %+v", resp.StatusCode, p)
+	}
+	// a malformed from is reported once
+	resp = submit(t, app, `{"from":"0xnope","to":"`+lowerAddr+`","amount":"1"}`)
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || len(p.Errors) != 1 {
+		t.Fatalf("expected one from error, got %+v %v", p, err)
+	}
+	// the signer's address in any case is accepted
+	if resp := submit(t, app, `{"from":"`+strings.ToLower(checksummed)+`","to":"`+lowerAddr+`","amount":"1"}`); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// APIKeyRepository reads API keys from the api_keys table. Keys are added
+// and revoked directly in the database.
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+var _ ports.APIKeyStore = (*APIKeyRepository)(nil)
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var (
+		key    entity.APIKey
+		scopes string
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT name, key_hash, scopes FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`, keyHash).Scan(&key.Name, &key.KeyHash, &scopes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	return &key, nil
+}
@@ -5,32 +5,89 @@ import (
 	"ChainConnector/internal/domain/ports"
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// InMemoryTxRepository keeps transactions in maps. It stores and returns
+// copies, so callers never share state with the repository or each other.
+// Events are appended to an in-memory outbox under the same lock as the write
+// they belong to.
 type InMemoryTxRepository struct {
-	mu     sync.RWMutex
-	byID   map[string]*entity.Transaction
-	byHash map[string]*entity.Transaction
+	mu      sync.RWMutex
+	byID    map[string]*entity.Transaction
+	byHash  map[string]string
+	byKey   map[string]string
+	history map[string][]entity.StatusChange
+	outbox  []*outboxEntry
+	lastMsg int64
 }
 
+// outboxEntry is an unpublished outbox message and its claim.
+type outboxEntry struct {
+	msg         ports.OutboxMessage
+	lockedUntil time.Time
+	lastError   string
+}
+
+var (
+	_ ports.TxRepositoryPort = (*InMemoryTxRepository)(nil)
+	_ ports.OutboxPort       = (*InMemoryTxRepository)(nil)
+)
+
 func NewInMemoryTxRepository() ports.TxRepositoryPort {
 	return &InMemoryTxRepository{
-		byID:   make(map[string]*entity.Transaction),
-		byHash: make(map[string]*entity.Transaction),
+		byID:    make(map[string]*entity.Transaction),
+		byHash:  make(map[string]string),
+		byKey:   make(map[string]string),
+		history: make(map[string][]entity.StatusChange),
 	}
 }
 
-func (r *InMemoryTxRepository) Save(ctx context.Context, tx *entity.Transaction) error {
+func (r *InMemoryTxRepository) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
 	if tx == nil || tx.ID == "" {
 		return errors.New("invalid transaction")
 	}
+	msgs, err := outboxMessages(events)
+	if err != nil {
+		return err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.byID[tx.ID] = tx
-	if tx.TxHash != "" {
-		r.byHash[tx.TxHash] = tx
+	prev := entity.TxStatusUnknown
+	old, exists := r.byID[tx.ID]
+	if exists != (tx.Version > 0) || exists && old.Version != tx.Version {
+		return ports.ErrConflict
+	}
+	if exists {
+		prev = old.Status
+		if prev != tx.Status {
+			if err := entity.CheckTransition(prev, tx.Status); err != nil {
+				return err
+			}
+		}
+	}
+	if id, taken := r.byKey[tx.IdempotencyKey]; taken && id != tx.ID {
+		return ports.ErrDuplicateIdempotencyKey
+	}
+	if exists && old.IdempotencyKey != tx.IdempotencyKey {
+		delete(r.byKey, old.IdempotencyKey)
+	}
+	stored := tx.Clone()
+	stored.Version++
+	// like the created_at column: set once on insert, never overwritten
+	if exists {
+		stored.CreatedAt = old.CreatedAt
+	} else if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now().UTC()
 	}
+	r.byID[tx.ID] = stored
+	r.index(stored)
+	r.record(tx.ID, prev, tx.Status, ports.UpdateMeta{})
+	r.enqueue(msgs)
+	tx.Version = stored.Version
 	return nil
 }
 
@@ -41,47 +98,222 @@ func (r *InMemoryTxRepository) FindByID(ctx context.Context, id string) (*entity
 	if !ok {
 		return nil, nil
 	}
-	return tx, nil
+	return tx.Clone(), nil
 }
 
 func (r *InMemoryTxRepository) FindByHash(ctx context.Context, hash string) (*entity.Transaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	tx, ok := r.byHash[hash]
+	id, ok := r.byHash[hash]
 	if !ok {
 		return nil, nil
 	}
-	return tx, nil
+	return r.byID[id].Clone(), nil
 }
 
-func (r *InMemoryTxRepository) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}) error {
+func (r *InMemoryTxRepository) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
+	msgs, err := outboxMessages(meta.Events)
+	if err != nil {
+		return err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	tx, ok := r.byID[txID]
+	cur, ok := r.byID[txID]
 	if !ok {
 		return errors.New("transaction not found")
 	}
+	if meta.Version != 0 && meta.Version != cur.Version {
+		return ports.ErrConflict
+	}
+	if err := entity.CheckTransition(cur.Status, status); err != nil {
+		return err
+	}
+	// the copy drops references to values owned by the caller
+	tx := cur.Clone()
 	tx.Status = status
-	if updates != nil {
-		if h, ok := updates["tx_hash"].(string); ok && h != "" {
-			tx.TxHash = h
-			r.byHash[h] = tx
+	tx.UpdatedAt = time.Now().UTC()
+	applyUpdates(tx, updates)
+	tx = tx.Clone()
+	tx.Version++
+	r.byID[txID] = tx
+	r.index(tx)
+	r.record(txID, cur.Status, status, meta)
+	r.enqueue(msgs)
+	return nil
+}
+
+// enqueue appends msgs to the outbox.
+func (r *InMemoryTxRepository) enqueue(msgs []ports.OutboxMessage) {
+	now := time.Now().UTC()
+	for _, m := range msgs {
+		r.lastMsg++
+		m.ID, m.CreatedAt = r.lastMsg, now
+		r.outbox = append(r.outbox, &outboxEntry{msg: m})
+	}
+}
+
+func (r *InMemoryTxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]ports.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var res []ports.OutboxMessage
+	for _, e := range r.outbox {
+		if len(res) >= limit {
+			break
+		}
+		if e.lockedUntil.After(now) {
+			continue
+		}
+		e.lockedUntil = now.Add(lease)
+		e.msg.Attempts++
+		m := e.msg
+		m.Payload = append([]byte(nil), e.msg.Payload...)
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// MarkPublished drops the message; published messages are not kept.
+func (r *InMemoryTxRepository) MarkPublished(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.outbox {
+		if e.msg.ID == id {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryTxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.outbox {
+		if e.msg.ID == id {
+			e.lastError = reason
 		}
 	}
 	return nil
 }
 
+// record appends the status change of txID to its history, if any.
+func (r *InMemoryTxRepository) record(txID string, prev, status entity.TxStatus, meta ports.UpdateMeta) {
+	if change, ok := statusChange(txID, prev, status, meta, time.Now().UTC()); ok {
+		r.history[txID] = append(r.history[txID], change)
+	}
+}
+
+func (r *InMemoryTxRepository) History(ctx context.Context, txID string) ([]entity.StatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]entity.StatusChange(nil), r.history[txID]...), nil
+}
+
+func (r *InMemoryTxRepository) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byKey[key]
+	if !ok {
+		return nil, nil
+	}
+	return r.byID[id].Clone(), nil
+}
+
+// index makes tx findable by its idempotency key, its current hash and every
+// attempt hash.
+func (r *InMemoryTxRepository) index(tx *entity.Transaction) {
+	if tx.IdempotencyKey != "" {
+		r.byKey[tx.IdempotencyKey] = tx.ID
+	}
+	if tx.TxHash != "" {
+		r.byHash[tx.TxHash] = tx.ID
+	}
+	for _, a := range tx.Attempts {
+		if a.TxHash != "" {
+			r.byHash[a.TxHash] = tx.ID
+		}
+	}
+}
+
 func (r *InMemoryTxRepository) ListPending(ctx context.Context, limit int) ([]*entity.Transaction, error) {
+	return r.ListByStatus(ctx, entity.TxStatusPending, limit)
+}
+
+// ListByStatus returns the oldest transactions in status first.
+func (r *InMemoryTxRepository) ListByStatus(ctx context.Context, status entity.TxStatus, limit int) ([]*entity.Transaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	res := make([]*entity.Transaction, 0, 10)
 	for _, tx := range r.byID {
-		if tx.Status == entity.TxStatusPending {
+		if tx.Status == status {
 			res = append(res, tx)
-			if limit > 0 && len(res) >= limit {
-				break
-			}
 		}
 	}
+	sort.Slice(res, func(i, j int) bool { return newer(res[j], res[i]) })
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	for i, tx := range res {
+		res[i] = tx.Clone()
+	}
 	return res, nil
 }
+
+func (r *InMemoryTxRepository) List(ctx context.Context, filter ports.TxFilter) (ports.TxPage, error) {
+	cursor, err := decodeCursor(filter.Cursor)
+	if err != nil {
+		return ports.TxPage{}, err
+	}
+	limit := listLimit(filter.Limit)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]*entity.Transaction, 0, 10)
+	for _, tx := range r.byID {
+		if matches(tx, filter) && (cursor == nil || newer(&entity.Transaction{ID: cursor.ID, CreatedAt: cursor.CreatedAt}, tx)) {
+			res = append(res, tx)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return newer(res[i], res[j]) })
+	if len(res) > limit+1 {
+		res = res[:limit+1]
+	}
+	for i, tx := range res {
+		res[i] = tx.Clone()
+	}
+	return page(res, limit), nil
+}
+
+// newer orders transactions like List: by creation time, then by id.
+func newer(a, b *entity.Transaction) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+func matches(tx *entity.Transaction, f ports.TxFilter) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, s := range f.Statuses {
+			found = found || tx.Status == s
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Chain != "" && !strings.EqualFold(tx.Chain, f.Chain) {
+		return false
+	}
+	if f.From != "" && !strings.EqualFold(tx.From, f.From) {
+		return false
+	}
+	if f.To != "" && (tx.To == nil || !strings.EqualFold(*tx.To, f.To)) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && tx.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	return f.CreatedBefore.IsZero() || tx.CreatedAt.Before(f.CreatedBefore)
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"math/big"
 	"testing"
+	"time"
 
 	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
 )
 
 func TestInMemoryRepository_BasicLifecycle(t *testing.T) {
@@ -24,19 +28,360 @@ func TestInMemoryRepository_BasicLifecycle(t *testing.T) {
 	}
 
 	// update status and hash
-	if err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSent, map[string]interface{}{"tx_hash": "h2"}); err != nil {
+	if err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSigned, map[string]interface{}{"tx_hash": "h2"}, ports.UpdateMeta{}); err != nil {
 		t.Fatalf("update status error: %v", err)
 	}
 	got, _ := repo.FindByID(ctx, "t1")
-	if got.TxHash != "h2" || got.Status != entity.TxStatusSent {
+	if got.TxHash != "h2" || got.Status != entity.TxStatusSigned {
 		t.Fatalf("unexpected state after update: %+v", got)
 	}
 
-	// list pending should not include sent tx
+	// list pending should not include signed tx
 	list, _ := repo.ListPending(ctx, 10)
 	for _, v := range list {
 		if v.ID == "t1" {
-			t.Fatalf("sent tx still in pending list")
+			t.Fatalf("signed tx still in pending list")
 		}
 	}
 }
+
+func TestInMemoryRepository_UpdateStatusAppliesUpdates(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", Status: entity.TxStatusSigned}); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+
+	sentAt := time.Now().UTC()
+	err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSent, map[string]interface{}{
+		"from":                     "0xfrom",
+		"nonce":                    uint64(4),
+		"gas":                      uint64(21000),
+		"chain_id":                 big.NewInt(1),
+		"gas_price":                big.NewInt(2),
+		"max_fee_per_gas":          big.NewInt(3),
+		"max_priority_fee_per_gas": big.NewInt(1),
+		"raw_tx_hex":               "0x02f8",
+		"tx_hash":                  "0xhash",
+		"sent_at":                  sentAt,
+		"confirmed_at":             sentAt,
+		"error_message":            "boom",
+		"receipt":                  &entity.Receipt{BlockNumber: 9},
+		"unknown":                  true,
+		"nonce_wrong_type":         "x",
+	}, ports.UpdateMeta{})
+	if err != nil {
+		t.Fatalf("update status error: %v", err)
+	}
+	got, _ := repo.FindByHash(ctx, "0xhash")
+	if got == nil {
+		t.Fatalf("expected tx indexed by new hash")
+	}
+	if got.From != "0xfrom" || got.Nonce != 4 || got.Gas != 21000 || got.RawTxHex != "0x02f8" {
+		t.Fatalf("unexpected scalar fields: %+v", got)
+	}
+	if got.ChainID.Int64() != 1 || got.GasPrice.Int64() != 2 || got.MaxFeePerGas.Int64() != 3 || got.MaxPriorityFeePerGas.Int64() != 1 {
+		t.Fatalf("unexpected fee fields: %+v", got)
+	}
+	if got.SentAt == nil || !got.SentAt.Equal(sentAt) || got.ConfirmedAt == nil || *got.ErrorMessage != "boom" {
+		t.Fatalf("unexpected lifecycle fields: %+v", got)
+	}
+	if got.Receipt == nil || got.Receipt.BlockNumber != 9 {
+		t.Fatalf("expected receipt to be stored: %+v", got.Receipt)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Fatalf("expected UpdatedAt to be set")
+	}
+
+	if err := repo.UpdateStatus(ctx, "missing", entity.TxStatusSent, nil, ports.UpdateMeta{}); err == nil {
+		t.Fatalf("expected error for unknown tx")
+	}
+}
+
+func TestInMemoryRepository_ListByStatus(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	for _, tx := range []*entity.Transaction{
+		{ID: "a", Status: entity.TxStatusSent},
+		{ID: "b", Status: entity.TxStatusSent},
+		{ID: "c", Status: entity.TxStatusPending},
+	} {
+		if err := repo.Save(ctx, tx); err != nil {
+			t.Fatalf("save error: %v", err)
+		}
+	}
+	sent, _ := repo.ListByStatus(ctx, entity.TxStatusSent, 0)
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 sent txs, got %d", len(sent))
+	}
+	limited, _ := repo.ListByStatus(ctx, entity.TxStatusSent, 1)
+	if len(limited) != 1 {
+		t.Fatalf("expected limit to apply, got %d", len(limited))
+	}
+}
+
+func TestInMemoryRepository_History(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	if err := repo.Save(ctx, &entity.Transaction{ID: "h", Status: entity.TxStatusPending}); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	steps := []struct {
+		status  entity.TxStatus
+		updates map[string]interface{}
+		meta    ports.UpdateMeta
+	}{
+		{entity.TxStatusSigned, nil, ports.UpdateMeta{Actor: "signer"}},
+		{entity.TxStatusSent, nil, ports.UpdateMeta{}},
+		{entity.TxStatusSent, map[string]interface{}{"nonce": uint64(1)}, ports.UpdateMeta{}}, // not recorded
+		{entity.TxStatusSent, nil, ports.UpdateMeta{Reason: "fee bump"}},
+	}
+	for _, s := range steps {
+		if err := repo.UpdateStatus(ctx, "h", s.status, s.updates, s.meta); err != nil {
+			t.Fatalf("update error: %v", err)
+		}
+	}
+
+	history, err := repo.History(ctx, "h")
+	if err != nil || len(history) != 4 {
+		t.Fatalf("expected 4 status changes, got %+v %v", history, err)
+	}
+	if history[0].From != entity.TxStatusUnknown || history[0].To != entity.TxStatusPending {
+		t.Fatalf("expected creation entry first, got %+v", history[0])
+	}
+	if history[1].Actor != "signer" || history[2].Actor != entity.ActorSystem {
+		t.Fatalf("unexpected actors: %+v", history)
+	}
+	if history[3].From != entity.TxStatusSent || history[3].Reason != "fee bump" {
+		t.Fatalf("expected reason-only entry, got %+v", history[3])
+	}
+
+	history[0].Actor = "tampered"
+	if again, _ := repo.History(ctx, "h"); again[0].Actor == "tampered" {
+		t.Fatalf("expected History to return a copy")
+	}
+	if none, err := repo.History(ctx, "missing"); err != nil || len(none) != 0 {
+		t.Fatalf("expected empty history, got %v %v", none, err)
+	}
+}
+
+func TestInMemoryRepository_RejectsInvalidTransitions(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	tx := &entity.Transaction{ID: "t1", Status: entity.TxStatusSent}
+	if err := repo.Save(ctx, tx); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "t1", entity.TxStatusConfirmed, nil, ports.UpdateMeta{}); err != nil {
+		t.Fatalf("update error: %v", err)
+	}
+
+	// a poller that lost the race must not flip the confirmed tx
+	err := repo.UpdateStatus(ctx, "t1", entity.TxStatusFailed, map[string]interface{}{"error_message": "late"}, ports.UpdateMeta{})
+	if !errors.Is(err, entity.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", Status: entity.TxStatusPending, Version: 2}); !errors.Is(err, entity.ErrInvalidTransition) {
+		t.Fatalf("expected Save to reject confirmed to pending, got %v", err)
+	}
+	got, _ := repo.FindByID(ctx, "t1")
+	if got.Status != entity.TxStatusConfirmed || got.ErrorMessage != nil {
+		t.Fatalf("expected confirmed tx to be untouched, got %+v", got)
+	}
+	if history, _ := repo.History(ctx, "t1"); len(history) != 2 {
+		t.Fatalf("expected rejected changes to stay out of the history, got %+v", history)
+	}
+}
+
+func TestInMemoryRepository_Versioning(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	tx := &entity.Transaction{ID: "t1", Value: big.NewInt(1), Status: entity.TxStatusPending}
+	if err := repo.Save(ctx, tx); err != nil || tx.Version != 1 {
+		t.Fatalf("expected version 1 after insert, got %d %v", tx.Version, err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", Status: entity.TxStatusPending}); !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected duplicate insert to conflict, got %v", err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t2", Version: 4}); !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected overwrite of a missing tx to conflict, got %v", err)
+	}
+
+	// two workers read the same version; the second write loses
+	a, _ := repo.FindByID(ctx, "t1")
+	b, _ := repo.FindByID(ctx, "t1")
+	a.Gas = 21000
+	if err := repo.Save(ctx, a); err != nil || a.Version != 2 {
+		t.Fatalf("expected version 2 after overwrite, got %d %v", a.Version, err)
+	}
+	b.Gas = 50000
+	if err := repo.Save(ctx, b); !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected stale save to conflict, got %v", err)
+	}
+	err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSigned, nil, ports.UpdateMeta{Version: b.Version})
+	if !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected stale update to conflict, got %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSigned, nil, ports.UpdateMeta{Version: a.Version}); err != nil {
+		t.Fatalf("update error: %v", err)
+	}
+
+	// returned transactions are copies
+	got, _ := repo.FindByID(ctx, "t1")
+	if got.Version != 3 || got.Gas != 21000 || got.Status != entity.TxStatusSigned {
+		t.Fatalf("unexpected stored tx: %+v", got)
+	}
+	got.Value.SetInt64(99)
+	tx.Value.SetInt64(98)
+	if again, _ := repo.FindByID(ctx, "t1"); again.Value.Int64() != 1 {
+		t.Fatalf("expected stored value to be isolated, got %v", again.Value)
+	}
+}
+
+func TestInMemoryRepository_List(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := "0xTo"
+	for i, tx := range []*entity.Transaction{
+		{ID: "a", Chain: "ETH", From: "0xAbC", Status: entity.TxStatusPending},
+		{ID: "b", Chain: "ETH", From: "0xabc", To: &to, Status: entity.TxStatusSent},
+		{ID: "c", Chain: "BSC", From: "0xabc", Status: entity.TxStatusSent},
+		{ID: "d", Chain: "eth", From: "0xdef", Status: entity.TxStatusFailed},
+		{ID: "e", Chain: "ETH", From: "0xabc", Status: entity.TxStatusSent},
+	} {
+		tx.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		if tx.ID == "e" {
+			tx.CreatedAt = base.Add(time.Hour) // same time as b, ordered by id
+		}
+		if err := repo.Save(ctx, tx); err != nil {
+			t.Fatalf("save error: %v", err)
+		}
+	}
+
+	ids := func(p ports.TxPage) string {
+		s := ""
+		for _, tx := range p.Transactions {
+			s += tx.ID
+		}
+		return s
+	}
+	tests := []struct {
+		filter ports.TxFilter
+		want   string
+	}{
+		{ports.TxFilter{}, "dceba"},
+		{ports.TxFilter{Chain: "eth"}, "deba"},
+		{ports.TxFilter{From: "0xABC", Statuses: []entity.TxStatus{entity.TxStatusSent, entity.TxStatusPending}}, "ceba"},
+		{ports.TxFilter{To: "0xto"}, "b"},
+		{ports.TxFilter{CreatedAfter: base.Add(time.Hour), CreatedBefore: base.Add(3 * time.Hour)}, "ceb"},
+	}
+	for _, tt := range tests {
+		got, err := repo.List(ctx, tt.filter)
+		if err != nil || ids(got) != tt.want || got.NextCursor != "" {
+			t.Errorf("List(%+v) = %q %q %v, want %q", tt.filter, ids(got), got.NextCursor, err, tt.want)
+		}
+	}
+
+	var all string
+	filter := ports.TxFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		p, err := repo.List(ctx, filter)
+		if err != nil || pages > 3 {
+			t.Fatalf("paging stopped at %q: %v", all, err)
+		}
+		all += ids(p)
+		if p.NextCursor == "" {
+			break
+		}
+		filter.Cursor = p.NextCursor
+	}
+	if all != "dceba" {
+		t.Fatalf("expected pages to cover every tx once, got %q", all)
+	}
+
+	if _, err := repo.List(ctx, ports.TxFilter{Cursor: "not a cursor"}); !errors.Is(err, ports.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	pending, _ := repo.ListByStatus(ctx, entity.TxStatusSent, 0)
+	if len(pending) != 3 || pending[0].ID != "b" || pending[1].ID != "e" || pending[2].ID != "c" {
+		t.Fatalf("expected oldest sent tx first, got %+v", pending)
+	}
+}
+
+func TestInMemoryRepository_Outbox(t *testing.T) {
+	repo := NewInMemoryTxRepository().(*InMemoryTxRepository)
+	ctx := context.Background()
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", Status: entity.TxStatusPending}, entity.TxCreatedEvent{TxID: "t1"}); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1"}, entity.TxCreatedEvent{TxID: "t1"}); !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	events := []entity.Event{entity.TxSignedEvent{TxID: "t1", TxHash: "0xa"}}
+	if err := repo.UpdateStatus(ctx, "t1", entity.TxStatusSigned, nil, ports.UpdateMeta{Events: events}); err != nil {
+		t.Fatalf("update error: %v", err)
+	}
+	err := repo.UpdateStatus(ctx, "t1", entity.TxStatusConfirmed, nil, ports.UpdateMeta{
+		Events: []entity.Event{entity.TxConfirmedEvent{TxID: "t1"}},
+	})
+	if !errors.Is(err, entity.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+
+	msgs, err := repo.Claim(ctx, 1, time.Hour)
+	if err != nil || len(msgs) != 1 || msgs[0].Topic != "TxCreated" || msgs[0].Attempts != 1 {
+		t.Fatalf("unexpected claim %+v %v", msgs, err)
+	}
+	rest, _ := repo.Claim(ctx, 10, time.Hour)
+	if len(rest) != 1 || rest[0].Topic != "TxSigned" || rest[0].ID <= msgs[0].ID {
+		t.Fatalf("expected the signed event next, got %+v", rest)
+	}
+	if none, _ := repo.Claim(ctx, 10, time.Hour); len(none) != 0 {
+		t.Fatalf("expected leased messages to stay hidden, got %+v", none)
+	}
+
+	if err := repo.MarkPublished(ctx, msgs[0].ID); err != nil {
+		t.Fatalf("mark published: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, rest[0].ID, "boom"); err != nil || repo.outbox[0].lastError != "boom" {
+		t.Fatalf("expected failure to be recorded, got %+v %v", repo.outbox, err)
+	}
+	repo.outbox[0].lockedUntil = time.Time{} // lease expired
+	retried, _ := repo.Claim(ctx, 10, time.Hour)
+	if len(retried) != 1 || retried[0].ID != rest[0].ID || retried[0].Attempts != 2 {
+		t.Fatalf("expected the failed message to be retried, got %+v", retried)
+	}
+}
+
+func TestInMemoryRepository_IdempotencyKey(t *testing.T) {
+	repo := NewInMemoryTxRepository()
+	ctx := context.Background()
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t1", IdempotencyKey: "k1", Status: entity.TxStatusPending}); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t2", IdempotencyKey: "k1"}); !errors.Is(err, ports.ErrDuplicateIdempotencyKey) {
+		t.Fatalf("expected ErrDuplicateIdempotencyKey, got %v", err)
+	}
+	for _, id := range []string{"t3", "t4"} {
+		if err := repo.Save(ctx, &entity.Transaction{ID: id, Status: entity.TxStatusPending}); err != nil {
+			t.Fatalf("expected keyless saves to succeed, got %v", err)
+		}
+	}
+	if got, _ := repo.FindByIdempotencyKey(ctx, "k1"); got == nil || got.ID != "t1" {
+		t.Fatalf("expected t1 by key, got %+v", got)
+	}
+
+	// moving the key to a new value frees the old one
+	tx, _ := repo.FindByID(ctx, "t1")
+	tx.IdempotencyKey = "k2"
+	if err := repo.Save(ctx, tx); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if got, _ := repo.FindByIdempotencyKey(ctx, "k1"); got != nil {
+		t.Fatalf("expected k1 to be released, got %+v", got)
+	}
+	if err := repo.Save(ctx, &entity.Transaction{ID: "t5", IdempotencyKey: "k1"}); err != nil {
+		t.Fatalf("expected k1 to be reusable, got %v", err)
+	}
+}
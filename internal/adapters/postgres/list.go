@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// listCursor is the position after which the next List page starts: the
+// creation time and id of the last transaction of the previous page.
+type listCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func encodeCursor(tx *entity.Transaction) string {
+	b, _ := json.Marshal(listCursor{CreatedAt: tx.CreatedAt, ID: tx.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns nil for an empty cursor and ports.ErrInvalidCursor for
+// one that was not produced by encodeCursor.
+func decodeCursor(s string) (*listCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ports.ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return nil, ports.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// listLimit applies the List page size defaults of ports.TxFilter.
+func listLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return ports.DefaultListLimit
+	case limit > ports.MaxListLimit:
+		return ports.MaxListLimit
+	}
+	return limit
+}
+
+// page cuts txs, fetched with one row more than limit, into a TxPage.
+func page(txs []*entity.Transaction, limit int) ports.TxPage {
+	if len(txs) <= limit {
+		return ports.TxPage{Transactions: txs}
+	}
+	txs = txs[:limit]
+	return ports.TxPage{Transactions: txs, NextCursor: encodeCursor(txs[limit-1])}
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationLockKey is the advisory lock held while a migration step runs so
+// concurrent migrators (e.g. several replicas starting with auto-migrate)
+// apply every version exactly once.
+const migrationLockKey = 0x636861696e636f6e // "chaincon"
+
+var (
+	ErrNoDownMigration = errors.New("migration has no down script")
+
+	migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is one schema version read from a pair of
+// <version>_<name>.up.sql and <version>_<name>.down.sql files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus reports whether a version is applied. Versions recorded in
+// schema_migrations without a file are listed with an empty Up script.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of a file system and records them in the
+// schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator reads the *.sql files of fsys. File names must follow
+// <version>_<name>.(up|down).sql and every version needs an up script.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrate applies every pending up migration of fsys.
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	m, err := NewMigrator(db, fsys)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
+	return err
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, name := range names {
+		parts := migrationFile.FindStringSubmatch(name)
+		if parts == nil {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.(up|down).sql", name)
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		} else if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", name, version, m)
+		}
+		if parts[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the pending migrations in version order, each in its own
+// transaction, and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	for _, mig := range m.migrations {
+		done := false
+		err := m.step(ctx, func(tx *sql.Tx) error {
+			var exists bool
+			if err := tx.QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, mig.Version).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+				return err
+			}
+			done = true
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", mig, err)
+		}
+		if done {
+			applied = append(applied, mig)
+		}
+	}
+	return applied, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	for i := 0; i < steps; i++ {
+		var mig *Migration
+		err := m.step(ctx, func(tx *sql.Tx) error {
+			var version int64
+			err := tx.QueryRowContext(ctx,
+				`SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1`).Scan(&version)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if mig = m.find(version); mig == nil {
+				return fmt.Errorf("applied version %d has no migration file", version)
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("%s: %w", mig, ErrNoDownMigration)
+			}
+			if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+				return fmt.Errorf("migration %s: %w", mig, err)
+			}
+			_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version)
+			return err
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if mig == nil {
+			break
+		}
+		reverted = append(reverted, *mig)
+	}
+	return reverted, nil
+}
+
+// Status lists every known version in order with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied := map[int64]MigrationStatus{}
+	err := m.step(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var s MigrationStatus
+			var at time.Time
+			if err := rows.Scan(&s.Version, &s.Name, &at); err != nil {
+				return err
+			}
+			s.AppliedAt = &at
+			applied[s.Version] = s
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := MigrationStatus{Migration: mig}
+		if a, ok := applied[mig.Version]; ok {
+			s.AppliedAt = a.AppliedAt
+			delete(applied, mig.Version)
+		}
+		out = append(out, s)
+	}
+	for _, s := range applied {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// step runs fn in a transaction holding the migration lock, creating the
+// schema_migrations table first if needed.
+func (m *Migrator) step(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(migrationLockKey)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
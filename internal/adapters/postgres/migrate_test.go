@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"math/big"
+	"testing"
+	"testing/fstest"
+
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/migrations"
+
+	"github.com/google/uuid"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatalf("load embedded migrations: %v", err)
+	}
+	if len(m.migrations) < 2 {
+		t.Fatalf("expected embedded migrations, got %v", m.migrations)
+	}
+	for i, mig := range m.migrations {
+		if mig.Version != int64(i+1) {
+			t.Errorf("expected version %d, got %s", i+1, mig)
+		}
+		if mig.Down == "" {
+			t.Errorf("%s has no down migration", mig)
+		}
+	}
+	if got := m.migrations[1].String(); got != "0002_align_transactions" {
+		t.Fatalf("unexpected migration name %q", got)
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	tests := map[string]fstest.MapFS{
+		"bad name":       {"create.sql": file("SELECT 1")},
+		"down only":      {"0001_a.down.sql": file("SELECT 1")},
+		"empty up":       {"0001_a.up.sql": file("")},
+		"shared version": {"0001_a.up.sql": file("SELECT 1"), "0001_b.up.sql": file("SELECT 1")},
+		"unreadable":     {"0001_a.up.sql": &fstest.MapFile{Mode: fs.ModeDir}},
+	}
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	ok := fstest.MapFS{
+		"0010_b.up.sql": file("SELECT 2"), "0002_a.up.sql": file("SELECT 1"), "0002_a.down.sql": file("SELECT 0"),
+	}
+	got, err := loadMigrations(ok)
+	if err != nil || len(got) != 2 || got[0].Version != 2 || got[0].Down != "SELECT 0" || got[1].Name != "b" {
+		t.Fatalf("unexpected migrations %+v %v", got, err)
+	}
+}
+
+func TestMigrator_Integration(t *testing.T) {
+	db := integrationDB(t)
+	repo := NewPostgresTxRepository(db)
+	ctx := context.Background()
+	m, err := NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("expected schema to be current, got %v %v", applied, err)
+	}
+
+	to := "0xto"
+	tx := &entity.Transaction{
+		ID: uuid.NewString(), Chain: "BSC", To: &to, Gas: 21000, Data: []byte{0xde, 0xad},
+		MaxFeePerGas: big.NewInt(30), MaxPriorityFeePerGas: big.NewInt(2), Status: entity.TxStatusPending,
+	}
+	if err := repo.Save(ctx, tx); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	reverted, err := m.Down(ctx, 7)
+	if err != nil || len(reverted) != 7 || reverted[0].Version != 8 || reverted[6].Version != 2 {
+		t.Fatalf("expected 0008 to 0002 to be reverted, got %v %v", reverted, err)
+	}
+	states, err := m.Status(ctx)
+	if err != nil || len(states) < 8 || states[0].AppliedAt == nil || states[1].AppliedAt != nil {
+		t.Fatalf("unexpected status %+v %v", states, err)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 7 {
+		t.Fatalf("expected 0002 to 0008 to be reapplied, got %v %v", applied, err)
+	}
+
+	got, err := repo.FindByID(ctx, tx.ID)
+	if err != nil || got == nil {
+		t.Fatalf("find: %v %v", got, err)
+	}
+	if got.Chain != "BSC" || string(got.Data) != "\xde\xad" || got.MaxFeePerGas.Int64() != 30 ||
+		got.MaxPriorityFeePerGas.Int64() != 2 || got.Status != entity.TxStatusPending {
+		t.Fatalf("columns not preserved across down/up: %+v", got)
+	}
+
+	if history, err := repo.History(ctx, tx.ID); err != nil || len(history) != 1 || history[0].Actor != "migration" {
+		t.Fatalf("expected backfilled history entry, got %+v %v", history, err)
+	}
+
+	noDown := &Migrator{db: db, migrations: []Migration{{Version: 8, Name: "add_authentication", Up: "SELECT 1"}}}
+	if _, err := noDown.Down(ctx, 1); !errors.Is(err, ErrNoDownMigration) {
+		t.Fatalf("expected ErrNoDownMigration, got %v", err)
+	}
+}
@@ -0,0 +1,628 @@
+package postgres
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	// registers the "pgx" database/sql driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresTxRepository stores transactions in the `transactions` table
+// defined by the files in migrations/. The access list and the attempts have
+// no column of their own and are kept in the payload jsonb column. Status
+// changes are appended to `transaction_events`, and events to `outbox`, in
+// the same database transaction as the row they describe.
+type PostgresTxRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTxRepository(db *sql.DB) *PostgresTxRepository {
+	return &PostgresTxRepository{db: db}
+}
+
+// Open connects to the database at dsn with the pgx driver and checks the
+// connection.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+	return db, nil
+}
+
+// txPayload holds the Transaction fields that have no column in the schema.
+type txPayload struct {
+	AccessList []entity.AccessTuple `json:"access_list,omitempty"`
+	Attempts   []entity.TxAttempt   `json:"attempts,omitempty"`
+	FixedNonce bool                 `json:"fixed_nonce,omitempty"`
+	FeeTier    entity.FeeTier       `json:"fee_tier,omitempty"`
+}
+
+const txColumns = `id::text, tx_hash, chain, chain_id, from_address, to_address, value::text, nonce,
+	gas_limit, gas_price::text, max_fee_per_gas::text, max_priority_fee_per_gas::text, data, raw_tx,
+	payload::text, receipt::text, status, attempts, sent_at, confirmed_at, error_message,
+	idempotency_key, request_hash, requested_by, version, created_at, updated_at`
+
+// insertRow and updateRow take the id as $1 and rowArgs as $2 to $24.
+const (
+	insertRow = `
+		INSERT INTO transactions (id, tx_hash, chain, chain_id, from_address, to_address, value,
+			nonce, gas_limit, gas_price, max_fee_per_gas, max_priority_fee_per_gas, data, raw_tx,
+			payload, receipt, status, attempts, sent_at, confirmed_at, error_message, idempotency_key,
+			request_hash, requested_by, version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::text::numeric, $8, $9, $10::text::numeric,
+			$11::text::numeric, $12::text::numeric, $13, $14, $15::text::jsonb, $16::text::jsonb,
+			$17, $18, $19, $20, $21, $22, $23, $24, 1, COALESCE($25::timestamptz, now()))
+		ON CONFLICT (id) DO NOTHING`
+	updateRow = `
+		UPDATE transactions SET tx_hash = $2, chain = $3, chain_id = $4, from_address = $5,
+			to_address = $6, value = $7::text::numeric, nonce = $8, gas_limit = $9,
+			gas_price = $10::text::numeric, max_fee_per_gas = $11::text::numeric,
+			max_priority_fee_per_gas = $12::text::numeric, data = $13, raw_tx = $14,
+			payload = $15::text::jsonb, receipt = $16::text::jsonb, status = $17, attempts = $18,
+			sent_at = $19, confirmed_at = $20, error_message = $21, idempotency_key = $22,
+			request_hash = $23, requested_by = $24, version = $25
+		WHERE id = $1::uuid`
+)
+
+// Save inserts a new row or, with tx.Version set, overwrites the locked row
+// at that version.
+func (r *PostgresTxRepository) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
+	if tx == nil || tx.ID == "" {
+		return errors.New("invalid transaction")
+	}
+	args, err := rowArgs(tx)
+	if err != nil {
+		return err
+	}
+	msgs, err := outboxMessages(events)
+	if err != nil {
+		return err
+	}
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbTx.Rollback() }()
+
+	var (
+		current string
+		version int64
+	)
+	err = dbTx.QueryRowContext(ctx, `SELECT status, version FROM transactions WHERE id = $1::uuid FOR UPDATE`, tx.ID).
+		Scan(&current, &version)
+	exists := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("save transaction %s: %w", tx.ID, err)
+	}
+	if exists != (tx.Version > 0) || exists && version != tx.Version {
+		return ports.ErrConflict
+	}
+
+	prev := entity.TxStatusUnknown
+	if exists {
+		if prev, err = entity.ParseTxStatus(current); err != nil {
+			return err
+		}
+		if prev != tx.Status {
+			if err := entity.CheckTransition(prev, tx.Status); err != nil {
+				return err
+			}
+		}
+		version++
+		_, err = dbTx.ExecContext(ctx, updateRow, append([]interface{}{tx.ID}, append(args, version)...)...)
+		if isUniqueViolation(err, idempotencyKeyConstraint) {
+			return ports.ErrDuplicateIdempotencyKey
+		}
+	} else {
+		var createdAt interface{}
+		if !tx.CreatedAt.IsZero() {
+			createdAt = tx.CreatedAt
+		}
+		var res sql.Result
+		res, err = dbTx.ExecContext(ctx, insertRow, append([]interface{}{tx.ID}, append(args, createdAt)...)...)
+		if isUniqueViolation(err, idempotencyKeyConstraint) {
+			return ports.ErrDuplicateIdempotencyKey
+		}
+		if err == nil {
+			// a concurrent Save inserted the same id first
+			if n, _ := res.RowsAffected(); n == 0 {
+				return ports.ErrConflict
+			}
+		}
+		version = 1
+	}
+	if err != nil {
+		return fmt.Errorf("save transaction %s: %w", tx.ID, err)
+	}
+	if err := record(ctx, dbTx, tx.ID, prev, tx.Status, ports.UpdateMeta{}); err != nil {
+		return err
+	}
+	if err := enqueue(ctx, dbTx, msgs); err != nil {
+		return err
+	}
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+	tx.Version = version
+	return nil
+}
+
+// FindByID returns nil for ids that are not UUIDs, since no row can match.
+func (r *PostgresTxRepository) FindByID(ctx context.Context, id string) (*entity.Transaction, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil
+	}
+	return r.findOne(ctx, `SELECT `+txColumns+` FROM transactions WHERE id = $1::uuid`, id)
+}
+
+// FindByHash matches the current hash and every attempt kept in the payload.
+func (r *PostgresTxRepository) FindByHash(ctx context.Context, hash string) (*entity.Transaction, error) {
+	return r.findOne(ctx, `SELECT `+txColumns+` FROM transactions
+		WHERE tx_hash = $1
+		   OR payload->'attempts' @> jsonb_build_array(jsonb_build_object('tx_hash', $1::text))
+		LIMIT 1`, hash)
+}
+
+func (r *PostgresTxRepository) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	return r.findOne(ctx, `SELECT `+txColumns+` FROM transactions WHERE idempotency_key = $1`, key)
+}
+
+// idempotencyKeyConstraint is the unique constraint on idempotency_key.
+const idempotencyKeyConstraint = "transactions_idempotency_key_key"
+
+// isUniqueViolation reports whether err is a unique_violation of constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// UpdateStatus locks the row, checks the version and the transition, applies
+// updates like the in-memory repository and writes the row back in one
+// transaction, so racing writers cannot leave a terminal status.
+func (r *PostgresTxRepository) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
+	if _, err := uuid.Parse(txID); err != nil {
+		return errors.New("transaction not found")
+	}
+	msgs, err := outboxMessages(meta.Events)
+	if err != nil {
+		return err
+	}
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbTx.Rollback() }()
+
+	tx, err := scanTx(dbTx.QueryRowContext(ctx, `SELECT `+txColumns+` FROM transactions WHERE id = $1::uuid FOR UPDATE`, txID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("transaction not found")
+	}
+	if err != nil {
+		return err
+	}
+	if meta.Version != 0 && meta.Version != tx.Version {
+		return ports.ErrConflict
+	}
+	prev := tx.Status
+	if err := entity.CheckTransition(prev, status); err != nil {
+		return err
+	}
+	tx.Status = status
+	applyUpdates(tx, updates)
+
+	args, err := rowArgs(tx)
+	if err != nil {
+		return err
+	}
+	if _, err := dbTx.ExecContext(ctx, updateRow, append([]interface{}{txID}, append(args, tx.Version+1)...)...); err != nil {
+		return fmt.Errorf("update transaction %s: %w", txID, err)
+	}
+	if err := record(ctx, dbTx, txID, prev, status, meta); err != nil {
+		return err
+	}
+	if err := enqueue(ctx, dbTx, msgs); err != nil {
+		return err
+	}
+	return dbTx.Commit()
+}
+
+// enqueue appends msgs to the outbox.
+func enqueue(ctx context.Context, dbTx *sql.Tx, msgs []ports.OutboxMessage) error {
+	for _, m := range msgs {
+		if _, err := dbTx.ExecContext(ctx, `INSERT INTO outbox (topic, payload) VALUES ($1, $2::text::jsonb)`,
+			m.Topic, string(m.Payload)); err != nil {
+			return fmt.Errorf("enqueue %s event: %w", m.Topic, err)
+		}
+	}
+	return nil
+}
+
+// Claim uses SKIP LOCKED so that several relays can drain the outbox
+// concurrently without claiming the same message.
+func (r *PostgresTxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]ports.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE outbox SET locked_until = now() + $2::bigint * interval '1 microsecond', attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND (locked_until IS NULL OR locked_until <= now())
+			ORDER BY id LIMIT $1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, topic, payload::text, attempts, created_at`, limit, lease.Microseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ports.OutboxMessage
+	for rows.Next() {
+		var (
+			m       ports.OutboxMessage
+			payload string
+		)
+		if err := rows.Scan(&m.ID, &m.Topic, &payload, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.Payload = []byte(payload)
+		res = append(res, m)
+	}
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, rows.Err()
+}
+
+func (r *PostgresTxRepository) MarkPublished(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET published_at = now(), locked_until = NULL WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresTxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox SET last_error = $2 WHERE id = $1`, id, reason)
+	return err
+}
+
+// record appends the status change of txID to transaction_events, if any.
+func record(ctx context.Context, dbTx *sql.Tx, txID string, prev, status entity.TxStatus, meta ports.UpdateMeta) error {
+	change, ok := statusChange(txID, prev, status, meta, time.Now().UTC())
+	if !ok {
+		return nil
+	}
+	if _, err := dbTx.ExecContext(ctx, `
+		INSERT INTO transaction_events (tx_id, from_status, to_status, actor, reason, created_at)
+		VALUES ($1::uuid, $2, $3, $4, $5, $6)`,
+		txID, change.From.String(), change.To.String(), change.Actor, nullString(change.Reason), change.At); err != nil {
+		return fmt.Errorf("record status change of %s: %w", txID, err)
+	}
+	return nil
+}
+
+// History returns nil for ids that are not UUIDs, since no row can match.
+func (r *PostgresTxRepository) History(ctx context.Context, txID string) ([]entity.StatusChange, error) {
+	if _, err := uuid.Parse(txID); err != nil {
+		return nil, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tx_id::text, from_status, to_status, actor, reason, created_at
+		FROM transaction_events WHERE tx_id = $1::uuid ORDER BY id`, txID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.StatusChange
+	for rows.Next() {
+		change, err := scanStatusChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, change)
+	}
+	return res, rows.Err()
+}
+
+func scanStatusChange(row rowScanner) (entity.StatusChange, error) {
+	var (
+		c        entity.StatusChange
+		from, to string
+		reason   sql.NullString
+		err      error
+	)
+	if err := row.Scan(&c.TxID, &from, &to, &c.Actor, &reason, &c.At); err != nil {
+		return c, err
+	}
+	if c.From, err = entity.ParseTxStatus(from); err != nil {
+		return c, err
+	}
+	if c.To, err = entity.ParseTxStatus(to); err != nil {
+		return c, err
+	}
+	c.Reason = reason.String
+	return c, nil
+}
+
+func (r *PostgresTxRepository) ListPending(ctx context.Context, limit int) ([]*entity.Transaction, error) {
+	return r.ListByStatus(ctx, entity.TxStatusPending, limit)
+}
+
+// ListByStatus returns the oldest transactions in status first.
+func (r *PostgresTxRepository) ListByStatus(ctx context.Context, status entity.TxStatus, limit int) ([]*entity.Transaction, error) {
+	query := `SELECT ` + txColumns + ` FROM transactions WHERE status = $1 ORDER BY created_at, id`
+	args := []interface{}{status.String()}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+	return r.query(ctx, query, args...)
+}
+
+// List pages through the matching rows with a keyset on (created_at, id).
+func (r *PostgresTxRepository) List(ctx context.Context, filter ports.TxFilter) (ports.TxPage, error) {
+	query, args, limit, err := listQuery(filter)
+	if err != nil {
+		return ports.TxPage{}, err
+	}
+	txs, err := r.query(ctx, query, args...)
+	if err != nil {
+		return ports.TxPage{}, err
+	}
+	return page(txs, limit), nil
+}
+
+// listQuery builds the SELECT for List. It fetches one row more than the page
+// size so that List knows whether another page follows.
+func listQuery(f ports.TxFilter) (query string, args []interface{}, limit int, err error) {
+	cursor, err := decodeCursor(f.Cursor)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if cursor != nil {
+		if _, err := uuid.Parse(cursor.ID); err != nil {
+			return "", nil, 0, ports.ErrInvalidCursor
+		}
+	}
+	var where []string
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, len(f.Statuses))
+		for i, s := range f.Statuses {
+			statuses[i] = s.String()
+		}
+		where = append(where, "status = ANY("+arg(statuses)+"::text[])")
+	}
+	if f.Chain != "" {
+		where = append(where, "lower(chain) = lower("+arg(f.Chain)+")")
+	}
+	if f.From != "" {
+		where = append(where, "lower(from_address) = lower("+arg(f.From)+")")
+	}
+	if f.To != "" {
+		where = append(where, "lower(to_address) = lower("+arg(f.To)+")")
+	}
+	if !f.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= "+arg(f.CreatedAfter))
+	}
+	if !f.CreatedBefore.IsZero() {
+		where = append(where, "created_at < "+arg(f.CreatedBefore))
+	}
+	if cursor != nil {
+		where = append(where, "(created_at, id) < ("+arg(cursor.CreatedAt)+", "+arg(cursor.ID)+"::uuid)")
+	}
+
+	query = `SELECT ` + txColumns + ` FROM transactions`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	limit = listLimit(f.Limit)
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ` + arg(limit+1)
+	return query, args, limit, nil
+}
+
+func (r *PostgresTxRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entity.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*entity.Transaction, 0, 10)
+	for rows.Next() {
+		tx, err := scanTx(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, tx)
+	}
+	return res, rows.Err()
+}
+
+func (r *PostgresTxRepository) findOne(ctx context.Context, query string, arg string) (*entity.Transaction, error) {
+	tx, err := scanTx(r.db.QueryRowContext(ctx, query, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return tx, err
+}
+
+// rowArgs returns the column values of tx in the order of placeholders $2 to
+// $24 used by Save and UpdateStatus.
+func rowArgs(tx *entity.Transaction) ([]interface{}, error) {
+	payload, err := json.Marshal(txPayload{
+		AccessList: tx.AccessList, Attempts: tx.Attempts, FixedNonce: tx.FixedNonce, FeeTier: tx.FeeTier,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var receipt interface{}
+	if tx.Receipt != nil {
+		b, err := json.Marshal(tx.Receipt)
+		if err != nil {
+			return nil, err
+		}
+		receipt = string(b)
+	}
+	var to interface{}
+	if tx.To != nil {
+		to = *tx.To
+	}
+	var data interface{}
+	if len(tx.Data) > 0 {
+		data = tx.Data
+	}
+	return []interface{}{
+		nullString(tx.TxHash),
+		nullString(tx.Chain),
+		bigToNull(tx.ChainID),
+		nullString(tx.From),
+		to,
+		bigToNull(tx.Value),
+		int64(tx.Nonce),
+		int64(tx.Gas),
+		bigToNull(tx.GasPrice),
+		bigToNull(tx.MaxFeePerGas),
+		bigToNull(tx.MaxPriorityFeePerGas),
+		data,
+		nullString(tx.RawTxHex),
+		string(payload),
+		receipt,
+		tx.Status.String(),
+		len(tx.Attempts),
+		nullTime(tx.SentAt),
+		nullTime(tx.ConfirmedAt),
+		tx.ErrorMessage,
+		nullString(tx.IdempotencyKey),
+		nullString(tx.RequestHash),
+		nullString(tx.RequestedBy),
+	}, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTx(row rowScanner) (*entity.Transaction, error) {
+	var (
+		tx                                    entity.Transaction
+		hash, chain, chainID, from, to, value sql.NullString
+		gasPrice, maxFee, maxTip, raw         sql.NullString
+		payload, receipt, errMsg              sql.NullString
+		idemKey, requestHash, requestedBy     sql.NullString
+		nonce, gas                            sql.NullInt64
+		attempts                              int
+		sentAt, confirmedAt                   sql.NullTime
+		status                                string
+	)
+	if err := row.Scan(&tx.ID, &hash, &chain, &chainID, &from, &to, &value, &nonce, &gas, &gasPrice,
+		&maxFee, &maxTip, &tx.Data, &raw, &payload, &receipt, &status, &attempts, &sentAt, &confirmedAt,
+		&errMsg, &idemKey, &requestHash, &requestedBy, &tx.Version, &tx.CreatedAt, &tx.UpdatedAt); err != nil {
+		return nil, err
+	}
+	tx.TxHash, tx.Chain, tx.From, tx.RawTxHex = hash.String, chain.String, from.String, raw.String
+	tx.IdempotencyKey, tx.RequestHash, tx.RequestedBy = idemKey.String, requestHash.String, requestedBy.String
+	if to.Valid {
+		tx.To = &to.String
+	}
+	if errMsg.Valid {
+		tx.ErrorMessage = &errMsg.String
+	}
+	if sentAt.Valid {
+		tx.SentAt = &sentAt.Time
+	}
+	if confirmedAt.Valid {
+		tx.ConfirmedAt = &confirmedAt.Time
+	}
+	tx.Nonce, tx.Gas = uint64(nonce.Int64), uint64(gas.Int64)
+
+	var err error
+	for _, f := range []struct {
+		dst **big.Int
+		src sql.NullString
+	}{
+		{&tx.ChainID, chainID}, {&tx.Value, value}, {&tx.GasPrice, gasPrice},
+		{&tx.MaxFeePerGas, maxFee}, {&tx.MaxPriorityFeePerGas, maxTip},
+	} {
+		if *f.dst, err = nullToBig(f.src); err != nil {
+			return nil, err
+		}
+	}
+	if tx.Status, err = entity.ParseTxStatus(status); err != nil {
+		return nil, err
+	}
+
+	if payload.Valid {
+		var p txPayload
+		if err := json.Unmarshal([]byte(payload.String), &p); err != nil {
+			return nil, fmt.Errorf("decode payload of %s: %w", tx.ID, err)
+		}
+		tx.AccessList, tx.Attempts, tx.FixedNonce, tx.FeeTier = p.AccessList, p.Attempts, p.FixedNonce, p.FeeTier
+	}
+	if receipt.Valid {
+		tx.Receipt = &entity.Receipt{}
+		if err := json.Unmarshal([]byte(receipt.String), tx.Receipt); err != nil {
+			return nil, fmt.Errorf("decode receipt of %s: %w", tx.ID, err)
+		}
+	}
+	return &tx, nil
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// bigToNull renders b as a decimal string for numeric and text columns.
+func bigToNull(b *big.Int) interface{} {
+	if b == nil {
+		return nil
+	}
+	return b.String()
+}
+
+func nullToBig(s sql.NullString) (*big.Int, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	// numeric columns may come back with a fractional part such as "10.0"
+	str := s.String
+	if i := strings.IndexByte(str, '.'); i >= 0 && strings.Trim(str[i+1:], "0") == "" {
+		str = str[:i]
+	}
+	b, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s.String)
+	}
+	return b, nil
+}
+
+var (
+	_ ports.TxRepositoryPort = (*PostgresTxRepository)(nil)
+	_ ports.OutboxPort       = (*PostgresTxRepository)(nil)
+)
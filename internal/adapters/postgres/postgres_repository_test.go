@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+)
+
+// fakeRow scans the values produced by rowArgs the way the SELECT returns
+// them, so the column mapping can be checked without a database.
+type fakeRow struct {
+	values []interface{}
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(dest) != len(r.values) {
+		return errors.New("column count mismatch")
+	}
+	for i, d := range dest {
+		v := r.values[i]
+		switch d := d.(type) {
+		case *string:
+			*d = v.(string)
+		case *sql.NullString:
+			if p, ok := v.(*string); ok {
+				v = nil
+				if p != nil {
+					v = *p
+				}
+			}
+			if v != nil {
+				*d = sql.NullString{String: v.(string), Valid: true}
+			}
+		case *sql.NullInt64:
+			if v != nil {
+				*d = sql.NullInt64{Int64: v.(int64), Valid: true}
+			}
+		case *sql.NullTime:
+			if v != nil {
+				*d = sql.NullTime{Time: v.(time.Time), Valid: true}
+			}
+		case *int:
+			*d = v.(int)
+		case *int64:
+			*d = v.(int64)
+		case *[]byte:
+			if v != nil {
+				*d = v.([]byte)
+			}
+		case *time.Time:
+			*d = v.(time.Time)
+		default:
+			return errors.New("unexpected destination type")
+		}
+	}
+	return nil
+}
+
+// rowFor lays out rowArgs in SELECT column order.
+func rowFor(t *testing.T, tx *entity.Transaction) fakeRow {
+	t.Helper()
+	args, err := rowArgs(tx)
+	if err != nil {
+		t.Fatalf("rowArgs: %v", err)
+	}
+	// SELECT has id first and version, created_at, updated_at last
+	values := append([]interface{}{tx.ID}, args...)
+	return fakeRow{values: append(values, tx.Version, tx.CreatedAt, tx.UpdatedAt)}
+}
+
+func TestRowArgsScanRoundTrip(t *testing.T) {
+	to := "0xto"
+	msg := "boom"
+	sentAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	in := &entity.Transaction{
+		ID: "4b1c2f0e-8f7e-4c1a-9d55-0a8f1b8e9c01", From: "0xfrom", Chain: "POLYGON", To: &to,
+		Value: huge, Gas: 21000, GasPrice: big.NewInt(7), MaxFeePerGas: big.NewInt(50), MaxPriorityFeePerGas: big.NewInt(2),
+		Nonce: 42, Data: []byte{0xca, 0xfe}, ChainID: big.NewInt(137), RawTxHex: "0x02", TxHash: "0xhash",
+		Status: entity.TxStatusSent, CreatedAt: sentAt, UpdatedAt: sentAt, SentAt: &sentAt, ErrorMessage: &msg, Version: 7,
+		IdempotencyKey: "k1", RequestHash: "h1", RequestedBy: "api-key:ops",
+		AccessList: []entity.AccessTuple{{Address: "0xa", StorageKeys: []string{"0x1"}}},
+		Receipt:    &entity.Receipt{BlockNumber: 9, Status: entity.ReceiptStatusSuccess, EffectiveGasPrice: big.NewInt(3)},
+		Attempts:   []entity.TxAttempt{{TxHash: "0xold", Status: entity.AttemptStatusReplaced}, {TxHash: "0xhash"}},
+	}
+
+	args, _ := rowArgs(in)
+	if args[15] != "sent" || args[16] != 2 {
+		t.Fatalf("expected status text and attempt count, got %v", args[15:17])
+	}
+	out, err := scanTx(rowFor(t, in))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	a, _ := json.Marshal(in)
+	b, _ := json.Marshal(out)
+	if string(a) != string(b) {
+		t.Fatalf("round trip mismatch:\n in: %s\nout: %s", a, b)
+	}
+
+	// unset optional columns come back as nil
+	empty := &entity.Transaction{ID: "x", Status: entity.TxStatusPending}
+	out, err = scanTx(rowFor(t, empty))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if out.To != nil || out.Value != nil || out.ChainID != nil || out.Receipt != nil || out.TxHash != "" {
+		t.Fatalf("expected empty optional fields, got %+v", out)
+	}
+}
+
+func TestScanTxErrors(t *testing.T) {
+	if _, err := scanTx(fakeRow{err: sql.ErrNoRows}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected scan error to propagate, got %v", err)
+	}
+	base := &entity.Transaction{ID: "x", Status: entity.TxStatusPending}
+	tests := map[string]func(values []interface{}){
+		"status":   func(v []interface{}) { v[16] = "lost" },
+		"value":    func(v []interface{}) { v[6] = "ten" },
+		"chain id": func(v []interface{}) { v[3] = "1e3" },
+		"gas":      func(v []interface{}) { v[9] = "x" },
+		"max fee":  func(v []interface{}) { v[10] = "0x1" },
+		"payload":  func(v []interface{}) { v[14] = "{" },
+		"receipt":  func(v []interface{}) { v[15] = "[" },
+	}
+	for name, mutate := range tests {
+		row := rowFor(t, base)
+		mutate(row.values)
+		if _, err := scanTx(row); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNumericHelpers(t *testing.T) {
+	if got, err := nullToBig(sql.NullString{String: "1000.000", Valid: true}); err != nil || got.Int64() != 1000 {
+		t.Fatalf("expected integral numeric to parse, got %v %v", got, err)
+	}
+	if _, err := nullToBig(sql.NullString{String: "1.5", Valid: true}); err == nil {
+		t.Fatalf("expected fractional numeric to be rejected")
+	}
+	if bigToNull(nil) != nil || bigToNull(big.NewInt(-5)) != "-5" {
+		t.Fatalf("unexpected bigToNull output")
+	}
+	if nullString("") != nil || nullString("a") != "a" {
+		t.Fatalf("unexpected nullString output")
+	}
+	now := time.Now()
+	if nullTime(nil) != nil || nullTime(&now) != now {
+		t.Fatalf("unexpected nullTime output")
+	}
+}
+
+func TestScanStatusChange(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c, err := scanStatusChange(fakeRow{values: []interface{}{"x", "pending", "signed", "signer", nil, at}})
+	if err != nil || c.From != entity.TxStatusPending || c.To != entity.TxStatusSigned || c.Actor != "signer" ||
+		c.Reason != "" || !c.At.Equal(at) {
+		t.Fatalf("unexpected change %+v %v", c, err)
+	}
+	for _, values := range [][]interface{}{
+		{"x", "lost", "signed", "signer", nil, at},
+		{"x", "pending", "lost", "signer", "why", at},
+	} {
+		if _, err := scanStatusChange(fakeRow{values: values}); err == nil {
+			t.Errorf("expected error for %v", values)
+		}
+	}
+}
+
+func TestListQuery(t *testing.T) {
+	after := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	cursor := encodeCursor(&entity.Transaction{ID: "6b1c6f1e-2f1a-4f36-9d7a-0c3f5f0d4a11", CreatedAt: after.Add(time.Hour)})
+	query, args, limit, err := listQuery(ports.TxFilter{
+		Statuses: []entity.TxStatus{entity.TxStatusSent, entity.TxStatusFailed},
+		Chain:    "ETH", From: "0xAbC", CreatedAfter: after, Limit: 1000, Cursor: cursor,
+	})
+	if err != nil || limit != ports.MaxListLimit {
+		t.Fatalf("unexpected limit %d %v", limit, err)
+	}
+	for _, part := range []string{
+		"status = ANY($1::text[])", "lower(chain) = lower($2)", "lower(from_address) = lower($3)",
+		"created_at >= $4", "(created_at, id) < ($5, $6::uuid)", "ORDER BY created_at DESC, id DESC LIMIT $7",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("expected %q in %s", part, query)
+		}
+	}
+	if len(args) != 7 || args[6] != ports.MaxListLimit+1 {
+		t.Fatalf("unexpected args %v", args)
+	}
+	if statuses, _ := args[0].([]string); len(statuses) != 2 || statuses[1] != "failed" {
+		t.Fatalf("unexpected status arg %v", args[0])
+	}
+
+	query, args, limit, err = listQuery(ports.TxFilter{})
+	if err != nil || strings.Contains(query, "WHERE") || limit != ports.DefaultListLimit || len(args) != 1 {
+		t.Fatalf("unexpected unfiltered query %q %v %d %v", query, args, limit, err)
+	}
+	for _, c := range []string{"%%%", "e30", encodeCursor(&entity.Transaction{ID: "t1", CreatedAt: after})} {
+		if _, _, _, err := listQuery(ports.TxFilter{Cursor: c}); !errors.Is(err, ports.ErrInvalidCursor) {
+			t.Errorf("expected ErrInvalidCursor for %q, got %v", c, err)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	c, err := decodeCursor(encodeCursor(&entity.Transaction{ID: "x", CreatedAt: at}))
+	if err != nil || c.ID != "x" || !c.CreatedAt.Equal(at) {
+		t.Fatalf("unexpected cursor %+v %v", c, err)
+	}
+	if c, err := decodeCursor(""); c != nil || err != nil {
+		t.Fatalf("expected empty cursor to mean the first page, got %+v %v", c, err)
+	}
+	p := page([]*entity.Transaction{{ID: "a"}, {ID: "b"}}, 1)
+	if len(p.Transactions) != 1 || p.NextCursor == "" {
+		t.Fatalf("expected a truncated page with a cursor, got %+v", p)
+	}
+}
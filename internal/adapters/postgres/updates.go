@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// statusChange builds the history entry for an UpdateStatus of txID from prev
+// to status. ok is false when the status is unchanged and no reason is given.
+func statusChange(txID string, prev, status entity.TxStatus, meta ports.UpdateMeta, at time.Time) (change entity.StatusChange, ok bool) {
+	if prev == status && meta.Reason == "" {
+		return entity.StatusChange{}, false
+	}
+	actor := meta.Actor
+	if actor == "" {
+		actor = entity.ActorSystem
+	}
+	return entity.StatusChange{TxID: txID, From: prev, To: status, Actor: actor, Reason: meta.Reason, At: at}, true
+}
+
+// outboxMessages encodes events as unsaved outbox messages.
+func outboxMessages(events []entity.Event) ([]ports.OutboxMessage, error) {
+	msgs := make([]ports.OutboxMessage, 0, len(events))
+	for _, evt := range events {
+		payload, err := json.Marshal(evt)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s event: %w", evt.Type(), err)
+		}
+		msgs = append(msgs, ports.OutboxMessage{Topic: evt.Type(), Payload: payload})
+	}
+	return msgs, nil
+}
+
+// applyUpdates copies the column updates accepted by UpdateStatus onto tx.
+// Values of an unexpected type are ignored.
+func applyUpdates(tx *entity.Transaction, updates map[string]interface{}) {
+	for k, v := range updates {
+		switch k {
+		case "from":
+			if s, ok := v.(string); ok {
+				tx.From = s
+			}
+		case "nonce":
+			if n, ok := v.(uint64); ok {
+				tx.Nonce = n
+			}
+		case "gas":
+			if n, ok := v.(uint64); ok {
+				tx.Gas = n
+			}
+		case "chain_id":
+			if b, ok := v.(*big.Int); ok {
+				tx.ChainID = b
+			}
+		case "gas_price":
+			if b, ok := v.(*big.Int); ok {
+				tx.GasPrice = b
+			}
+		case "max_fee_per_gas":
+			if b, ok := v.(*big.Int); ok {
+				tx.MaxFeePerGas = b
+			}
+		case "max_priority_fee_per_gas":
+			if b, ok := v.(*big.Int); ok {
+				tx.MaxPriorityFeePerGas = b
+			}
+		case "raw_tx_hex":
+			if s, ok := v.(string); ok {
+				tx.RawTxHex = s
+			}
+		case "tx_hash":
+			if s, ok := v.(string); ok && s != "" {
+				tx.TxHash = s
+			}
+		case "sent_at":
+			if t, ok := v.(time.Time); ok {
+				tx.SentAt = &t
+			}
+		case "confirmed_at":
+			if t, ok := v.(time.Time); ok {
+				tx.ConfirmedAt = &t
+			}
+		case "error_message":
+			if s, ok := v.(string); ok {
+				tx.ErrorMessage = &s
+			}
+		case "receipt":
+			if rc, ok := v.(*entity.Receipt); ok {
+				tx.Receipt = rc
+			}
+		case "attempts":
+			if a, ok := v.([]entity.TxAttempt); ok {
+				tx.Attempts = a
+			}
+		}
+	}
+}
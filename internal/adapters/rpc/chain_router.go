@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownChain is matched (via errors.Is) by every UnknownChainError.
+var ErrUnknownChain = errors.New("unknown chain")
+
+// UnknownChainError is returned when a call names a chain the router has no
+// client for.
+type UnknownChainError struct {
+	Chain string
+}
+
+func (e *UnknownChainError) Error() string {
+	return fmt.Sprintf("unknown chain %q", e.Chain)
+}
+
+func (e *UnknownChainError) Is(target error) bool {
+	return target == ErrUnknownChain
+}
+
+// ChainRouter implements BlockchainPort by dispatching each call to the client
+// registered for its logical chain name. Calls with an empty chain go to the
+// default chain so single-chain callers keep working unchanged.
+type ChainRouter struct {
+	clients      map[string]ports.BlockchainPort
+	defaultChain string
+}
+
+var _ ports.BlockchainPort = (*ChainRouter)(nil)
+
+// NewChainRouter builds a router over clients, keyed by chain name. Names are
+// case-insensitive. defaultChain must be one of the keys.
+func NewChainRouter(defaultChain string, clients map[string]ports.BlockchainPort) (*ChainRouter, error) {
+	r := &ChainRouter{
+		clients:      make(map[string]ports.BlockchainPort, len(clients)),
+		defaultChain: config.NormalizeChain(defaultChain),
+	}
+	for name, c := range clients {
+		if c == nil {
+			return nil, fmt.Errorf("nil client for chain %q", name)
+		}
+		r.clients[config.NormalizeChain(name)] = c
+	}
+	if _, ok := r.clients[r.defaultChain]; !ok {
+		return nil, &UnknownChainError{Chain: defaultChain}
+	}
+	return r, nil
+}
+
+// NewChainRouterFromConfig builds one ETHRPC per configured chain.
+func NewChainRouterFromConfig(logger *zap.Logger, cfg *config.Config) (*ChainRouter, error) {
+	clients := make(map[string]ports.BlockchainPort, len(cfg.Chains))
+	for name, cc := range cfg.Chains {
+		clients[name] = NewETHRPC(logger, nil, cc)
+	}
+	return NewChainRouter(cfg.DefaultChain, clients)
+}
+
+// DefaultChain returns the chain used when a call does not name one.
+func (r *ChainRouter) DefaultChain() string {
+	return r.defaultChain
+}
+
+// Chains returns the registered chain names in sorted order.
+func (r *ChainRouter) Chains() []string {
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Client returns the client serving chain and the resolved chain name.
+func (r *ChainRouter) Client(chain string) (ports.BlockchainPort, string, error) {
+	name := config.NormalizeChain(chain)
+	if name == "" {
+		name = r.defaultChain
+	}
+	c, ok := r.clients[name]
+	if !ok {
+		return nil, "", &UnknownChainError{Chain: chain}
+	}
+	return c, name, nil
+}
+
+func (r *ChainRouter) GetBalance(ctx context.Context, chain string, address string) (*big.Int, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetBalance(ctx, name, address)
+}
+
+func (r *ChainRouter) GetNonce(ctx context.Context, chain string, address string) (uint64, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return 0, err
+	}
+	return c.GetNonce(ctx, name, address)
+}
+
+func (r *ChainRouter) GetTransactionReceipt(ctx context.Context, chain string, txHash string) (*entity.Receipt, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTransactionReceipt(ctx, name, txHash)
+}
+
+func (r *ChainRouter) GetLogs(ctx context.Context, chain string, f entity.LogFilter) ([]entity.Log, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetLogs(ctx, name, f)
+}
+
+func (r *ChainRouter) GetChainID(ctx context.Context, chain string) (*big.Int, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetChainID(ctx, name)
+}
+
+func (r *ChainRouter) GetBlockNumber(ctx context.Context, chain string) (uint64, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return 0, err
+	}
+	return c.GetBlockNumber(ctx, name)
+}
+
+func (r *ChainRouter) EstimateFees(ctx context.Context, chain string, tier entity.FeeTier) (*big.Int, *big.Int, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.EstimateFees(ctx, name, tier)
+}
+
+func (r *ChainRouter) SendRawTransaction(ctx context.Context, chain string, signedTx []byte) (string, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return "", err
+	}
+	return c.SendRawTransaction(ctx, name, signedTx)
+}
+
+func (r *ChainRouter) SendRawTransactionHex(ctx context.Context, chain string, signedTxHex string) (string, error) {
+	c, name, err := r.Client(chain)
+	if err != nil {
+		return "", err
+	}
+	return c.SendRawTransactionHex(ctx, name, signedTxHex)
+}
@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+
+	"go.uber.org/zap"
+)
+
+// chainServer answers every JSON-RPC call with result, so each chain can be
+// told apart by the value it returns.
+func chainServer(result interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func newTestRouter(t *testing.T) (*ChainRouter, func()) {
+	eth := chainServer("0x1")
+	poly := chainServer("0x89")
+	r, err := NewChainRouter("eth", map[string]ports.BlockchainPort{
+		"ETH":     NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: eth.URL}),
+		"polygon": NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: poly.URL}),
+	})
+	if err != nil {
+		t.Fatalf("NewChainRouter error: %v", err)
+	}
+	return r, func() {
+		eth.Close()
+		poly.Close()
+	}
+}
+
+func TestChainRouterDispatchesByChain(t *testing.T) {
+	r, done := newTestRouter(t)
+	defer done()
+	ctx := context.Background()
+
+	bn, err := r.GetBlockNumber(ctx, "POLYGON")
+	if err != nil || bn != 0x89 {
+		t.Fatalf("expected polygon block 0x89, got %d (%v)", bn, err)
+	}
+	bn, err = r.GetBlockNumber(ctx, " Polygon ")
+	if err != nil || bn != 0x89 {
+		t.Fatalf("expected chain names to be case-insensitive, got %d (%v)", bn, err)
+	}
+	// empty chain falls back to the default
+	bn, err = r.GetBlockNumber(ctx, "")
+	if err != nil || bn != 1 {
+		t.Fatalf("expected default chain block 1, got %d (%v)", bn, err)
+	}
+
+	if bal, err := r.GetBalance(ctx, "ETH", "0xaddr"); err != nil || bal.Int64() != 1 {
+		t.Fatalf("GetBalance: %v %v", bal, err)
+	}
+	if n, err := r.GetNonce(ctx, "POLYGON", "0xaddr"); err != nil || n != 0x89 {
+		t.Fatalf("GetNonce: %v %v", n, err)
+	}
+	if id, err := r.GetChainID(ctx, "POLYGON"); err != nil || id.Int64() != 0x89 {
+		t.Fatalf("GetChainID: %v %v", id, err)
+	}
+	if h, err := r.SendRawTransaction(ctx, "POLYGON", []byte{1}); err != nil || h != "0x89" {
+		t.Fatalf("SendRawTransaction: %v %v", h, err)
+	}
+	if h, err := r.SendRawTransactionHex(ctx, "", "0x01"); err != nil || h != "0x1" {
+		t.Fatalf("SendRawTransactionHex: %v %v", h, err)
+	}
+
+	if got := r.Chains(); len(got) != 2 || got[0] != "ETH" || got[1] != "POLYGON" {
+		t.Fatalf("unexpected chains: %v", got)
+	}
+	if r.DefaultChain() != "ETH" {
+		t.Fatalf("unexpected default chain: %s", r.DefaultChain())
+	}
+}
+
+func TestChainRouterUnknownChain(t *testing.T) {
+	r, done := newTestRouter(t)
+	defer done()
+	ctx := context.Background()
+
+	checks := map[string]error{}
+	_, checks["GetBalance"] = r.GetBalance(ctx, "SOLANA", "0x")
+	_, checks["GetNonce"] = r.GetNonce(ctx, "SOLANA", "0x")
+	_, checks["GetTransactionReceipt"] = r.GetTransactionReceipt(ctx, "SOLANA", "0x")
+	_, checks["GetLogs"] = r.GetLogs(ctx, "SOLANA", entity.LogFilter{})
+	_, checks["GetBlockNumber"] = r.GetBlockNumber(ctx, "SOLANA")
+	_, checks["GetChainID"] = r.GetChainID(ctx, "SOLANA")
+	_, _, checks["EstimateFees"] = r.EstimateFees(ctx, "SOLANA", entity.FeeTierStandard)
+	_, checks["SendRawTransaction"] = r.SendRawTransaction(ctx, "SOLANA", nil)
+	_, checks["SendRawTransactionHex"] = r.SendRawTransactionHex(ctx, "SOLANA", "0x")
+
+	for method, err := range checks {
+		if !errors.Is(err, ErrUnknownChain) {
+			t.Fatalf("%s: expected ErrUnknownChain, got %v", method, err)
+		}
+		var uce *UnknownChainError
+		if !errors.As(err, &uce) || uce.Chain != "SOLANA" {
+			t.Fatalf("%s: expected UnknownChainError for SOLANA, got %v", method, err)
+		}
+	}
+}
+
+func TestChainRouterPassesThroughOtherCalls(t *testing.T) {
+	srv := chainServer(nil)
+	defer srv.Close()
+	r, err := NewChainRouter("ETH", map[string]ports.BlockchainPort{"ETH": NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: srv.URL})})
+	if err != nil {
+		t.Fatalf("NewChainRouter error: %v", err)
+	}
+	ctx := context.Background()
+	if rec, err := r.GetTransactionReceipt(ctx, "ETH", "0xhash"); err != nil || rec != nil {
+		t.Fatalf("expected nil receipt, got %v %v", rec, err)
+	}
+	if logs, err := r.GetLogs(ctx, "ETH", entity.LogFilter{}); err != nil || len(logs) != 0 {
+		t.Fatalf("expected no logs, got %v %v", logs, err)
+	}
+	// a null fee history has no base fee, so the legacy gas price path is used
+	if tip, _, err := r.EstimateFees(ctx, "ETH", entity.FeeTierFast); err != nil || tip != nil {
+		t.Fatalf("expected legacy estimate, got tip=%v err=%v", tip, err)
+	}
+}
+
+func TestNewChainRouterValidation(t *testing.T) {
+	if _, err := NewChainRouter("ETH", map[string]ports.BlockchainPort{}); !errors.Is(err, ErrUnknownChain) {
+		t.Fatalf("expected ErrUnknownChain for missing default, got %v", err)
+	}
+	if _, err := NewChainRouter("ETH", map[string]ports.BlockchainPort{"ETH": nil}); err == nil {
+		t.Fatalf("expected error for nil client")
+	}
+}
@@ -1,54 +1,86 @@
 package rpc
 
 import (
+	"ChainConnector/internal/config"
 	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// RPCError is the error object returned by a node in a JSON-RPC response.
+type RPCError struct {
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error: %d %s", e.Code, e.Message)
+}
+
+// ETHRPC is a JSON-RPC client for a single EVM endpoint. It ignores the chain
+// argument of BlockchainPort methods; use ChainRouter to serve several chains.
 type ETHRPC struct {
 	httpClient *http.Client
 	logger     *zap.Logger
 	url        string
+	fees       config.FeeConfig
+
+	chainIDMu sync.Mutex
+	chainID   *big.Int
 }
 
-// NewETHRPC constructs an ETHRPC. The httpClient parameter is optional; if nil,
-// a default client with timeout is used.
-func NewETHRPC(logger *zap.Logger, httpClient *http.Client) *ETHRPC {
-	url := "https://ethereum-sepolia-rpc.publicnode.com"
+// NewETHRPC constructs an ETHRPC for the endpoint described by cfg. The
+// httpClient parameter is optional; if nil, a client with cfg.Timeout is used.
+func NewETHRPC(logger *zap.Logger, httpClient *http.Client, cfg config.ChainConfig) *ETHRPC {
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 10 * time.Second}
+		timeout := time.Duration(cfg.Timeout)
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	fees := cfg.Fees
+	if fees.BlockCount == 0 {
+		fees = config.DefaultFeeConfig()
 	}
 	return &ETHRPC{
-		url:        url,
+		url:        cfg.RPCURL,
 		httpClient: httpClient,
 		logger:     logger,
+		fees:       fees,
 	}
 }
 
 func (e *ETHRPC) SendRawTransaction(ctx context.Context, chain string, signedTx []byte) (string, error) {
 	hexTx := "0x" + hex.EncodeToString(signedTx)
-	return e.SendRawTransactionHex(ctx, "", hexTx)
+	return e.SendRawTransactionHex(ctx, chain, hexTx)
 }
 
 func (e *ETHRPC) SendRawTransactionHex(ctx context.Context, chain string, signedTxHex string) (string, error) {
 	var res string
 	if err := e.rpcCall(ctx, "eth_sendRawTransaction", []interface{}{signedTxHex}, &res); err != nil {
+		if isTxRejectedError(err) {
+			return "", fmt.Errorf("%w: %w", ports.ErrTxRejected, err)
+		}
 		return "", err
 	}
 	return res, nil
 }
 
-func (e *ETHRPC) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+func (e *ETHRPC) GetBalance(ctx context.Context, chain string, address string) (*big.Int, error) {
 	var res string
 	if err := e.rpcCall(ctx, "eth_getBalance", []interface{}{address, "latest"}, &res); err != nil {
 		return nil, err
@@ -56,7 +88,7 @@ func (e *ETHRPC) GetBalance(ctx context.Context, address string) (*big.Int, erro
 	return hexToBigInt(res)
 }
 
-func (e *ETHRPC) GetNonce(ctx context.Context, address string) (uint64, error) {
+func (e *ETHRPC) GetNonce(ctx context.Context, chain string, address string) (uint64, error) {
 	var res string
 	if err := e.rpcCall(ctx, "eth_getTransactionCount", []interface{}{address, "pending"}, &res); err != nil {
 		return 0, err
@@ -64,7 +96,25 @@ func (e *ETHRPC) GetNonce(ctx context.Context, address string) (uint64, error) {
 	return hexToUint64(res)
 }
 
-func (e *ETHRPC) GetBlockNumber(ctx context.Context) (uint64, error) {
+// GetChainID returns the endpoint's chain id, caching it after the first call.
+func (e *ETHRPC) GetChainID(ctx context.Context, chain string) (*big.Int, error) {
+	e.chainIDMu.Lock()
+	defer e.chainIDMu.Unlock()
+	if e.chainID == nil {
+		var res string
+		if err := e.rpcCall(ctx, "eth_chainId", []interface{}{}, &res); err != nil {
+			return nil, err
+		}
+		id, err := hexToBigInt(res)
+		if err != nil {
+			return nil, err
+		}
+		e.chainID = id
+	}
+	return new(big.Int).Set(e.chainID), nil
+}
+
+func (e *ETHRPC) GetBlockNumber(ctx context.Context, chain string) (uint64, error) {
 	var res string
 	if err := e.rpcCall(ctx, "eth_blockNumber", []interface{}{}, &res); err != nil {
 		return 0, err
@@ -72,7 +122,7 @@ func (e *ETHRPC) GetBlockNumber(ctx context.Context) (uint64, error) {
 	return hexToUint64(res)
 }
 
-func (e *ETHRPC) GetTransactionReceipt(ctx context.Context, txHash string) (*entity.Receipt, error) {
+func (e *ETHRPC) GetTransactionReceipt(ctx context.Context, chain string, txHash string) (*entity.Receipt, error) {
 	var raw map[string]interface{}
 	if err := e.rpcCall(ctx, "eth_getTransactionReceipt", []interface{}{txHash}, &raw); err != nil {
 		return nil, err
@@ -119,33 +169,7 @@ func (e *ETHRPC) GetTransactionReceipt(ctx context.Context, txHash string) (*ent
 			if !ok {
 				continue
 			}
-			var lg entity.Log
-			if addr, ok := m["address"].(string); ok {
-				lg.Address = addr
-			}
-			if topics, ok := m["topics"].([]interface{}); ok {
-				for _, t := range topics {
-					if ts, ok := t.(string); ok {
-						lg.Topics = append(lg.Topics, ts)
-					}
-				}
-			}
-			if data, ok := m["data"].(string); ok {
-				b, _ := hexToBytes(data)
-				lg.Data = b
-			}
-			if bn, ok := m["blockNumber"].(string); ok {
-				n, _ := hexToUint64(bn)
-				lg.BlockNumber = n
-			}
-			if txh, ok := m["transactionHash"].(string); ok {
-				lg.TxHash = txh
-			}
-			if li, ok := m["logIndex"].(string); ok {
-				ix, _ := hexToUint64(li)
-				lg.LogIndex = uint32(ix)
-			}
-			logs = append(logs, lg)
+			logs = append(logs, parseLog(m))
 		}
 		r.Logs = logs
 	}
@@ -188,7 +212,7 @@ func (e *ETHRPC) rpcCall(ctx context.Context, method string, params interface{},
 		return fmt.Errorf("invalid rpc response: %w; body=%s", err, string(body))
 	}
 	if envelope.Error != nil {
-		return fmt.Errorf("rpc error: %d %s", envelope.Error.Code, envelope.Error.Message)
+		return &RPCError{Code: envelope.Error.Code, Message: envelope.Error.Message}
 	}
 	if result == nil {
 		return nil
@@ -235,6 +259,10 @@ func hexToUint64(hexs string) (uint64, error) {
 	return v.Uint64(), nil
 }
 
+func uint64ToHex(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
 func hexToBytes(hexs string) ([]byte, error) {
 	s := strings.TrimPrefix(hexs, "0x")
 	if s == "" {
@@ -246,12 +274,182 @@ func hexToBytes(hexs string) ([]byte, error) {
 	return hex.DecodeString(s)
 }
 
-// GetLogs is not implemented for this adapter yet.
-func (e *ETHRPC) GetLogs(ctx context.Context, f entity.LogFilter) ([]entity.Log, error) {
-	return nil, fmt.Errorf("unsupported operation: GetLogs not implemented")
+// GetLogs returns the logs matching f using eth_getLogs. When the node refuses
+// the query because the block range or the result set is too large, the range
+// is split in half and each half is fetched separately.
+func (e *ETHRPC) GetLogs(ctx context.Context, chain string, f entity.LogFilter) ([]entity.Log, error) {
+	var raw []map[string]interface{}
+	err := e.rpcCall(ctx, "eth_getLogs", []interface{}{encodeLogFilter(f)}, &raw)
+	if err == nil {
+		logs := make([]entity.Log, 0, len(raw))
+		for _, m := range raw {
+			logs = append(logs, parseLog(m))
+		}
+		return logs, nil
+	}
+	if !isLogRangeError(err) || f.FromBlock == nil {
+		return nil, err
+	}
+
+	from := *f.FromBlock
+	var to uint64
+	if f.ToBlock != nil {
+		to = *f.ToBlock
+	} else {
+		latest, berr := e.GetBlockNumber(ctx, chain)
+		if berr != nil {
+			return nil, berr
+		}
+		to = latest
+	}
+	if to <= from {
+		// a single block cannot be split any further
+		return nil, err
+	}
+
+	mid := from + (to-from)/2
+	next := mid + 1
+	left, right := f, f
+	left.FromBlock, left.ToBlock = &from, &mid
+	right.FromBlock, right.ToBlock = &next, &to
+
+	if e.logger != nil {
+		e.logger.Debug("splitting eth_getLogs range",
+			zap.Uint64("from", from), zap.Uint64("to", to), zap.Error(err))
+	}
+
+	first, err := e.GetLogs(ctx, chain, left)
+	if err != nil {
+		return nil, err
+	}
+	second, err := e.GetLogs(ctx, chain, right)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// encodeLogFilter converts a LogFilter into the eth_getLogs filter object.
+func encodeLogFilter(f entity.LogFilter) map[string]interface{} {
+	q := map[string]interface{}{}
+	if f.FromBlock != nil {
+		q["fromBlock"] = uint64ToHex(*f.FromBlock)
+	}
+	if f.ToBlock != nil {
+		q["toBlock"] = uint64ToHex(*f.ToBlock)
+	}
+	switch len(f.Addresses) {
+	case 0:
+	case 1:
+		q["address"] = f.Addresses[0]
+	default:
+		q["address"] = f.Addresses
+	}
+	if len(f.Topics) > 0 {
+		// positions are ANDed; alternatives within a position are ORed and an
+		// empty position is a wildcard (null).
+		topics := make([]interface{}, len(f.Topics))
+		for i, alts := range f.Topics {
+			switch len(alts) {
+			case 0:
+				topics[i] = nil
+			case 1:
+				topics[i] = alts[0]
+			default:
+				topics[i] = alts
+			}
+		}
+		q["topics"] = topics
+	}
+	return q
+}
+
+// logRangeErrors are fragments of the messages nodes return when an
+// eth_getLogs query spans too many blocks or matches too many logs.
+var logRangeErrors = []string{
+	"too many results",
+	"range too large",
+	"returned more than",
+	"block range",
+	"limit exceeded",
+	"response size",
 }
 
-// EstimateFees is not implemented by this simple RPC adapter and returns an error.
-func (e *ETHRPC) EstimateFees(ctx context.Context, chain string) (*big.Int, *big.Int, error) {
-	return nil, nil, fmt.Errorf("unsupported operation: EstimateFees not implemented")
+func isLogRangeError(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, frag := range logRangeErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+// txRejectedErrors are fragments of the messages nodes return when
+// eth_sendRawTransaction refuses a transaction outright. Anything else, like
+// "already known" or an internal error, does not prove the transaction is
+// absent from the pool.
+var txRejectedErrors = []string{
+	"nonce too low",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"transaction underpriced",
+	"less than block base fee",
+	"tip higher than fee cap",
+	"priority fee per gas higher than max fee per gas",
+	"invalid sender",
+	"invalid chain id",
+	"exceeds the configured cap",
+	"oversized data",
+	"transaction type not supported",
+	"only replay-protected",
+}
+
+func isTxRejectedError(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, frag := range txRejectedErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLog(m map[string]interface{}) entity.Log {
+	var lg entity.Log
+	if addr, ok := m["address"].(string); ok {
+		lg.Address = addr
+	}
+	if topics, ok := m["topics"].([]interface{}); ok {
+		for _, t := range topics {
+			if ts, ok := t.(string); ok {
+				lg.Topics = append(lg.Topics, ts)
+			}
+		}
+	}
+	if data, ok := m["data"].(string); ok {
+		b, _ := hexToBytes(data)
+		lg.Data = b
+	}
+	if bn, ok := m["blockNumber"].(string); ok {
+		n, _ := hexToUint64(bn)
+		lg.BlockNumber = n
+	}
+	if txh, ok := m["transactionHash"].(string); ok {
+		lg.TxHash = txh
+	}
+	if li, ok := m["logIndex"].(string); ok {
+		ix, _ := hexToUint64(li)
+		lg.LogIndex = uint32(ix)
+	}
+	return lg
 }
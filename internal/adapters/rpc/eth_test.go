@@ -3,12 +3,15 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"ChainConnector/internal/config"
 	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
 
 	"go.uber.org/zap"
 )
@@ -44,6 +47,7 @@ func TestHexParsers(t *testing.T) {
 }
 
 func TestRPCMethodsAgainstTestServer(t *testing.T) {
+	chainIDCalls := 0
 	// Test server that returns appropriate json-rpc envelopes
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req map[string]interface{}
@@ -58,6 +62,9 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": "0x01"})
 		case "eth_blockNumber":
 			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": "0x02"})
+		case "eth_chainId":
+			chainIDCalls++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": "0xaa36a7"})
 		case "eth_getTransactionReceipt":
 			res := map[string]interface{}{
 				"blockNumber":       "0x2",
@@ -76,7 +83,7 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	eth := NewETHRPC(zap.NewNop(), nil)
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
 	// override URL to point to our test server
 	eth.url = srv.URL
 
@@ -90,7 +97,7 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 	}
 
 	// GetBalance
-	bal, err := eth.GetBalance(context.Background(), "0xaddr")
+	bal, err := eth.GetBalance(context.Background(), "", "0xaddr")
 	if err != nil {
 		t.Fatalf("GetBalance error: %v", err)
 	}
@@ -99,7 +106,7 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 	}
 
 	// GetNonce
-	nonce, err := eth.GetNonce(context.Background(), "0xaddr")
+	nonce, err := eth.GetNonce(context.Background(), "", "0xaddr")
 	if err != nil {
 		t.Fatalf("GetNonce error: %v", err)
 	}
@@ -108,7 +115,7 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 	}
 
 	// GetBlockNumber
-	bn, err := eth.GetBlockNumber(context.Background())
+	bn, err := eth.GetBlockNumber(context.Background(), "")
 	if err != nil {
 		t.Fatalf("GetBlockNumber error: %v", err)
 	}
@@ -117,13 +124,27 @@ func TestRPCMethodsAgainstTestServer(t *testing.T) {
 	}
 
 	// GetTransactionReceipt
-	rec, err := eth.GetTransactionReceipt(context.Background(), "0xhash")
+	rec, err := eth.GetTransactionReceipt(context.Background(), "", "0xhash")
 	if err != nil {
 		t.Fatalf("GetTransactionReceipt error: %v", err)
 	}
 	if rec == nil || rec.Status != entity.ReceiptStatusSuccess {
 		t.Fatalf("unexpected receipt: %+v", rec)
 	}
+
+	// GetChainID is cached after the first call
+	for i := 0; i < 2; i++ {
+		id, err := eth.GetChainID(context.Background(), "")
+		if err != nil {
+			t.Fatalf("GetChainID error: %v", err)
+		}
+		if id.Int64() != 11155111 {
+			t.Fatalf("expected sepolia chain id, got %s", id)
+		}
+	}
+	if chainIDCalls != 1 {
+		t.Fatalf("expected chain id to be cached, got %d calls", chainIDCalls)
+	}
 }
 
 func TestRPCErrorEnvelopeAndInvalidJSON(t *testing.T) {
@@ -133,7 +154,7 @@ func TestRPCErrorEnvelopeAndInvalidJSON(t *testing.T) {
 	}))
 	defer srvErr.Close()
 
-	eth := NewETHRPC(zap.NewNop(), nil)
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
 	eth.url = srvErr.URL
 	if _, err := eth.SendRawTransactionHex(context.Background(), "", "0x01"); err == nil {
 		t.Fatalf("expected error from SendRawTransactionHex when server returns error envelope")
@@ -147,9 +168,12 @@ func TestRPCErrorEnvelopeAndInvalidJSON(t *testing.T) {
 	}))
 	defer srvBad.Close()
 	eth.url = srvBad.URL
-	if _, err := eth.GetBalance(context.Background(), "0xaddr"); err == nil {
+	if _, err := eth.GetBalance(context.Background(), "", "0xaddr"); err == nil {
 		t.Fatalf("expected error from GetBalance when server returns invalid JSON")
 	}
+	if _, err := eth.GetChainID(context.Background(), ""); err == nil {
+		t.Fatalf("expected error from GetChainID when server returns invalid JSON")
+	}
 }
 
 func TestReceiptParsingWithLogsAndNullReceipt(t *testing.T) {
@@ -182,9 +206,9 @@ func TestReceiptParsingWithLogsAndNullReceipt(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	eth := NewETHRPC(zap.NewNop(), nil)
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
 	eth.url = srv.URL
-	rec, err := eth.GetTransactionReceipt(context.Background(), "0xhash")
+	rec, err := eth.GetTransactionReceipt(context.Background(), "", "0xhash")
 	if err != nil {
 		t.Fatalf("GetTransactionReceipt error: %v", err)
 	}
@@ -206,7 +230,7 @@ func TestReceiptParsingWithLogsAndNullReceipt(t *testing.T) {
 	}))
 	defer srvNull.Close()
 	eth.url = srvNull.URL
-	rec2, err := eth.GetTransactionReceipt(context.Background(), "0xhash")
+	rec2, err := eth.GetTransactionReceipt(context.Background(), "", "0xhash")
 	if err != nil {
 		t.Fatalf("GetTransactionReceipt error on null: %v", err)
 	}
@@ -227,7 +251,7 @@ func TestSendRawTransactionWrapper(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	eth := NewETHRPC(zap.NewNop(), nil)
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
 	eth.url = srv.URL
 
 	// call wrapper that accepts bytes
@@ -240,14 +264,209 @@ func TestSendRawTransactionWrapper(t *testing.T) {
 	}
 }
 
-func TestGetLogsAndEstimateFeesUnsupported(t *testing.T) {
-	eth := NewETHRPC(zap.NewNop(), nil)
-	_, err := eth.GetLogs(context.Background(), entity.LogFilter{})
-	if err == nil {
-		t.Fatalf("expected error from GetLogs")
+func TestSendRawTransactionRejected(t *testing.T) {
+	message := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1,
+			"error": map[string]interface{}{"code": -32000, "message": message}})
+	}))
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
+	eth.url = srv.URL
+	for msg, rejected := range map[string]bool{
+		"nonce too low: next nonce 5, tx nonce 4":    true,
+		"insufficient funds for gas * price + value": true,
+		"replacement transaction underpriced":        true,
+		"already known":                              false,
+		"internal error":                             false,
+	} {
+		message = msg
+		_, err := eth.SendRawTransactionHex(context.Background(), "", "0x01")
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) || errors.Is(err, ports.ErrTxRejected) != rejected {
+			t.Errorf("%q: expected rejected=%v, got %v", msg, rejected, err)
+		}
+	}
+
+	// a transport failure is not a rejection
+	srv.Close()
+	if _, err := eth.SendRawTransactionHex(context.Background(), "", "0x01"); err == nil || errors.Is(err, ports.ErrTxRejected) {
+		t.Fatalf("expected an unclassified error, got %v", err)
+	}
+}
+
+func TestEncodeLogFilter(t *testing.T) {
+	from, to := uint64(16), uint64(255)
+	q := encodeLogFilter(entity.LogFilter{
+		FromBlock: &from,
+		ToBlock:   &to,
+		Addresses: []string{"0xa", "0xb"},
+		Topics:    [][]string{{"0x01"}, nil, {"0x02", "0x03"}},
+	})
+	if q["fromBlock"] != "0x10" || q["toBlock"] != "0xff" {
+		t.Fatalf("unexpected block range: %v %v", q["fromBlock"], q["toBlock"])
+	}
+	if addrs, ok := q["address"].([]string); !ok || len(addrs) != 2 {
+		t.Fatalf("expected address list, got %v", q["address"])
+	}
+	topics := q["topics"].([]interface{})
+	if topics[0] != "0x01" || topics[1] != nil {
+		t.Fatalf("unexpected topics: %v", topics)
+	}
+	if alts, ok := topics[2].([]string); !ok || len(alts) != 2 {
+		t.Fatalf("expected OR topics at position 2, got %v", topics[2])
+	}
+
+	// a single address is sent as a plain string and empty fields are omitted
+	q = encodeLogFilter(entity.LogFilter{Addresses: []string{"0xa"}})
+	if q["address"] != "0xa" {
+		t.Fatalf("expected single address string, got %v", q["address"])
+	}
+	if _, ok := q["fromBlock"]; ok {
+		t.Fatalf("expected fromBlock to be omitted")
+	}
+	if _, ok := q["topics"]; ok {
+		t.Fatalf("expected topics to be omitted")
+	}
+}
+
+func TestGetLogsParsesResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["method"].(string) != "eth_getLogs" {
+			t.Fatalf("unexpected method %v", req["method"])
+		}
+		res := []interface{}{
+			map[string]interface{}{
+				"address":         "0xcontract",
+				"topics":          []interface{}{"0xddf2"},
+				"data":            "0x0102",
+				"blockNumber":     "0x10",
+				"transactionHash": "0xhash",
+				"logIndex":        "0x3",
+			},
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": res})
+	}))
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
+	eth.url = srv.URL
+	logs, err := eth.GetLogs(context.Background(), "", entity.LogFilter{Addresses: []string{"0xcontract"}})
+	if err != nil {
+		t.Fatalf("GetLogs error: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(logs))
+	}
+	lg := logs[0]
+	if lg.Address != "0xcontract" || lg.BlockNumber != 16 || lg.LogIndex != 3 || len(lg.Data) != 2 || lg.TxHash != "0xhash" {
+		t.Fatalf("unexpected log: %+v", lg)
+	}
+}
+
+// logRangeServer serves eth_getLogs but refuses any range wider than maxRange
+// blocks, returning one log per block otherwise. A missing toBlock means latest.
+func logRangeServer(t *testing.T, maxRange uint64, latest string, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                   `json:"method"`
+			Params []map[string]interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "eth_blockNumber" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": latest})
+			return
+		}
+		*calls++
+		from, _ := hexToUint64(req.Params[0]["fromBlock"].(string))
+		toHex, ok := req.Params[0]["toBlock"].(string)
+		if !ok {
+			toHex = latest
+		}
+		to, _ := hexToUint64(toHex)
+		if to-from+1 > maxRange {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1,
+				"error": map[string]interface{}{"code": -32005, "message": "query returned more than 10000 results"}})
+			return
+		}
+		res := []interface{}{}
+		for b := from; b <= to; b++ {
+			res = append(res, map[string]interface{}{"blockNumber": uint64ToHex(b)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": res})
+	}))
+}
+
+func TestGetLogsSplitsLargeRanges(t *testing.T) {
+	calls := 0
+	srv := logRangeServer(t, 4, "0x0", &calls)
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
+	eth.url = srv.URL
+	from, to := uint64(1), uint64(10)
+	logs, err := eth.GetLogs(context.Background(), "", entity.LogFilter{FromBlock: &from, ToBlock: &to})
+	if err != nil {
+		t.Fatalf("GetLogs error: %v", err)
 	}
-	_, _, err2 := eth.EstimateFees(context.Background(), "")
-	if err2 == nil {
-		t.Fatalf("expected error from EstimateFees")
+	if len(logs) != 10 {
+		t.Fatalf("expected 10 logs, got %d", len(logs))
+	}
+	for i, lg := range logs {
+		if lg.BlockNumber != uint64(i+1) {
+			t.Fatalf("logs out of order: %d at %d", lg.BlockNumber, i)
+		}
+	}
+	if calls < 3 {
+		t.Fatalf("expected range to be split, got %d calls", calls)
+	}
+}
+
+func TestGetLogsSplitResolvesLatestBlock(t *testing.T) {
+	calls := 0
+	srv := logRangeServer(t, 3, "0x8", &calls)
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
+	eth.url = srv.URL
+	from := uint64(5)
+	// without an explicit toBlock the node answers up to "latest", which is
+	// resolved to block 8 when splitting; blocks 5..8 exceed the limit of 3.
+	logs, err := eth.GetLogs(context.Background(), "", entity.LogFilter{FromBlock: &from})
+	if err != nil {
+		t.Fatalf("GetLogs error: %v", err)
+	}
+	if len(logs) != 4 {
+		t.Fatalf("expected 4 logs, got %d", len(logs))
+	}
+}
+
+func TestGetLogsDoesNotSplitOtherErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1,
+			"error": map[string]interface{}{"code": -32602, "message": "invalid params"}})
+	}))
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{})
+	eth.url = srv.URL
+	from, to := uint64(1), uint64(10)
+	_, err := eth.GetLogs(context.Background(), "", entity.LogFilter{FromBlock: &from, ToBlock: &to})
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Fatalf("expected RPCError to be returned unchanged, got %v", err)
+	}
+
+	// a single-block range that is still too large surfaces the node error
+	calls := 0
+	srv2 := logRangeServer(t, 0, "0x0", &calls)
+	defer srv2.Close()
+	eth.url = srv2.URL
+	one := uint64(7)
+	if _, err := eth.GetLogs(context.Background(), "", entity.LogFilter{FromBlock: &one, ToBlock: &one}); err == nil {
+		t.Fatalf("expected error for unsplittable range")
 	}
 }
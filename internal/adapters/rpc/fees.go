@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// FeeEstimate is the suggested pricing for one tier. On chains without
+// EIP-1559 only GasPrice is set.
+type FeeEstimate struct {
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	GasPrice             *big.Int
+}
+
+// Legacy reports whether the estimate is a pre-London gas price.
+func (f FeeEstimate) Legacy() bool {
+	return f.MaxFeePerGas == nil
+}
+
+// FeeTiers holds the slow, standard and fast estimates, in that order.
+type FeeTiers [3]FeeEstimate
+
+// Tier returns the estimate for t; the zero tier is the standard one.
+func (ft FeeTiers) Tier(t entity.FeeTier) FeeEstimate {
+	switch t {
+	case entity.FeeTierSlow:
+		return ft[0]
+	case entity.FeeTierFast:
+		return ft[2]
+	}
+	return ft[1]
+}
+
+// EstimateFees returns the priority fee (tip) and max fee (fee cap) of tier.
+// On pre-London chains the tip is nil and the fee cap is the legacy gas price.
+func (e *ETHRPC) EstimateFees(ctx context.Context, chain string, tier entity.FeeTier) (*big.Int, *big.Int, error) {
+	tiers, err := e.EstimateFeeTiers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	fees := tiers.Tier(tier)
+	if fees.Legacy() {
+		return nil, fees.GasPrice, nil
+	}
+	return fees.MaxPriorityFeePerGas, fees.MaxFeePerGas, nil
+}
+
+// EstimateFeeTiers estimates slow, standard and fast fees from eth_feeHistory
+// using the latest base fee and the configured reward percentiles. Tips are
+// taken from eth_maxPriorityFeePerGas when the history has no rewards, and
+// chains without a base fee fall back to eth_gasPrice.
+func (e *ETHRPC) EstimateFeeTiers(ctx context.Context) (FeeTiers, error) {
+	cfg := e.fees
+	var hist struct {
+		BaseFeePerGas []string   `json:"baseFeePerGas"`
+		Reward        [][]string `json:"reward"`
+	}
+	params := []interface{}{uint64ToHex(cfg.BlockCount), "latest", cfg.Percentiles[:]}
+	if err := e.rpcCall(ctx, "eth_feeHistory", params, &hist); err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			return FeeTiers{}, err
+		}
+		return e.legacyFeeTiers(ctx)
+	}
+
+	baseFee := big.NewInt(0)
+	if n := len(hist.BaseFeePerGas); n > 0 {
+		// the last entry is the base fee of the next (pending) block
+		bf, err := hexToBigInt(hist.BaseFeePerGas[n-1])
+		if err != nil {
+			return FeeTiers{}, fmt.Errorf("invalid base fee: %w", err)
+		}
+		baseFee = bf
+	}
+	if baseFee.Sign() == 0 {
+		return e.legacyFeeTiers(ctx)
+	}
+
+	var suggested *big.Int
+	feeCapBase := new(big.Int).Mul(baseFee, big.NewInt(cfg.BaseFeeMultiplier))
+	var tiers FeeTiers
+	for i := range tiers {
+		tip, err := medianReward(hist.Reward, i)
+		if err != nil {
+			return FeeTiers{}, err
+		}
+		if tip.Sign() == 0 {
+			if suggested == nil {
+				if suggested, err = e.maxPriorityFeePerGas(ctx); err != nil {
+					return FeeTiers{}, err
+				}
+			}
+			tip = new(big.Int).Set(suggested)
+		}
+		tiers[i] = FeeEstimate{
+			MaxPriorityFeePerGas: tip,
+			MaxFeePerGas:         new(big.Int).Add(feeCapBase, tip),
+		}
+	}
+	return tiers, nil
+}
+
+func (e *ETHRPC) legacyFeeTiers(ctx context.Context) (FeeTiers, error) {
+	var res string
+	if err := e.rpcCall(ctx, "eth_gasPrice", []interface{}{}, &res); err != nil {
+		return FeeTiers{}, err
+	}
+	gp, err := hexToBigInt(res)
+	if err != nil {
+		return FeeTiers{}, err
+	}
+	var tiers FeeTiers
+	for i := range tiers {
+		tiers[i] = FeeEstimate{GasPrice: new(big.Int).Set(gp)}
+	}
+	return tiers, nil
+}
+
+func (e *ETHRPC) maxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	var res string
+	if err := e.rpcCall(ctx, "eth_maxPriorityFeePerGas", []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	return hexToBigInt(res)
+}
+
+// medianReward returns the median of the idx-th percentile reward across the
+// sampled blocks, or zero when no block reported rewards.
+func medianReward(rewards [][]string, idx int) (*big.Int, error) {
+	vals := make([]*big.Int, 0, len(rewards))
+	for _, r := range rewards {
+		if idx >= len(r) {
+			continue
+		}
+		v, err := hexToBigInt(r[idx])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reward: %w", err)
+		}
+		vals = append(vals, v)
+	}
+	if len(vals) == 0 {
+		return big.NewInt(0), nil
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i].Cmp(vals[j]) < 0 })
+	return vals[len(vals)/2], nil
+}
@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+
+	"go.uber.org/zap"
+)
+
+// feeServer answers fee related RPC calls from a method → result table; a
+// missing entry is answered with a "method not found" error envelope.
+func feeServer(t *testing.T, results map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		res, ok := results[req["method"].(string)]
+		if !ok {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1,
+				"error": map[string]interface{}{"code": -32601, "message": "the method does not exist"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": res})
+	}))
+}
+
+func TestEstimateFeeTiersFromHistory(t *testing.T) {
+	srv := feeServer(t, map[string]interface{}{
+		"eth_feeHistory": map[string]interface{}{
+			"oldestBlock":   "0x10",
+			"baseFeePerGas": []string{"0x1", "0x2", "0x64"}, // next block base fee = 100
+			"reward": [][]string{
+				{"0x1", "0x5", "0xa"},
+				{"0x3", "0x7", "0x14"},
+			},
+		},
+	})
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: srv.URL})
+	tiers, err := eth.EstimateFeeTiers(context.Background())
+	if err != nil {
+		t.Fatalf("EstimateFeeTiers error: %v", err)
+	}
+	// median of two samples picks the upper one
+	wantTips := []int64{3, 7, 20}
+	for i, want := range wantTips {
+		fe := tiers[i]
+		if fe.Legacy() {
+			t.Fatalf("tier %d unexpectedly legacy", i)
+		}
+		if fe.MaxPriorityFeePerGas.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("tier %d: expected tip %d, got %s", i, want, fe.MaxPriorityFeePerGas)
+		}
+		if fe.MaxFeePerGas.Cmp(big.NewInt(200+want)) != 0 {
+			t.Fatalf("tier %d: expected max fee %d, got %s", i, 200+want, fe.MaxFeePerGas)
+		}
+	}
+
+	// the zero tier is the standard one
+	for tier, want := range map[entity.FeeTier]int64{"": 7, entity.FeeTierSlow: 3, entity.FeeTierFast: 20} {
+		tip, maxFee, err := eth.EstimateFees(context.Background(), "ETH", tier)
+		if err != nil {
+			t.Fatalf("EstimateFees error: %v", err)
+		}
+		if tip.Cmp(big.NewInt(want)) != 0 || maxFee.Cmp(big.NewInt(200+want)) != 0 {
+			t.Fatalf("unexpected %q fees: tip=%s max=%s", tier, tip, maxFee)
+		}
+	}
+}
+
+func TestEstimateFeeTiersUsesNodeTipWithoutRewards(t *testing.T) {
+	srv := feeServer(t, map[string]interface{}{
+		"eth_feeHistory": map[string]interface{}{
+			"baseFeePerGas": []string{"0xa", "0xa"},
+		},
+		"eth_maxPriorityFeePerGas": "0x2",
+	})
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{
+		RPCURL: srv.URL,
+		Fees:   config.FeeConfig{BlockCount: 1, Percentiles: [3]float64{5, 50, 95}, BaseFeeMultiplier: 3},
+	})
+	tiers, err := eth.EstimateFeeTiers(context.Background())
+	if err != nil {
+		t.Fatalf("EstimateFeeTiers error: %v", err)
+	}
+	fast := tiers.Tier(entity.FeeTierFast)
+	if fast.MaxPriorityFeePerGas.Cmp(big.NewInt(2)) != 0 || fast.MaxFeePerGas.Cmp(big.NewInt(32)) != 0 {
+		t.Fatalf("unexpected fast tier: %+v", fast)
+	}
+}
+
+func TestEstimateFeesFallsBackToGasPrice(t *testing.T) {
+	// node without eth_feeHistory
+	srv := feeServer(t, map[string]interface{}{"eth_gasPrice": "0x3b9aca00"})
+	defer srv.Close()
+
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: srv.URL})
+	tip, maxFee, err := eth.EstimateFees(context.Background(), "", entity.FeeTierStandard)
+	if err != nil {
+		t.Fatalf("EstimateFees error: %v", err)
+	}
+	if tip != nil {
+		t.Fatalf("expected nil tip on legacy chain, got %s", tip)
+	}
+	if maxFee.Cmp(big.NewInt(1_000_000_000)) != 0 {
+		t.Fatalf("expected gas price 1 gwei, got %s", maxFee)
+	}
+
+	// node with eth_feeHistory but no base fee (pre-London blocks)
+	srv2 := feeServer(t, map[string]interface{}{
+		"eth_feeHistory": map[string]interface{}{"baseFeePerGas": []string{"0x0", "0x0"}},
+		"eth_gasPrice":   "0x5",
+	})
+	defer srv2.Close()
+	eth.url = srv2.URL
+	tiers, err := eth.EstimateFeeTiers(context.Background())
+	if err != nil {
+		t.Fatalf("EstimateFeeTiers error: %v", err)
+	}
+	if slow := tiers.Tier(entity.FeeTierSlow); !slow.Legacy() || slow.GasPrice.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("unexpected legacy tier: %+v", slow)
+	}
+}
+
+func TestEstimateFeesErrors(t *testing.T) {
+	// neither fee history nor gas price available
+	srv := feeServer(t, map[string]interface{}{})
+	defer srv.Close()
+	eth := NewETHRPC(zap.NewNop(), nil, config.ChainConfig{RPCURL: srv.URL})
+	if _, _, err := eth.EstimateFees(context.Background(), "", entity.FeeTierStandard); err == nil {
+		t.Fatalf("expected error when no fee method is available")
+	}
+
+	// rewards are zero and the node has no tip suggestion
+	srv2 := feeServer(t, map[string]interface{}{
+		"eth_feeHistory": map[string]interface{}{
+			"baseFeePerGas": []string{"0x1"},
+			"reward":        [][]string{{"0x0", "0x0", "0x0"}},
+		},
+	})
+	defer srv2.Close()
+	eth.url = srv2.URL
+	if _, err := eth.EstimateFeeTiers(context.Background()); err == nil {
+		t.Fatalf("expected error when tip cannot be determined")
+	}
+
+	// transport errors are not mistaken for a pre-London chain
+	srv3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srv3.Close()
+	eth.url = srv3.URL
+	if _, err := eth.EstimateFeeTiers(context.Background()); err == nil {
+		t.Fatalf("expected error on invalid response")
+	}
+}
+
+func TestMedianReward(t *testing.T) {
+	v, err := medianReward([][]string{{"0x9"}, {"0x1"}, {"0x5"}}, 0)
+	if err != nil || v.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("expected median 5, got %v (%v)", v, err)
+	}
+	if v, _ := medianReward(nil, 1); v.Sign() != 0 {
+		t.Fatalf("expected zero without rewards, got %s", v)
+	}
+	if _, err := medianReward([][]string{{"0xzz"}}, 0); err == nil {
+		t.Fatalf("expected error for invalid hex")
+	}
+}
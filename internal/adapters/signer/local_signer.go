@@ -0,0 +1,221 @@
+package signer
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// EIP-2718 transaction type bytes.
+const (
+	txTypeLegacy     byte = 0x00
+	txTypeAccessList byte = 0x01
+	txTypeDynamicFee byte = 0x02
+)
+
+// LocalSigner signs transactions with a secp256k1 private key held in memory.
+type LocalSigner struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+var _ ports.WalletSignerPort = (*LocalSigner)(nil)
+
+// NewLocalSigner loads a hex-encoded (optionally 0x-prefixed) 32-byte private key.
+func NewLocalSigner(hexKey string) (*LocalSigner, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid private key length %d", len(b))
+	}
+	key := secp256k1.PrivKeyFromBytes(b)
+	if key.Key.IsZero() {
+		return nil, errors.New("invalid private key: zero scalar")
+	}
+	pub := key.PubKey().SerializeUncompressed()
+	return &LocalSigner{
+		key:     key,
+		address: entity.ChecksumAddress(keccak256(pub[1:])[12:]),
+	}, nil
+}
+
+// Address returns the EIP-55 checksummed address of the key.
+func (s *LocalSigner) Address(ctx context.Context) (string, error) {
+	return s.address, nil
+}
+
+// SignHash signs a 32-byte hash and returns the 65-byte [R || S || V]
+// signature with V in {0, 1}.
+func (s *LocalSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	compact := ecdsa.SignCompact(s.key, hash, false)
+	// compact is [27 + recovery id || R || S]
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0] - 27
+	return sig, nil
+}
+
+// SignTransaction signs tx and returns the raw encoded transaction and its
+// hash. A DynamicFee (EIP-1559) transaction is produced when MaxFeePerGas is
+// set, an access-list (EIP-2930) transaction when only an access list is
+// given, and a legacy EIP-155 transaction otherwise.
+func (s *LocalSigner) SignTransaction(ctx context.Context, tx *entity.Transaction) ([]byte, string, error) {
+	if tx == nil {
+		return nil, "", errors.New("transaction is nil")
+	}
+	if tx.From != "" && !strings.EqualFold(tx.From, s.address) {
+		return nil, "", fmt.Errorf("signer %s cannot sign for %s", s.address, tx.From)
+	}
+	typ := txTypeFor(tx)
+	fields, err := txFields(tx, typ)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var unsigned []byte
+	if typ == txTypeLegacy {
+		// EIP-155: chainId, 0, 0 replace v, r, s in the signing payload
+		unsigned = rlpList(append(fields, rlpBig(tx.ChainID), rlpUint(0), rlpUint(0))...)
+	} else {
+		unsigned = append([]byte{typ}, rlpList(fields...)...)
+	}
+
+	sig, err := s.SignHash(ctx, keccak256(unsigned))
+	if err != nil {
+		return nil, "", err
+	}
+	raw := encodeSigned(typ, fields, tx.ChainID, sig)
+	return raw, "0x" + hex.EncodeToString(keccak256(raw)), nil
+}
+
+func txTypeFor(tx *entity.Transaction) byte {
+	switch {
+	case tx.MaxFeePerGas != nil:
+		return txTypeDynamicFee
+	case len(tx.AccessList) > 0:
+		return txTypeAccessList
+	default:
+		return txTypeLegacy
+	}
+}
+
+// txFields returns the RLP encoded payload fields of tx (without signature)
+// in the order defined for typ.
+func txFields(tx *entity.Transaction, typ byte) ([][]byte, error) {
+	if tx.ChainID == nil || tx.ChainID.Sign() <= 0 {
+		return nil, errors.New("chain id is required")
+	}
+	to := []byte{}
+	if tx.To != nil && *tx.To != "" {
+		b, err := decodeAddress(*tx.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to address: %w", err)
+		}
+		to = b
+	}
+
+	switch typ {
+	case txTypeLegacy:
+		if tx.GasPrice == nil {
+			return nil, errors.New("gas price is required for legacy transactions")
+		}
+		return [][]byte{
+			rlpUint(tx.Nonce), rlpBig(tx.GasPrice), rlpUint(tx.Gas),
+			rlpBytes(to), rlpBig(tx.Value), rlpBytes(tx.Data),
+		}, nil
+	case txTypeAccessList:
+		if tx.GasPrice == nil {
+			return nil, errors.New("gas price is required for access list transactions")
+		}
+		al, err := encodeAccessList(tx.AccessList)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{
+			rlpBig(tx.ChainID), rlpUint(tx.Nonce), rlpBig(tx.GasPrice), rlpUint(tx.Gas),
+			rlpBytes(to), rlpBig(tx.Value), rlpBytes(tx.Data), al,
+		}, nil
+	default:
+		if tx.MaxPriorityFeePerGas == nil {
+			return nil, errors.New("max priority fee is required for dynamic fee transactions")
+		}
+		if tx.MaxPriorityFeePerGas.Cmp(tx.MaxFeePerGas) > 0 {
+			return nil, fmt.Errorf("max priority fee %s exceeds max fee %s", tx.MaxPriorityFeePerGas, tx.MaxFeePerGas)
+		}
+		al, err := encodeAccessList(tx.AccessList)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{
+			rlpBig(tx.ChainID), rlpUint(tx.Nonce), rlpBig(tx.MaxPriorityFeePerGas), rlpBig(tx.MaxFeePerGas),
+			rlpUint(tx.Gas), rlpBytes(to), rlpBig(tx.Value), rlpBytes(tx.Data), al,
+		}, nil
+	}
+}
+
+// encodeSigned appends the [R || S || V] signature to fields.
+func encodeSigned(typ byte, fields [][]byte, chainID *big.Int, sig []byte) []byte {
+	r := new(big.Int).SetBytes(sig[:32])
+	sv := new(big.Int).SetBytes(sig[32:64])
+	recID := uint64(sig[64])
+
+	if typ == txTypeLegacy {
+		// v = recovery id + chainId * 2 + 35
+		v := new(big.Int).Mul(chainID, big.NewInt(2))
+		v.Add(v, new(big.Int).SetUint64(recID+35))
+		return rlpList(append(fields, rlpBig(v), rlpBig(r), rlpBig(sv))...)
+	}
+	payload := rlpList(append(fields, rlpUint(recID), rlpBig(r), rlpBig(sv))...)
+	return append([]byte{typ}, payload...)
+}
+
+func encodeAccessList(al []entity.AccessTuple) ([]byte, error) {
+	tuples := make([][]byte, 0, len(al))
+	for _, t := range al {
+		addr, err := decodeAddress(t.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access list address: %w", err)
+		}
+		keys := make([][]byte, 0, len(t.StorageKeys))
+		for _, k := range t.StorageKeys {
+			b, err := hex.DecodeString(strings.TrimPrefix(k, "0x"))
+			if err != nil || len(b) != 32 {
+				return nil, fmt.Errorf("invalid storage key %q", k)
+			}
+			keys = append(keys, rlpBytes(b))
+		}
+		tuples = append(tuples, rlpList(rlpBytes(addr), rlpList(keys...)))
+	}
+	return rlpList(tuples...), nil
+}
+
+func decodeAddress(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 20 {
+		return nil, fmt.Errorf("address must be 20 bytes, got %d", len(b))
+	}
+	return b, nil
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,297 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"ChainConnector/internal/domain/entity"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		t.Fatalf("bad hex %q: %v", s, err)
+	}
+	return b
+}
+
+func strPtr(s string) *string { return &s }
+
+// Example from EIP-155 (https://eips.ethereum.org/EIPS/eip-155).
+const eip155Key = "0x4646464646464646464646464646464646464646464646464646464646464646"
+
+func eip155Tx() *entity.Transaction {
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	return &entity.Transaction{
+		Nonce:    9,
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21000,
+		To:       strPtr("0x3535353535353535353535353535353535353535"),
+		Value:    value,
+		ChainID:  big.NewInt(1),
+	}
+}
+
+func TestNewLocalSignerAddress(t *testing.T) {
+	s, err := NewLocalSigner(eip155Key)
+	if err != nil {
+		t.Fatalf("NewLocalSigner error: %v", err)
+	}
+	addr, _ := s.Address(context.Background())
+	if addr != "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F" {
+		t.Fatalf("unexpected address %s", addr)
+	}
+
+	for _, bad := range []string{"zz", "0x0102", "0x" + strings.Repeat("00", 32)} {
+		if _, err := NewLocalSigner(bad); err == nil {
+			t.Fatalf("expected error for key %q", bad)
+		}
+	}
+}
+
+func TestSignTransactionEIP155Vector(t *testing.T) {
+	s, _ := NewLocalSigner(eip155Key)
+	raw, hash, err := s.SignTransaction(context.Background(), eip155Tx())
+	if err != nil {
+		t.Fatalf("SignTransaction error: %v", err)
+	}
+	want := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	if got := hex.EncodeToString(raw); got != want {
+		t.Fatalf("unexpected signed tx\n got %s\nwant %s", got, want)
+	}
+	if hash != "0x"+hex.EncodeToString(keccak256(raw)) {
+		t.Fatalf("hash does not match raw tx: %s", hash)
+	}
+}
+
+func TestSigningPayloadEIP155Vector(t *testing.T) {
+	tx := eip155Tx()
+	fields, err := txFields(tx, txTypeLegacy)
+	if err != nil {
+		t.Fatalf("txFields error: %v", err)
+	}
+	unsigned := rlpList(append(fields, rlpBig(tx.ChainID), rlpUint(0), rlpUint(0))...)
+	if got := hex.EncodeToString(unsigned); got != "ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080" {
+		t.Fatalf("unexpected signing data %s", got)
+	}
+	if got := hex.EncodeToString(keccak256(unsigned)); got != "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53" {
+		t.Fatalf("unexpected signing hash %s", got)
+	}
+}
+
+// Encoding vector for an EIP-2930 transaction taken from go-ethereum's
+// core/types transaction tests (TestEIP2718TransactionEncode).
+func TestEncodeAccessListVector(t *testing.T) {
+	tx := &entity.Transaction{
+		Nonce:    3,
+		To:       strPtr("0x095e7baea6a6c7c4c2dfeb977efac326af552d87"),
+		Value:    big.NewInt(10),
+		Gas:      25000,
+		GasPrice: big.NewInt(1),
+		Data:     mustHex(t, "5544"),
+		ChainID:  big.NewInt(1),
+	}
+	fields, err := txFields(tx, txTypeAccessList)
+	if err != nil {
+		t.Fatalf("txFields error: %v", err)
+	}
+	sig := mustHex(t, "c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b266032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d3752101")
+	raw := encodeSigned(txTypeAccessList, fields, tx.ChainID, sig)
+	want := "01f8630103018261a894095e7baea6a6c7c4c2dfeb977efac326af552d870a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521"
+	if got := hex.EncodeToString(raw); got != want {
+		t.Fatalf("unexpected encoding\n got %s\nwant %s", got, want)
+	}
+}
+
+// Encoding vector for an EIP-1559 transaction taken from go-ethereum's
+// core/types block tests (TestEIP1559BlockEncoding).
+func TestEncodeDynamicFeeVector(t *testing.T) {
+	tx := &entity.Transaction{
+		Nonce:                0,
+		To:                   strPtr("0x095e7baea6a6c7c4c2dfeb977efac326af552d87"),
+		Value:                big.NewInt(0),
+		Gas:                  123457,
+		MaxPriorityFeePerGas: big.NewInt(0),
+		MaxFeePerGas:         big.NewInt(1_000_000_000),
+		ChainID:              big.NewInt(1),
+		AccessList: []entity.AccessTuple{{
+			Address:     "0x0000000000000000000000000000000000000001",
+			StorageKeys: []string{"0x" + strings.Repeat("00", 32)},
+		}},
+	}
+	if typ := txTypeFor(tx); typ != txTypeDynamicFee {
+		t.Fatalf("expected dynamic fee type, got %x", typ)
+	}
+	fields, err := txFields(tx, txTypeDynamicFee)
+	if err != nil {
+		t.Fatalf("txFields error: %v", err)
+	}
+	sig := mustHex(t, "fe38ca4e44a30002ac54af7cf922a6ac2ba11b7d22f548e8ecb3f51f41cb31b06de6a5cbae13c0c856e33acf021b51819636cfc009d39eafb9f606d546e305a800")
+	raw := encodeSigned(txTypeDynamicFee, fields, tx.ChainID, sig)
+	want := "02f8a0018080843b9aca008301e24194095e7baea6a6c7c4c2dfeb977efac326af552d878080f838f7940000000000000000000000000000000000000001e1a0000000000000000000000000000000000000000000000000000000000000000080a0fe38ca4e44a30002ac54af7cf922a6ac2ba11b7d22f548e8ecb3f51f41cb31b0a06de6a5cbae13c0c856e33acf021b51819636cfc009d39eafb9f606d546e305a8"
+	if got := hex.EncodeToString(raw); got != want {
+		t.Fatalf("unexpected encoding\n got %s\nwant %s", got, want)
+	}
+}
+
+// recoverSigner recovers the address that signed a typed transaction by
+// rebuilding its signing payload.
+func recoverSigner(t *testing.T, tx *entity.Transaction, typ byte, raw []byte) string {
+	t.Helper()
+	fields, err := txFields(tx, typ)
+	if err != nil {
+		t.Fatalf("txFields error: %v", err)
+	}
+	unsigned := append([]byte{typ}, rlpList(fields...)...)
+	// the signature is the last 3 items: yParity (0x80 or 0x01) and two
+	// 32-byte integers each prefixed by 0xa0
+	sigPart := raw[len(raw)-67:]
+	compact := make([]byte, 65)
+	compact[0] = 27
+	if sigPart[0] == 0x01 {
+		compact[0]++
+	}
+	copy(compact[1:33], sigPart[2:34])
+	copy(compact[33:], sigPart[35:67])
+	pub, _, err := ecdsa.RecoverCompact(compact, keccak256(unsigned))
+	if err != nil {
+		t.Fatalf("recover error: %v", err)
+	}
+	return entity.ChecksumAddress(keccak256(pub.SerializeUncompressed()[1:])[12:])
+}
+
+func TestSignTransactionTypeSelection(t *testing.T) {
+	s, _ := NewLocalSigner(eip155Key)
+	ctx := context.Background()
+	addr, _ := s.Address(ctx)
+
+	dyn := eip155Tx()
+	dyn.From = strings.ToLower(addr)
+	dyn.GasPrice = nil
+	dyn.MaxPriorityFeePerGas = big.NewInt(1_000_000_000)
+	dyn.MaxFeePerGas = big.NewInt(30_000_000_000)
+	raw, _, err := s.SignTransaction(ctx, dyn)
+	if err != nil {
+		t.Fatalf("dynamic fee sign error: %v", err)
+	}
+	if raw[0] != txTypeDynamicFee {
+		t.Fatalf("expected dynamic fee type, got %x", raw[0])
+	}
+	if got := recoverSigner(t, dyn, txTypeDynamicFee, raw); got != addr {
+		t.Fatalf("dynamic fee tx recovered %s, want %s", got, addr)
+	}
+
+	al := eip155Tx()
+	al.AccessList = []entity.AccessTuple{{
+		Address:     "0x3535353535353535353535353535353535353535",
+		StorageKeys: []string{"0x" + strings.Repeat("00", 31) + "01"},
+	}}
+	raw, _, err = s.SignTransaction(ctx, al)
+	if err != nil {
+		t.Fatalf("access list sign error: %v", err)
+	}
+	if raw[0] != txTypeAccessList {
+		t.Fatalf("expected access list type, got %x", raw[0])
+	}
+	if got := recoverSigner(t, al, txTypeAccessList, raw); got != addr {
+		t.Fatalf("access list tx recovered %s, want %s", got, addr)
+	}
+
+	// contract creation: no recipient
+	create := eip155Tx()
+	create.To = nil
+	if _, _, err := s.SignTransaction(ctx, create); err != nil {
+		t.Fatalf("contract creation sign error: %v", err)
+	}
+}
+
+func TestSignTransactionErrors(t *testing.T) {
+	s, _ := NewLocalSigner(eip155Key)
+	ctx := context.Background()
+
+	cases := map[string]func(tx *entity.Transaction){
+		"foreign from": func(tx *entity.Transaction) { tx.From = "0x0000000000000000000000000000000000000001" },
+		"no chain id":  func(tx *entity.Transaction) { tx.ChainID = nil },
+		"bad to":       func(tx *entity.Transaction) { tx.To = strPtr("0x1234") },
+		"no gas price": func(tx *entity.Transaction) { tx.GasPrice = nil },
+		"no tip":       func(tx *entity.Transaction) { tx.MaxFeePerGas = big.NewInt(1) },
+		"tip over cap": func(tx *entity.Transaction) {
+			tx.MaxFeePerGas, tx.MaxPriorityFeePerGas = big.NewInt(1), big.NewInt(2)
+		},
+		"al gas price": func(tx *entity.Transaction) {
+			tx.GasPrice = nil
+			tx.AccessList = []entity.AccessTuple{{Address: "0x3535353535353535353535353535353535353535"}}
+		},
+		"al bad addr": func(tx *entity.Transaction) { tx.AccessList = []entity.AccessTuple{{Address: "0xzz"}} },
+		"al bad key": func(tx *entity.Transaction) {
+			tx.AccessList = []entity.AccessTuple{{Address: "0x3535353535353535353535353535353535353535", StorageKeys: []string{"0x01"}}}
+		},
+		"dyn bad al": func(tx *entity.Transaction) {
+			tx.MaxFeePerGas, tx.MaxPriorityFeePerGas = big.NewInt(2), big.NewInt(1)
+			tx.AccessList = []entity.AccessTuple{{Address: "0x01"}}
+		},
+	}
+	for name, mutate := range cases {
+		tx := eip155Tx()
+		mutate(tx)
+		if _, _, err := s.SignTransaction(ctx, tx); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	if _, _, err := s.SignTransaction(ctx, nil); err == nil {
+		t.Fatalf("expected error for nil tx")
+	}
+}
+
+func TestSignHash(t *testing.T) {
+	s, _ := NewLocalSigner(eip155Key)
+	hash := keccak256([]byte("hello"))
+	sig, err := s.SignHash(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("SignHash error: %v", err)
+	}
+	if len(sig) != 65 || sig[64] > 1 {
+		t.Fatalf("unexpected signature %x", sig)
+	}
+	compact := append([]byte{27 + sig[64]}, sig[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		t.Fatalf("recover error: %v", err)
+	}
+	addr, _ := s.Address(context.Background())
+	if entity.ChecksumAddress(keccak256(pub.SerializeUncompressed()[1:])[12:]) != addr {
+		t.Fatalf("signature does not recover to signer")
+	}
+	if _, err := s.SignHash(context.Background(), []byte{1}); err == nil {
+		t.Fatalf("expected error for short hash")
+	}
+}
+
+func TestRLPEncoding(t *testing.T) {
+	cases := []struct {
+		got  []byte
+		want string
+	}{
+		{rlpBytes(nil), "80"},
+		{rlpBytes([]byte{0x7f}), "7f"},
+		{rlpBytes([]byte{0x80}), "8180"},
+		{rlpBytes([]byte("dog")), "83646f67"},
+		{rlpUint(0), "80"},
+		{rlpUint(1024), "820400"},
+		{rlpBig(nil), "80"},
+		{rlpList(), "c0"},
+		{rlpList(rlpBytes([]byte("cat")), rlpBytes([]byte("dog"))), "c88363617483646f67"},
+		{rlpBytes([]byte(strings.Repeat("a", 56))), "b838" + hex.EncodeToString([]byte(strings.Repeat("a", 56)))},
+	}
+	for i, c := range cases {
+		if got := hex.EncodeToString(c.got); got != c.want {
+			t.Fatalf("case %d: expected %s, got %s", i, c.want, got)
+		}
+	}
+}
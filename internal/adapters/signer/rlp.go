@@ -0,0 +1,47 @@
+package signer
+
+import (
+	"math/big"
+)
+
+// Minimal RLP encoder covering the item kinds used by Ethereum transactions:
+// byte strings, unsigned integers and (nested) lists of already encoded items.
+
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+func rlpUint(v uint64) []byte {
+	return rlpBytes(new(big.Int).SetUint64(v).Bytes())
+}
+
+// rlpBig encodes a non-negative integer; nil is encoded as zero.
+func rlpBig(v *big.Int) []byte {
+	if v == nil {
+		return rlpBytes(nil)
+	}
+	return rlpBytes(v.Bytes())
+}
+
+func rlpList(items ...[]byte) []byte {
+	size := 0
+	for _, it := range items {
+		size += len(it)
+	}
+	out := rlpHeader(0xc0, size)
+	for _, it := range items {
+		out = append(out, it...)
+	}
+	return out
+}
+
+func rlpHeader(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	sz := new(big.Int).SetInt64(int64(size)).Bytes()
+	return append([]byte{offset + 55 + byte(len(sz))}, sz...)
+}
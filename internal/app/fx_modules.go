@@ -5,11 +5,17 @@ import (
 	"ChainConnector/internal/adapters/http"
 	"ChainConnector/internal/adapters/postgres"
 	"ChainConnector/internal/adapters/rpc"
+	"ChainConnector/internal/adapters/signer"
+	"ChainConnector/internal/config"
 	"ChainConnector/internal/domain/entity"
 	"ChainConnector/internal/domain/ports"
 	"ChainConnector/internal/domain/service"
+	"ChainConnector/migrations"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -17,32 +23,34 @@ import (
 
 var Modules = fx.Options(
 	fx.Provide(
+		config.LoadFromEnv,
 		newZapLogger,
 		service.NewTransactionService,
-		func() ports.EventBus { return eventbus.NewInMemoryBus(4, 1024) },
-		postgres.NewInMemoryTxRepository,
+		providerEventBus,
+		providerDatabase,
+		providerTxRepository,
+		providerOutbox,
+		providerAPIKeyStore,
+		providerLimiter,
 		http.NewFiberServer,
-		providerETHRPC,
+		providerChainRouter,
+		providerWalletSigner,
 	),
 	fx.Invoke(func(lc fx.Lifecycle, h *http.FiberServer) {
 		h.Start(lc)
 	}),
 	fx.Invoke(func(lc fx.Lifecycle, bus ports.EventBus, svc *service.TransactionService, logger *zap.Logger) {
-		var unsub func()
+		var unsubs []func()
 		lc.Append(fx.Hook{
 			OnStart: func(ctx context.Context) error {
-				unsub = bus.Subscribe("transactions.create", func(ctx context.Context, payload interface{}) error {
-					tx, ok := payload.(*entity.Transaction)
-					if !ok {
-						return errors.New("invalid payload for transactions.create")
-					}
-					return svc.CreateTransaction(ctx, tx)
-				})
-				logger.Info("subscribed to transactions.create")
+				unsubs = append(unsubs,
+					bus.Subscribe(entity.TxCreatedEvent{}.Type(), signOnCreate(svc, logger)),
+				)
+				logger.Info("subscribed to " + entity.TxCreatedEvent{}.Type())
 				return nil
 			},
 			OnStop: func(ctx context.Context) error {
-				if unsub != nil {
+				for _, unsub := range unsubs {
 					unsub()
 				}
 				if err := bus.Close(); err != nil {
@@ -54,11 +62,113 @@ var Modules = fx.Options(
 	}),
 )
 
+// signOnCreate returns the TxCreated handler that signs and broadcasts each
+// newly created transaction.
+func signOnCreate(svc *service.TransactionService, logger *zap.Logger) ports.EventHandler {
+	return func(ctx context.Context, payload interface{}) error {
+		evt, ok := payload.(entity.TxCreatedEvent)
+		if !ok {
+			return errors.New("invalid payload for TxCreated")
+		}
+		if err := svc.SignAndSend(ctx, evt.TxID); err != nil {
+			logger.Error("sign and send failed", zap.String("tx_id", evt.TxID), zap.Error(err))
+			return err
+		}
+		return nil
+	}
+}
+
 func newZapLogger() (*zap.Logger, error) {
 	return zap.NewProduction()
 }
 
-func providerETHRPC(logger *zap.Logger) *rpc.ETHRPC {
-	eth := rpc.NewETHRPC(logger, nil)
-	return eth
+func providerEventBus(cfg *config.Config) ports.EventBus {
+	return eventbus.NewInMemoryBus(cfg.Bus.Workers, cfg.Bus.QueueSize)
+}
+
+// providerDatabase opens the postgres connection pool when the postgres
+// backend is configured, applies pending migrations when
+// repository.auto_migrate is set and closes the pool on stop. Other backends
+// get a nil *sql.DB.
+func providerDatabase(lc fx.Lifecycle, cfg *config.Config) (*sql.DB, error) {
+	if cfg.Repository.Backend != config.BackendPostgres {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	db, err := postgres.Open(ctx, cfg.Repository.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Repository.AutoMigrate {
+		if err := postgres.Migrate(ctx, db, migrations.FS); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return db.Close()
+		},
+	})
+	return db, nil
+}
+
+// providerTxRepository selects the repository implementation named by
+// cfg.Repository.Backend.
+func providerTxRepository(cfg *config.Config, db *sql.DB) (ports.TxRepositoryPort, error) {
+	switch cfg.Repository.Backend {
+	case config.BackendMemory:
+		return postgres.NewInMemoryTxRepository(), nil
+	case config.BackendPostgres:
+		return postgres.NewPostgresTxRepository(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported repository backend %q", cfg.Repository.Backend)
+	}
+}
+
+// providerOutbox exposes the outbox of the configured repository; every
+// repository backend implements ports.OutboxPort.
+func providerOutbox(repo ports.TxRepositoryPort) (ports.OutboxPort, error) {
+	outbox, ok := repo.(ports.OutboxPort)
+	if !ok {
+		return nil, fmt.Errorf("repository %T has no outbox", repo)
+	}
+	return outbox, nil
+}
+
+// providerAPIKeyStore looks API keys up in the api_keys table of the postgres
+// backend. Other backends only accept the keys of cfg.Auth and get nil.
+func providerAPIKeyStore(db *sql.DB) ports.APIKeyStore {
+	if db == nil {
+		return nil
+	}
+	return postgres.NewAPIKeyRepository(db)
+}
+
+// providerLimiter builds the API rate limits and quotas of cfg.Limits.
+func providerLimiter(cfg *config.Config) (*service.Limiter, error) {
+	quotas, err := cfg.Limits.DailyValueWei()
+	if err != nil {
+		return nil, err
+	}
+	return service.NewLimiter(service.LimitPolicy{
+		PerKey:     service.Rate{PerSecond: cfg.Limits.PerKey.PerSecond, Burst: cfg.Limits.PerKey.Burst},
+		PerSender:  service.Rate{PerSecond: cfg.Limits.PerSender.PerSecond, Burst: cfg.Limits.PerSender.Burst},
+		DailyValue: quotas,
+	}), nil
+}
+
+// providerWalletSigner loads the local signer key from cfg.Signer. Without a
+// key no signer is provided and the port is nil.
+func providerWalletSigner(cfg *config.Config) (ports.WalletSignerPort, error) {
+	if cfg.Signer.PrivateKey == "" {
+		return nil, nil
+	}
+	return signer.NewLocalSigner(cfg.Signer.PrivateKey)
+}
+
+// providerChainRouter builds one ETHRPC per configured chain.
+func providerChainRouter(logger *zap.Logger, cfg *config.Config) (ports.BlockchainPort, error) {
+	return rpc.NewChainRouterFromConfig(logger, cfg)
 }
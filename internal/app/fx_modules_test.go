@@ -1,16 +1,157 @@
 package app
 
 import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/adapters/rpc"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"ChainConnector/internal/domain/service"
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
 	"testing"
 
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
 	"go.uber.org/zap"
 )
 
-func TestProviderETHRPC(t *testing.T) {
+func TestProviderChainRouter(t *testing.T) {
 	logger := zap.NewNop()
-	eth := providerETHRPC(logger)
-	if eth == nil {
-		t.Fatalf("expected non-nil provider result")
+	cfg := config.Default()
+	cfg.Chains["POLYGON"] = config.ChainConfig{RPCURL: "http://polygon.local"}
+	cfg.Chains["ARBITRUM"] = config.ChainConfig{RPCURL: "http://arbitrum.local"}
+	bc, err := providerChainRouter(logger, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router, ok := bc.(*rpc.ChainRouter)
+	if !ok {
+		t.Fatalf("expected *rpc.ChainRouter, got %T", bc)
+	}
+	if router.DefaultChain() != "ETH" {
+		t.Fatalf("expected default chain ETH, got %s", router.DefaultChain())
+	}
+	if got := router.Chains(); len(got) != 3 {
+		t.Fatalf("expected 3 chains, got %v", got)
+	}
+}
+
+func TestProviderChainRouterUnknownDefault(t *testing.T) {
+	cfg := config.Default()
+	cfg.DefaultChain = "SOLANA"
+	if _, err := providerChainRouter(zap.NewNop(), cfg); err == nil {
+		t.Fatalf("expected error for unknown default chain")
+	}
+}
+
+func TestProviderEventBus(t *testing.T) {
+	bus := providerEventBus(config.Default())
+	if bus == nil {
+		t.Fatalf("expected non-nil bus")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
+
+func TestProviderTxRepository(t *testing.T) {
+	cfg := config.Default()
+	if repo, err := providerTxRepository(cfg, nil); err != nil || repo == nil {
+		t.Fatalf("expected in-memory repository, got %v %v", repo, err)
+	}
+	cfg.Repository.Backend = config.BackendPostgres
+	if repo, err := providerTxRepository(cfg, nil); err != nil || repo == nil {
+		t.Fatalf("expected postgres repository, got %v %v", repo, err)
+	}
+	cfg.Repository.Backend = "mongo"
+	if _, err := providerTxRepository(cfg, nil); err == nil {
+		t.Fatalf("expected error for unsupported backend")
+	}
+}
+
+func TestProviderOutbox(t *testing.T) {
+	if outbox, err := providerOutbox(postgres.NewInMemoryTxRepository()); err != nil || outbox == nil {
+		t.Fatalf("expected in-memory outbox, got %v %v", outbox, err)
+	}
+	if outbox, err := providerOutbox(postgres.NewPostgresTxRepository(nil)); err != nil || outbox == nil {
+		t.Fatalf("expected postgres outbox, got %v %v", outbox, err)
+	}
+	if _, err := providerOutbox(struct{ ports.TxRepositoryPort }{}); err == nil {
+		t.Fatalf("expected error for a repository without outbox")
+	}
+}
+
+func TestProviderAPIKeyStore(t *testing.T) {
+	if store := providerAPIKeyStore(nil); store != nil {
+		t.Fatalf("expected no key store without a database, got %v", store)
+	}
+	if store := providerAPIKeyStore(&sql.DB{}); store == nil {
+		t.Fatalf("expected the postgres key store")
+	}
+}
+
+func TestProviderLimiter(t *testing.T) {
+	cfg := config.Default()
+	cfg.Limits.PerSender = config.RateConfig{PerSecond: 1, Burst: 1}
+	cfg.Limits.DailyValue = map[string]string{"eth": "10"}
+	limits, err := providerLimiter(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := limits.Reserve("0xa", "ETH", big.NewInt(10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := limits.Reserve("0xb", "ETH", big.NewInt(11)); !errors.Is(err, service.ErrQuotaExceeded) {
+		t.Fatalf("expected the configured quota, got %v", err)
+	}
+	cfg.Limits.DailyValue["eth"] = "lots"
+	if _, err := providerLimiter(cfg); err == nil {
+		t.Fatalf("expected error for an invalid quota")
+	}
+}
+
+func TestProviderDatabase(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+	cfg := config.Default()
+	if db, err := providerDatabase(lc, cfg); err != nil || db != nil {
+		t.Fatalf("expected no database for the memory backend, got %v %v", db, err)
+	}
+	cfg.Repository.Backend = config.BackendPostgres
+	cfg.Repository.DSN = "postgres://nobody@127.0.0.1:1/none?sslmode=disable&connect_timeout=1"
+	if _, err := providerDatabase(lc, cfg); err == nil {
+		t.Fatalf("expected error for unreachable database")
+	}
+}
+
+func TestProviderWalletSigner(t *testing.T) {
+	cfg := config.Default()
+	if s, err := providerWalletSigner(cfg); err != nil || s != nil {
+		t.Fatalf("expected no signer without a key, got %v %v", s, err)
+	}
+	cfg.Signer.PrivateKey = "0x4646464646464646464646464646464646464646464646464646464646464646"
+	if s, err := providerWalletSigner(cfg); err != nil || s == nil {
+		t.Fatalf("expected signer, got %v %v", s, err)
+	}
+	cfg.Signer.PrivateKey = "nope"
+	if _, err := providerWalletSigner(cfg); err == nil {
+		t.Fatalf("expected error for invalid key")
+	}
+}
+
+func TestSignOnCreate(t *testing.T) {
+	repo := postgres.NewInMemoryTxRepository()
+	svc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	h := signOnCreate(svc, zap.NewNop())
+	if err := h(context.Background(), "not an event"); err == nil {
+		t.Fatalf("expected error for invalid payload")
+	}
+	// without a signer the service refuses to sign
+	err := h(context.Background(), entity.TxCreatedEvent{TxID: "t1"})
+	if !errors.Is(err, service.ErrSignerUnavailable) {
+		t.Fatalf("expected ErrSignerUnavailable, got %v", err)
 	}
 }
 
@@ -23,3 +164,9 @@ func TestNewZapLogger(t *testing.T) {
 		t.Fatalf("expected non-nil logger")
 	}
 }
+
+func TestModulesGraphIsValid(t *testing.T) {
+	if err := fx.ValidateApp(Modules); err != nil {
+		t.Fatalf("invalid fx graph: %v", err)
+	}
+}
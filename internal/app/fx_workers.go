@@ -0,0 +1,93 @@
+package app
+
+import (
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/ports"
+	"ChainConnector/internal/domain/service"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var Workers = fx.Options(
+	fx.Provide(providerConfirmationTracker, providerOutboxRelay),
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, relay *service.OutboxRelay, logger *zap.Logger) {
+		startWorker(lc, logger, "outbox-relay", time.Duration(cfg.Outbox.PollInterval), relay.Drain)
+	}),
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, tracker *service.ConfirmationTracker, logger *zap.Logger) {
+		startWorker(lc, logger, "confirmation-tracker", time.Duration(cfg.Tracker.PollInterval), tracker.Poll)
+	}),
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, svc *service.TransactionService, logger *zap.Logger) {
+		startWorker(lc, logger, "nonce-reconciler", time.Duration(cfg.Nonces.ReconcileInterval), svc.ReconcileNonces)
+	}),
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, svc *service.TransactionService, logger *zap.Logger) {
+		stuckAfter := time.Duration(cfg.Tracker.StuckAfter)
+		startWorker(lc, logger, "stuck-tx-speedup", time.Duration(cfg.Tracker.PollInterval), func(ctx context.Context) error {
+			return svc.SpeedUpStuck(ctx, stuckAfter)
+		})
+	}),
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, svc *service.TransactionService, logger *zap.Logger) {
+		retryAfter := time.Duration(cfg.Tracker.StuckAfter)
+		startWorker(lc, logger, "pending-tx-retry", time.Duration(cfg.Tracker.PollInterval), func(ctx context.Context) error {
+			return svc.RetryPending(ctx, retryAfter)
+		})
+	}),
+)
+
+func providerConfirmationTracker(
+	cfg *config.Config,
+	repo ports.TxRepositoryPort,
+	chain ports.BlockchainPort,
+	logger *zap.Logger,
+) *service.ConfirmationTracker {
+	return service.NewConfirmationTracker(repo, chain, logger,
+		cfg.Confirmations(), cfg.Chains[cfg.DefaultChain].Confirmations, cfg.Tracker.BatchSize)
+}
+
+func providerOutboxRelay(cfg *config.Config, outbox ports.OutboxPort, bus ports.EventBus, logger *zap.Logger) *service.OutboxRelay {
+	return service.NewOutboxRelay(outbox, bus, logger, cfg.Outbox.BatchSize, time.Duration(cfg.Outbox.Lease))
+}
+
+// startWorker registers lifecycle hooks that run fn once on start and then
+// every interval in a background goroutine until OnStop.
+func startWorker(lc fx.Lifecycle, logger *zap.Logger, name string, interval time.Duration, fn func(context.Context) error) {
+	var (
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					if err := fn(ctx); err != nil && ctx.Err() == nil {
+						logger.Warn("worker run failed", zap.String("worker", name), zap.Error(err))
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			}()
+			logger.Info("worker started", zap.String("worker", name), zap.Duration("interval", interval))
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			if cancel != nil {
+				cancel()
+			}
+			wg.Wait()
+			logger.Info("worker stopped", zap.String("worker", name))
+			return nil
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ChainConnector/internal/adapters/eventbus"
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/service"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestStartWorkerRunsUntilStop(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+	var runs atomic.Int32
+	startWorker(lc, zap.NewNop(), "test", time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		return context.DeadlineExceeded
+	})
+	lc.RequireStart()
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	lc.RequireStop()
+	if runs.Load() < 2 {
+		t.Fatalf("expected worker to run repeatedly, got %d runs", runs.Load())
+	}
+	stopped := runs.Load()
+	time.Sleep(5 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Fatalf("worker kept running after stop")
+	}
+}
+
+func TestProviderConfirmationTracker(t *testing.T) {
+	cfg := config.Default()
+	chain, err := providerChainRouter(zap.NewNop(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracker := providerConfirmationTracker(cfg, postgres.NewInMemoryTxRepository(), chain, zap.NewNop())
+	if tracker == nil {
+		t.Fatalf("expected tracker")
+	}
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("empty repository poll should succeed: %v", err)
+	}
+}
+
+func TestOutboxRelayDeliversStoredEvents(t *testing.T) {
+	cfg := config.Default()
+	repo := postgres.NewInMemoryTxRepository()
+	outbox, err := providerOutbox(repo)
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+	bus := eventbus.NewInMemoryBus(1, 1)
+	defer func() { _ = bus.Close() }()
+	created := make(chan entity.TxCreatedEvent, 1)
+	bus.Subscribe(entity.TxCreatedEvent{}.Type(), func(ctx context.Context, payload interface{}) error {
+		created <- payload.(entity.TxCreatedEvent)
+		return nil
+	})
+
+	svc := service.NewTransactionService(repo, nil, nil, zap.NewNop())
+	if err := svc.CreateTransaction(context.Background(), &entity.Transaction{ID: "t1"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	relay := providerOutboxRelay(cfg, outbox, bus, zap.NewNop())
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	select {
+	case evt := <-created:
+		if evt.TxID != "t1" {
+			t.Fatalf("unexpected event %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("TxCreated was not delivered")
+	}
+	if left, _ := outbox.Claim(context.Background(), 10, time.Minute); len(left) != 0 {
+		t.Fatalf("expected the outbox to be drained, got %+v", left)
+	}
+}
+
+func TestWorkersGraphIsValid(t *testing.T) {
+	if err := fx.ValidateApp(Modules, Workers); err != nil {
+		t.Fatalf("invalid fx graph: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"ChainConnector/migrations"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: chainconnector migrate up | down [steps] | status"
+
+// migrator is the part of *postgres.Migrator used by the migrate command.
+type migrator interface {
+	Up(ctx context.Context) ([]postgres.Migration, error)
+	Down(ctx context.Context, steps int) ([]postgres.Migration, error)
+	Status(ctx context.Context) ([]postgres.MigrationStatus, error)
+}
+
+// RunMigrate implements `chainconnector migrate up|down [steps]|status`
+// against the database of repository.dsn, writing progress to out.
+func RunMigrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return err
+	}
+	if cfg.Repository.DSN == "" {
+		return errors.New("migrate: repository.dsn is not set")
+	}
+	openCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	db, err := postgres.Open(openCtx, cfg.Repository.DSN)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	m, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+	return runMigrate(ctx, m, args, out)
+}
+
+func runMigrate(ctx context.Context, m migrator, args []string, out io.Writer) error {
+	switch {
+	case len(args) == 0:
+		return errors.New(migrateUsage)
+	case len(args) == 1 && args[0] == "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(out, "applied %s\n", mig)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case len(args) <= 2 && args[0] == "down":
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: invalid steps %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(out, "reverted %s\n", mig)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(out, "no migration to revert")
+		}
+		return err
+	case len(args) == 1 && args[0] == "status":
+		states, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"ChainConnector/internal/adapters/postgres"
+	"ChainConnector/internal/config"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMigrator struct {
+	applied   []postgres.Migration
+	steps     int
+	states    []postgres.MigrationStatus
+	statusErr error
+}
+
+func (f *fakeMigrator) Up(context.Context) ([]postgres.Migration, error) {
+	return f.applied, nil
+}
+
+func (f *fakeMigrator) Down(_ context.Context, steps int) ([]postgres.Migration, error) {
+	f.steps = steps
+	return f.applied, nil
+}
+
+func (f *fakeMigrator) Status(context.Context) ([]postgres.MigrationStatus, error) {
+	return f.states, f.statusErr
+}
+
+func TestRunMigrate(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := &fakeMigrator{
+		applied: []postgres.Migration{{Version: 2, Name: "align_transactions"}},
+		states: []postgres.MigrationStatus{
+			{Migration: postgres.Migration{Version: 1, Name: "create_transactions"}, AppliedAt: &at},
+			{Migration: postgres.Migration{Version: 2, Name: "align_transactions"}},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := runMigrate(ctx, m, []string{"up"}, &out); err != nil || out.String() != "applied 0002_align_transactions\n" {
+		t.Fatalf("up: %q %v", out.String(), err)
+	}
+	out.Reset()
+	if err := runMigrate(ctx, m, []string{"down", "3"}, &out); err != nil || m.steps != 3 ||
+		!strings.Contains(out.String(), "reverted 0002_align_transactions") {
+		t.Fatalf("down: %q %d %v", out.String(), m.steps, err)
+	}
+	if err := runMigrate(ctx, m, []string{"down"}, &out); err != nil || m.steps != 1 {
+		t.Fatalf("expected one step by default, got %d %v", m.steps, err)
+	}
+	out.Reset()
+	if err := runMigrate(ctx, m, []string{"status"}, &out); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "2024-01-02T03:04:05Z") || !strings.HasSuffix(lines[2], "pending") {
+		t.Fatalf("unexpected status output:\n%s", out.String())
+	}
+
+	m.applied = nil
+	out.Reset()
+	if err := runMigrate(ctx, m, []string{"up"}, &out); err != nil || out.String() != "schema is up to date\n" {
+		t.Fatalf("up to date: %q %v", out.String(), err)
+	}
+
+	m.statusErr = errors.New("db down")
+	for _, args := range [][]string{{"status"}, {}, {"sideways"}, {"down", "0"}, {"down", "x"}, {"up", "now"}} {
+		if err := runMigrate(ctx, m, args, &out); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+}
+
+func TestRunMigrateConfig(t *testing.T) {
+	t.Setenv(config.EnvConfigFile, "")
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_DSN", "")
+	if err := RunMigrate(context.Background(), nil, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected usage error")
+	}
+	if err := RunMigrate(context.Background(), []string{"status"}, &bytes.Buffer{}); err == nil ||
+		!strings.Contains(err.Error(), "repository.dsn") {
+		t.Fatalf("expected missing dsn error, got %v", err)
+	}
+}
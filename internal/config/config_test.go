@@ -0,0 +1,330 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return p
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Addr != ":3000" || cfg.Bus.Workers != 4 || cfg.Bus.QueueSize != 1024 {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.Repository.Backend != BackendMemory {
+		t.Fatalf("expected memory backend, got %s", cfg.Repository.Backend)
+	}
+	eth, ok := cfg.Chains["ETH"]
+	if !ok || eth.RPCURL != DefaultRPCURL {
+		t.Fatalf("expected default ETH chain, got %+v", cfg.Chains)
+	}
+	if time.Duration(eth.Timeout) != 10*time.Second || eth.Fees != DefaultFeeConfig() || eth.Confirmations != 1 {
+		t.Fatalf("expected chain defaults to be filled, got %+v", eth)
+	}
+	if time.Duration(cfg.Tracker.PollInterval) != 5*time.Second || cfg.Tracker.BatchSize != 100 ||
+		time.Duration(cfg.Tracker.StuckAfter) != 3*time.Minute {
+		t.Fatalf("unexpected tracker defaults: %+v", cfg.Tracker)
+	}
+	if time.Duration(cfg.Nonces.ReconcileInterval) != 30*time.Second {
+		t.Fatalf("unexpected nonce defaults: %+v", cfg.Nonces)
+	}
+	if time.Duration(cfg.Outbox.PollInterval) != 500*time.Millisecond || cfg.Outbox.BatchSize != 100 ||
+		time.Duration(cfg.Outbox.Lease) != 30*time.Second {
+		t.Fatalf("unexpected outbox defaults: %+v", cfg.Outbox)
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	p := writeFile(t, "config.yaml", `
+server:
+  addr: ":8080"
+bus:
+  workers: 8
+tracker:
+  poll_interval: 2s
+default_chain: polygon
+chains:
+  polygon:
+    rpc_url: https://polygon.example
+    timeout: 3s
+    confirmations: 12
+    fees:
+      percentiles: [20, 60, 95]
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Addr != ":8080" || cfg.Bus.Workers != 8 || cfg.Bus.QueueSize != 1024 {
+		t.Fatalf("unexpected server/bus config: %+v", cfg)
+	}
+	if cfg.DefaultChain != "POLYGON" {
+		t.Fatalf("expected normalized default chain, got %s", cfg.DefaultChain)
+	}
+	poly := cfg.Chains["POLYGON"]
+	if time.Duration(poly.Timeout) != 3*time.Second {
+		t.Fatalf("expected 3s timeout, got %v", time.Duration(poly.Timeout))
+	}
+	if poly.Fees.Percentiles != [3]float64{20, 60, 95} || poly.Fees.BlockCount != 10 {
+		t.Fatalf("unexpected fee config: %+v", poly.Fees)
+	}
+	if time.Duration(cfg.Tracker.PollInterval) != 2*time.Second || cfg.Tracker.BatchSize != 100 {
+		t.Fatalf("unexpected tracker config: %+v", cfg.Tracker)
+	}
+	if conf := cfg.Confirmations(); conf["POLYGON"] != 12 || len(conf) != 1 {
+		t.Fatalf("unexpected confirmations: %v", conf)
+	}
+	if got := cfg.ChainNames(); len(got) != 1 || got[0] != "POLYGON" {
+		t.Fatalf("expected the file chains to replace the defaults, got %v", got)
+	}
+}
+
+func TestLoadChainNames(t *testing.T) {
+	// a file without chains keeps the default one
+	cfg, err := Load(writeFile(t, "config.yaml", "server:\n  addr: \":8080\"\n"))
+	if err != nil || cfg.Chains["ETH"].RPCURL != DefaultRPCURL {
+		t.Fatalf("expected the default ETH chain, got %+v %v", cfg, err)
+	}
+	// a lower-case name replaces the default instead of racing it
+	cfg, err = Load(writeFile(t, "config.yaml", "chains:\n  eth:\n    rpc_url: http://localhost:8545\n"))
+	if err != nil || len(cfg.Chains) != 1 || cfg.Chains["ETH"].RPCURL != "http://localhost:8545" {
+		t.Fatalf("expected the file ETH chain only, got %+v %v", cfg, err)
+	}
+
+	_, err = Load(writeFile(t, "config.yaml", "chains:\n  eth:\n    rpc_url: http://a.example\n  ETH:\n    rpc_url: http://b.example\n"))
+	if err == nil || !strings.Contains(err.Error(), `"ETH" and "eth"`) {
+		t.Fatalf("expected colliding chain names to be rejected, got %v", err)
+	}
+	t.Setenv("EVM_RPC_MAP", `{"base":"https://a.example","Base":"https://b.example"}`)
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "EVM_RPC_MAP") {
+		t.Fatalf("expected colliding EVM_RPC_MAP names to be rejected, got %v", err)
+	}
+
+	// chains from the environment alone also replace the default
+	t.Setenv("EVM_RPC_MAP", `{"base":"https://base.example"}`)
+	t.Setenv("CHAINCONNECTOR_DEFAULT_CHAIN", "base")
+	cfg, err = Load("")
+	if err != nil || len(cfg.Chains) != 1 || cfg.Chains["BASE"].RPCURL != "https://base.example" {
+		t.Fatalf("expected the BASE chain only, got %+v %v", cfg, err)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	p := writeFile(t, "config.json", `{"server":{"addr":":9000"},"chains":{"ETH":{"rpc_url":"http://localhost:8545","timeout":"1m"}}}`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Addr != ":9000" || cfg.Chains["ETH"].RPCURL != "http://localhost:8545" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if time.Duration(cfg.Chains["ETH"].Timeout) != time.Minute {
+		t.Fatalf("unexpected timeout: %v", time.Duration(cfg.Chains["ETH"].Timeout))
+	}
+}
+
+func TestLoadFromEnvOverrides(t *testing.T) {
+	p := writeFile(t, "config.yml", "server:\n  addr: \":8080\"\n")
+	t.Setenv(EnvConfigFile, p)
+	t.Setenv("CHAINCONNECTOR_SERVER_ADDR", ":7000")
+	t.Setenv("CHAINCONNECTOR_BUS_WORKERS", "2")
+	t.Setenv("CHAINCONNECTOR_BUS_QUEUE_SIZE", "16")
+	t.Setenv("CHAINCONNECTOR_CHAINS_ARBITRUM_ONE_RPC_URL", "https://arb.example")
+	t.Setenv("EVM_RPC_MAP", `{"base":"https://base.example"}`)
+	t.Setenv("CHAINCONNECTOR_DEFAULT_CHAIN", "base")
+	t.Setenv("CHAINCONNECTOR_SIGNER_PRIVATE_KEY", "0xabc")
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_BACKEND", "Postgres")
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_DSN", "postgres://localhost/cc")
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_AUTO_MIGRATE", "true")
+	t.Setenv("CHAINCONNECTOR_AUTH_ENABLED", "true")
+	t.Setenv("CHAINCONNECTOR_AUTH_JWT_HS256_SECRET", "s3cret")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.Addr != ":7000" || cfg.Bus.Workers != 2 || cfg.Bus.QueueSize != 16 {
+		t.Fatalf("env overrides not applied: %+v", cfg)
+	}
+	if cfg.Chains["ARBITRUM_ONE"].RPCURL != "https://arb.example" {
+		t.Fatalf("expected ARBITRUM_ONE chain from env, got %+v", cfg.Chains)
+	}
+	if cfg.Chains["BASE"].RPCURL != "https://base.example" || cfg.DefaultChain != "BASE" {
+		t.Fatalf("expected BASE default chain from env, got %+v", cfg)
+	}
+	if cfg.Signer.PrivateKey != "0xabc" {
+		t.Fatalf("expected signer key from env")
+	}
+	if cfg.Repository.Backend != BackendPostgres || cfg.Repository.DSN != "postgres://localhost/cc" || !cfg.Repository.AutoMigrate {
+		t.Fatalf("expected postgres repository from env, got %+v", cfg.Repository)
+	}
+	if !cfg.Auth.Enabled || cfg.Auth.JWT.HS256Secret != "s3cret" {
+		t.Fatalf("expected auth settings from env, got %+v", cfg.Auth)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if _, err := Load(writeFile(t, "config.toml", "")); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+	if _, err := Load(writeFile(t, "config.json", "{")); err == nil {
+		t.Fatalf("expected error for malformed json")
+	}
+	if _, err := Load(writeFile(t, "config.yaml", "chains:\n  ETH:\n    timeout: soon\n")); err == nil {
+		t.Fatalf("expected error for invalid duration")
+	}
+
+	t.Setenv("CHAINCONNECTOR_BUS_WORKERS", "many")
+	if _, err := Load(""); err == nil {
+		t.Fatalf("expected error for invalid integer override")
+	}
+	t.Setenv("CHAINCONNECTOR_BUS_WORKERS", "1")
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_AUTO_MIGRATE", "sometimes")
+	if _, err := Load(""); err == nil {
+		t.Fatalf("expected error for invalid boolean override")
+	}
+	t.Setenv("CHAINCONNECTOR_REPOSITORY_AUTO_MIGRATE", "false")
+	t.Setenv("EVM_RPC_MAP", "not json")
+	if _, err := Load(""); err == nil {
+		t.Fatalf("expected error for invalid EVM_RPC_MAP")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{
+		Repository:   RepositoryConfig{Backend: "mongo"},
+		DefaultChain: "ETH",
+		Chains: map[string]ChainConfig{
+			"POLYGON": {RPCURL: "ftp://polygon", Fees: FeeConfig{Percentiles: [3]float64{90, 50, 10}}},
+			"BSC":     {RPCURL: "::", Timeout: -1, Fees: DefaultFeeConfig()},
+			"BASE":    {RPCURL: "https://base", Fees: FeeConfig{Percentiles: [3]float64{1, 2, 3}}},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, want := range []string{
+		"server.addr", "bus.workers", "bus.queue_size", "repository.backend",
+		"default_chain", "chains.POLYGON", "chains.BSC", "base_fee_multiplier",
+		"tracker.poll_interval", "tracker.batch_size", "tracker.stuck_after",
+		"nonces.reconcile_interval", "outbox.poll_interval", "outbox.batch_size", "outbox.lease",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in validation error, got: %v", want, err)
+		}
+	}
+
+	pg := Default()
+	pg.Repository.Backend = BackendPostgres
+	if err := pg.Validate(); err == nil || !strings.Contains(err.Error(), "repository.dsn") {
+		t.Fatalf("expected missing dsn error, got %v", err)
+	}
+
+	if err := (&Config{Server: ServerConfig{Addr: ":1"}, Bus: BusConfig{1, 1}, Repository: RepositoryConfig{Backend: BackendMemory}}).Validate(); err == nil ||
+		!strings.Contains(err.Error(), "at least one chain") {
+		t.Fatalf("expected missing chain error, got %v", err)
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	cfg := Default()
+	cfg.normalize()
+	cfg.Auth.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "enabled without") {
+		t.Fatalf("expected missing credentials error, got %v", err)
+	}
+
+	cfg.Auth.APIKeys = []APIKeyConfig{
+		{Name: "ops", SHA256: hash, Scopes: []string{"tx:read", "admin"}},
+		{SHA256: "abc", Scopes: []string{"tx:delete"}},
+		{Name: "copy", SHA256: strings.ToUpper(hash)},
+	}
+	cfg.Auth.JWT.RS256PublicKey = "not pem"
+	err := cfg.Validate()
+	for _, want := range []string{
+		"api_keys[1].name", "api_keys[1].sha256", `unknown scope "tx:delete"`,
+		"api_keys[2].sha256 is a duplicate", "api_keys[2].scopes", "jwt.rs256_public_key",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in validation error, got: %v", want, err)
+		}
+	}
+
+	cfg.Auth.APIKeys = cfg.Auth.APIKeys[:1]
+	cfg.Auth.JWT.RS256PublicKey = ""
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// database keys are enough with the postgres backend
+	pg := Default()
+	pg.normalize()
+	pg.Repository = RepositoryConfig{Backend: BackendPostgres, DSN: "postgres://localhost/db"}
+	pg.Auth.Enabled = true
+	if err := pg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDurationText(t *testing.T) {
+	d := Duration(90 * time.Second)
+	b, err := d.MarshalText()
+	if err != nil || string(b) != "1m30s" {
+		t.Fatalf("unexpected marshal: %s %v", b, err)
+	}
+	var back Duration
+	if err := back.UnmarshalText(b); err != nil || back != d {
+		t.Fatalf("round trip failed: %v %v", back, err)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	cfg := Default()
+	cfg.Limits = LimitsConfig{
+		PerKey:     RateConfig{PerSecond: 2.5},
+		PerSender:  RateConfig{PerSecond: 0.1},
+		DailyValue: map[string]string{"eth": "1000000000000000000"},
+	}
+	cfg.normalize()
+	if cfg.Limits.PerKey.Burst != 3 || cfg.Limits.PerSender.Burst != 1 {
+		t.Fatalf("expected bursts defaulted from the rates, got %+v", cfg.Limits)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wei, _ := cfg.Limits.DailyValueWei(); wei["ETH"].String() != "1000000000000000000" {
+		t.Fatalf("expected the quota keyed by normalized chain, got %v", wei)
+	}
+
+	cfg.Limits.PerKey.PerSecond = -1
+	cfg.Limits.DailyValue = map[string]string{"ETH": "1e18", "SOLANA": "5"}
+	err := cfg.Validate()
+	for _, want := range []string{"per_key must not be negative", `daily_value.ETH: "1e18"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in validation error, got: %v", want, err)
+		}
+	}
+	cfg.Limits.DailyValue = map[string]string{"SOLANA": "5"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "daily_value.SOLANA: chain is not configured") {
+		t.Fatalf("expected unknown chain error, got %v", err)
+	}
+}
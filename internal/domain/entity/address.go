@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ChecksumAddress returns the EIP-55 mixed-case hex form of a 20-byte address.
+func ChecksumAddress(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"math/big"
+	"time"
+)
+
+type AttemptStatus int
+
+const (
+	AttemptStatusPending AttemptStatus = iota
+	AttemptStatusMined
+	AttemptStatusReplaced
+)
+
+func (s AttemptStatus) String() string {
+	switch s {
+	case AttemptStatusPending:
+		return "pending"
+	case AttemptStatusMined:
+		return "mined"
+	case AttemptStatusReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// TxAttempt is one signed and broadcast version of a transaction. Speeding up
+// a stuck transaction re-signs the same nonce with higher fees, so a single
+// Transaction may have several attempts of which at most one is mined.
+type TxAttempt struct {
+	TxHash               string        `json:"tx_hash"`
+	RawTxHex             string        `json:"raw_tx_hex,omitempty"`
+	GasPrice             *big.Int      `json:"gas_price,omitempty"`
+	MaxPriorityFeePerGas *big.Int      `json:"max_priority_fee_per_gas,omitempty"`
+	MaxFeePerGas         *big.Int      `json:"max_fee_per_gas,omitempty"`
+	SentAt               time.Time     `json:"sent_at"`
+	Status               AttemptStatus `json:"status"`
+	// Cancel marks a zero-value self-transfer that replaces the original
+	// payload to cancel the transaction.
+	Cancel bool `json:"cancel,omitempty"`
+}
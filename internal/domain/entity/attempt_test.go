@@ -0,0 +1,30 @@
+package entity
+
+import "testing"
+
+func TestAttemptStatusString(t *testing.T) {
+	tests := map[AttemptStatus]string{
+		AttemptStatusPending:  "pending",
+		AttemptStatusMined:    "mined",
+		AttemptStatusReplaced: "replaced",
+		AttemptStatus(42):     "unknown",
+	}
+	for status, want := range tests {
+		if got := status.String(); got != want {
+			t.Errorf("AttemptStatus(%d).String() = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestAttemptHashes(t *testing.T) {
+	if got := (&Transaction{}).AttemptHashes(); got != nil {
+		t.Fatalf("expected no hashes, got %v", got)
+	}
+	if got := (&Transaction{TxHash: "0x1"}).AttemptHashes(); len(got) != 1 || got[0] != "0x1" {
+		t.Fatalf("expected TxHash fallback, got %v", got)
+	}
+	tx := &Transaction{TxHash: "0x2", Attempts: []TxAttempt{{TxHash: "0x1"}, {TxHash: "0x2"}}}
+	if got := tx.AttemptHashes(); len(got) != 2 || got[0] != "0x1" || got[1] != "0x2" {
+		t.Fatalf("unexpected hashes %v", got)
+	}
+}
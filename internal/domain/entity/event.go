@@ -1,6 +1,11 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
 
 type Event interface {
 	Type() string
@@ -36,6 +41,17 @@ type TxSentEvent struct {
 
 func (TxSentEvent) Type() string { return "TxSent" }
 
+// TxReplacedEvent is published when a stuck transaction is re-broadcast with
+// higher fees under a new hash.
+type TxReplacedEvent struct {
+	BaseEvent
+	TxID    string `json:"tx_id"`
+	OldHash string `json:"old_hash"`
+	NewHash string `json:"new_hash"`
+}
+
+func (TxReplacedEvent) Type() string { return "TxReplaced" }
+
 type TxConfirmedEvent struct {
 	BaseEvent
 	TxID    string  `json:"tx_id"`
@@ -52,3 +68,46 @@ type TxFailedEvent struct {
 }
 
 func (TxFailedEvent) Type() string { return "TxFailed" }
+
+// TxCancelledEvent is published when a transaction is cancelled, either
+// before signing or once its zero-value replacement is mined.
+type TxCancelledEvent struct {
+	BaseEvent
+	TxID   string `json:"tx_id"`
+	TxHash string `json:"tx_hash,omitempty"`
+}
+
+func (TxCancelledEvent) Type() string { return "TxCancelled" }
+
+// ErrUnknownEvent is returned by DecodeEvent for an event type it does not know.
+var ErrUnknownEvent = errors.New("unknown event type")
+
+// eventDecoders maps each Event.Type to the decoder of its JSON form.
+var eventDecoders = map[string]func([]byte) (Event, error){
+	TxCreatedEvent{}.Type():   decodeEvent[TxCreatedEvent],
+	TxSignedEvent{}.Type():    decodeEvent[TxSignedEvent],
+	TxSentEvent{}.Type():      decodeEvent[TxSentEvent],
+	TxReplacedEvent{}.Type():  decodeEvent[TxReplacedEvent],
+	TxConfirmedEvent{}.Type(): decodeEvent[TxConfirmedEvent],
+	TxFailedEvent{}.Type():    decodeEvent[TxFailedEvent],
+	TxCancelledEvent{}.Type(): decodeEvent[TxCancelledEvent],
+}
+
+// DecodeEvent rebuilds the event of type eventType from its JSON encoding.
+// The result has the same dynamic type the event was published with, e.g.
+// TxCreatedEvent rather than a pointer or a map.
+func DecodeEvent(eventType string, data []byte) (Event, error) {
+	decode, ok := eventDecoders[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEvent, eventType)
+	}
+	return decode(data)
+}
+
+func decodeEvent[T Event](data []byte) (Event, error) {
+	var evt T
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return nil, err
+	}
+	return evt, nil
+}
@@ -1,6 +1,9 @@
 package entity
 
 import (
+	"encoding/json"
+	"errors"
+	"math/big"
 	"testing"
 	"time"
 )
@@ -32,4 +35,47 @@ func TestEventTypesAndTimestamp(t *testing.T) {
 	if e5.Type() != "TxFailed" {
 		t.Fatalf("unexpected type %s", e5.Type())
 	}
+	var e6 TxReplacedEvent
+	if e6.Type() != "TxReplaced" {
+		t.Fatalf("unexpected type %s", e6.Type())
+	}
+	var e7 TxCancelledEvent
+	if e7.Type() != "TxCancelled" {
+		t.Fatalf("unexpected type %s", e7.Type())
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, evt := range []Event{
+		TxCreatedEvent{BaseEvent{at}, "t1"},
+		TxSignedEvent{BaseEvent{at}, "t1", "0xa"},
+		TxSentEvent{BaseEvent{at}, "t1", "0xa"},
+		TxReplacedEvent{BaseEvent{at}, "t1", "0xa", "0xb"},
+		TxConfirmedEvent{BaseEvent{at}, "t1", "0xb", Receipt{BlockNumber: 9, EffectiveGasPrice: big.NewInt(7)}},
+		TxFailedEvent{BaseEvent{at}, "t1", "boom"},
+		TxCancelledEvent{BaseEvent{at}, "t1", "0xc"},
+	} {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", evt.Type(), err)
+		}
+		got, err := DecodeEvent(evt.Type(), data)
+		if err != nil {
+			t.Fatalf("decode %s: %v", evt.Type(), err)
+		}
+		again, _ := json.Marshal(got)
+		if got.Type() != evt.Type() || !got.Timestamp().Equal(at) || string(again) != string(data) {
+			t.Errorf("%s did not round-trip: %s != %s", evt.Type(), again, data)
+		}
+	}
+	if evt, _ := DecodeEvent("TxCreated", []byte(`{"tx_id":"t1"}`)); evt != (TxCreatedEvent{TxID: "t1"}) {
+		t.Fatalf("expected a TxCreatedEvent value, got %#v", evt)
+	}
+	if _, err := DecodeEvent("TxExploded", []byte(`{}`)); !errors.Is(err, ErrUnknownEvent) {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+	if _, err := DecodeEvent("TxSent", []byte(`{`)); err == nil {
+		t.Fatalf("expected invalid JSON to fail")
+	}
 }
@@ -0,0 +1,24 @@
+package entity
+
+import "fmt"
+
+// FeeTier selects how aggressively the estimated fees of a transaction are
+// priced. The zero value is the standard tier.
+type FeeTier string
+
+const (
+	FeeTierSlow     FeeTier = "slow"
+	FeeTierStandard FeeTier = "standard"
+	FeeTierFast     FeeTier = "fast"
+)
+
+// ParseFeeTier reads a tier name; an empty name is the standard tier.
+func ParseFeeTier(s string) (FeeTier, error) {
+	switch t := FeeTier(s); t {
+	case "", FeeTierStandard:
+		return FeeTierStandard, nil
+	case FeeTierSlow, FeeTierFast:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown fee tier %q", s)
+}
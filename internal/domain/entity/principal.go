@@ -0,0 +1,38 @@
+package entity
+
+import "slices"
+
+// Scopes granted to API credentials. ScopeAdmin implies every other scope.
+const (
+	ScopeTxRead  = "tx:read"
+	ScopeTxWrite = "tx:write"
+	ScopeAdmin   = "admin"
+)
+
+// KnownScope reports whether scope is one of the scopes above.
+func KnownScope(scope string) bool {
+	switch scope {
+	case ScopeTxRead, ScopeTxWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// Principal is an authenticated API caller. Subject names it with the kind of
+// credential it used, e.g. "api-key:backoffice" or "jwt:user-42".
+type Principal struct {
+	Subject string
+	Scopes  []string
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// APIKey is a stored API key. Only the hex SHA-256 of the key is kept.
+type APIKey struct {
+	Name    string
+	KeyHash string
+	Scopes  []string
+}
@@ -1,5 +1,10 @@
 package entity
 
+import (
+	"fmt"
+	"strings"
+)
+
 type TxStatus int
 
 const (
@@ -30,3 +35,26 @@ func (s TxStatus) String() string {
 		return "unknown"
 	}
 }
+
+// ParseTxStatus is the inverse of TxStatus.String. Matching ignores case and
+// surrounding spaces.
+func ParseTxStatus(s string) (TxStatus, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "pending":
+		return TxStatusPending, nil
+	case "signed":
+		return TxStatusSigned, nil
+	case "sent":
+		return TxStatusSent, nil
+	case "confirmed":
+		return TxStatusConfirmed, nil
+	case "failed":
+		return TxStatusFailed, nil
+	case "cancelled":
+		return TxStatusCancelled, nil
+	case "unknown":
+		return TxStatusUnknown, nil
+	default:
+		return TxStatusUnknown, fmt.Errorf("unknown transaction status %q", s)
+	}
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// ActorSystem is recorded as the actor of status changes whose caller did not
+// name one.
+const ActorSystem = "system"
+
+// StatusChange is one entry of the append-only status history of a
+// transaction. The first entry of a transaction goes from TxStatusUnknown to
+// its initial status.
+type StatusChange struct {
+	TxID   string    `json:"tx_id" db:"tx_id"`
+	From   TxStatus  `json:"from" db:"from_status"`
+	To     TxStatus  `json:"to" db:"to_status"`
+	Actor  string    `json:"actor" db:"actor"`
+	Reason string    `json:"reason,omitempty" db:"reason"`
+	At     time.Time `json:"at" db:"created_at"`
+}
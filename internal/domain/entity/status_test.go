@@ -43,3 +43,18 @@ func TestTxStatusString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTxStatus(t *testing.T) {
+	for st := TxStatusUnknown; st <= TxStatusCancelled; st++ {
+		got, err := ParseTxStatus(st.String())
+		if err != nil || got != st {
+			t.Errorf("ParseTxStatus(%q) = %v, %v; want %v", st.String(), got, err, st)
+		}
+	}
+	if got, err := ParseTxStatus(" Sent "); err != nil || got != TxStatusSent {
+		t.Errorf("expected case-insensitive match, got %v %v", got, err)
+	}
+	if _, err := ParseTxStatus("mined"); err == nil {
+		t.Errorf("expected error for unknown status text")
+	}
+}
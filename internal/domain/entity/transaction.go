@@ -15,16 +15,37 @@ type Transaction struct {
 	Gas      uint64   `json:"gas" db:"gas"`
 	GasPrice *big.Int `json:"gas_price" db:"gas_price"`
 	// EIP-1559 fields (optional). If set, signer should produce a DynamicFeeTx.
-	MaxPriorityFeePerGas *big.Int  `json:"max_priority_fee_per_gas,omitempty" db:"max_priority_fee_per_gas"`
-	MaxFeePerGas         *big.Int  `json:"max_fee_per_gas,omitempty" db:"max_fee_per_gas"`
-	Nonce                uint64    `json:"nonce" db:"nonce"`
-	Data                 []byte    `json:"data,omitempty" db:"data"`
-	ChainID              *big.Int  `json:"chain_id" db:"chain_id"`
-	RawTxHex             string    `json:"raw_tx_hex,omitempty" db:"raw_tx_hex"`
-	TxHash               string    `json:"tx_hash,omitempty" db:"tx_hash"`
-	Status               TxStatus  `json:"status" db:"status"`
-	CreatedAt            time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty" db:"max_priority_fee_per_gas"`
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty" db:"max_fee_per_gas"`
+	// FeeTier prices the estimated fees; it is not used when the caller set
+	// the fees.
+	FeeTier FeeTier `json:"fee_tier,omitempty" db:"-"`
+	Nonce   uint64  `json:"nonce" db:"nonce"`
+	// FixedNonce marks a transaction created with its Nonce, like a nonce gap
+	// filler. It is signed with that nonce instead of a newly reserved one.
+	FixedNonce bool   `json:"fixed_nonce,omitempty" db:"-"`
+	Data       []byte `json:"data,omitempty" db:"data"`
+	// AccessList (EIP-2930) is optional; on its own it selects an access list tx.
+	AccessList []AccessTuple `json:"access_list,omitempty" db:"access_list"`
+	ChainID    *big.Int      `json:"chain_id" db:"chain_id"`
+	RawTxHex   string        `json:"raw_tx_hex,omitempty" db:"raw_tx_hex"`
+	TxHash     string        `json:"tx_hash,omitempty" db:"tx_hash"`
+	Status     TxStatus      `json:"status" db:"status"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at" db:"updated_at"`
+
+	// IdempotencyKey is the client-chosen key of the request that created the
+	// transaction; no two transactions share one. RequestHash fingerprints
+	// that request so a retry can be told apart from a reused key.
+	IdempotencyKey string `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	RequestHash    string `json:"request_hash,omitempty" db:"request_hash"`
+	// RequestedBy is the Principal.Subject of the API caller that submitted
+	// the transaction, empty when the API is not authenticated.
+	RequestedBy string `json:"requested_by,omitempty" db:"requested_by"`
+
+	// Version is incremented by every write. Repositories reject writes made
+	// against an older version with ports.ErrConflict; zero means not stored.
+	Version int64 `json:"version" db:"version"`
 
 	// Optional lifecycle timestamps
 	SentAt      *time.Time `json:"sent_at,omitempty" db:"sent_at"`
@@ -32,4 +53,109 @@ type Transaction struct {
 
 	// Failure reason
 	ErrorMessage *string `json:"error_message,omitempty" db:"error_message"`
+
+	// Receipt of the mined transaction, stored once it is final.
+	Receipt *Receipt `json:"receipt,omitempty" db:"receipt"`
+
+	// Attempts lists every broadcast of this transaction, oldest first. TxHash
+	// is the hash of the latest attempt until one of them is mined.
+	Attempts []TxAttempt `json:"attempts,omitempty" db:"attempts"`
+}
+
+// AttemptHashes returns the hashes of all attempts, or TxHash when no attempt
+// was recorded.
+func (t *Transaction) AttemptHashes() []string {
+	if len(t.Attempts) == 0 {
+		if t.TxHash == "" {
+			return nil
+		}
+		return []string{t.TxHash}
+	}
+	hashes := make([]string, 0, len(t.Attempts))
+	for _, a := range t.Attempts {
+		hashes = append(hashes, a.TxHash)
+	}
+	return hashes
+}
+
+// Clone returns a deep copy of t that shares no pointers, slices or big.Ints
+// with it.
+func (t *Transaction) Clone() *Transaction {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	if t.To != nil {
+		to := *t.To
+		c.To = &to
+	}
+	c.Value = cloneBig(t.Value)
+	c.GasPrice = cloneBig(t.GasPrice)
+	c.MaxPriorityFeePerGas = cloneBig(t.MaxPriorityFeePerGas)
+	c.MaxFeePerGas = cloneBig(t.MaxFeePerGas)
+	c.ChainID = cloneBig(t.ChainID)
+	c.Data = cloneBytes(t.Data)
+	if t.AccessList != nil {
+		c.AccessList = make([]AccessTuple, len(t.AccessList))
+		for i, a := range t.AccessList {
+			c.AccessList[i] = AccessTuple{Address: a.Address, StorageKeys: append([]string(nil), a.StorageKeys...)}
+		}
+	}
+	c.SentAt = cloneTime(t.SentAt)
+	c.ConfirmedAt = cloneTime(t.ConfirmedAt)
+	if t.ErrorMessage != nil {
+		msg := *t.ErrorMessage
+		c.ErrorMessage = &msg
+	}
+	if t.Receipt != nil {
+		rc := *t.Receipt
+		rc.EffectiveGasPrice = cloneBig(t.Receipt.EffectiveGasPrice)
+		if t.Receipt.Logs != nil {
+			rc.Logs = make([]Log, len(t.Receipt.Logs))
+			for i, l := range t.Receipt.Logs {
+				l.Topics = append([]string(nil), l.Topics...)
+				l.Data = cloneBytes(l.Data)
+				rc.Logs[i] = l
+			}
+		}
+		c.Receipt = &rc
+	}
+	if t.Attempts != nil {
+		c.Attempts = make([]TxAttempt, len(t.Attempts))
+		for i, a := range t.Attempts {
+			a.GasPrice = cloneBig(a.GasPrice)
+			a.MaxPriorityFeePerGas = cloneBig(a.MaxPriorityFeePerGas)
+			a.MaxFeePerGas = cloneBig(a.MaxFeePerGas)
+			c.Attempts[i] = a
+		}
+	}
+	return &c
+}
+
+func cloneBig(b *big.Int) *big.Int {
+	if b == nil {
+		return nil
+	}
+	return new(big.Int).Set(b)
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// AccessTuple is an EIP-2930 access list entry.
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storage_keys"`
 }
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTransactionClone(t *testing.T) {
+	to, msg := "0xto", "boom"
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	orig := &Transaction{
+		ID: "t1", To: &to, Value: big.NewInt(1), GasPrice: big.NewInt(2), MaxFeePerGas: big.NewInt(3),
+		MaxPriorityFeePerGas: big.NewInt(4), ChainID: big.NewInt(5), Data: []byte{1},
+		AccessList: []AccessTuple{{Address: "0xa", StorageKeys: []string{"0x1"}}},
+		SentAt:     &at, ConfirmedAt: &at, ErrorMessage: &msg, Version: 3,
+		Receipt:  &Receipt{EffectiveGasPrice: big.NewInt(6), Logs: []Log{{Topics: []string{"0xt"}, Data: []byte{2}}}},
+		Attempts: []TxAttempt{{TxHash: "0xh", GasPrice: big.NewInt(7)}},
+	}
+	want, _ := json.Marshal(orig)
+
+	c := orig.Clone()
+	if got, _ := json.Marshal(c); string(got) != string(want) {
+		t.Fatalf("clone differs:\n%s\n%s", got, want)
+	}
+
+	*c.To = "0xother"
+	c.Value.SetInt64(100)
+	c.GasPrice.SetInt64(100)
+	c.MaxFeePerGas.SetInt64(100)
+	c.MaxPriorityFeePerGas.SetInt64(100)
+	c.ChainID.SetInt64(100)
+	c.Data[0] = 100
+	c.AccessList[0].StorageKeys[0] = "0x100"
+	*c.SentAt = at.Add(time.Hour)
+	*c.ConfirmedAt = at.Add(time.Hour)
+	*c.ErrorMessage = "other"
+	c.Receipt.EffectiveGasPrice.SetInt64(100)
+	c.Receipt.Logs[0].Topics[0] = "0x100"
+	c.Receipt.Logs[0].Data[0] = 100
+	c.Attempts[0].GasPrice.SetInt64(100)
+	c.Attempts[0].TxHash = "0x100"
+
+	if got, _ := json.Marshal(orig); string(got) != string(want) {
+		t.Fatalf("mutating the clone changed the original:\n%s\n%s", got, want)
+	}
+	var nilTx *Transaction
+	if nilTx.Clone() != nil {
+		t.Fatalf("expected nil clone of nil transaction")
+	}
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition is matched by every InvalidTransitionError.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions is the legal status graph. Sent may move to Sent again when a
+// replacement attempt is broadcast; Confirmed, Failed and Cancelled are
+// terminal. TxStatusUnknown, the status of rows stored before statuses were
+// tracked, may move anywhere.
+var transitions = map[TxStatus][]TxStatus{
+	TxStatusPending: {TxStatusSigned, TxStatusFailed, TxStatusCancelled},
+	TxStatusSigned:  {TxStatusSent, TxStatusFailed},
+	TxStatusSent:    {TxStatusSent, TxStatusConfirmed, TxStatusFailed, TxStatusCancelled},
+}
+
+// InvalidTransitionError reports a status change that the graph forbids.
+type InvalidTransitionError struct {
+	From TxStatus
+	To   TxStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("%s from %s to %s", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// IsTerminal reports whether no transition leaves s.
+func (s TxStatus) IsTerminal() bool {
+	return s == TxStatusConfirmed || s == TxStatusFailed || s == TxStatusCancelled
+}
+
+// CanTransition reports whether a transaction in status from may move to to.
+func CanTransition(from, to TxStatus) bool {
+	if from == TxStatusUnknown {
+		return to != TxStatusUnknown
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns an *InvalidTransitionError when from may not move
+// to to.
+func CheckTransition(from, to TxStatus) error {
+	if !CanTransition(from, to) {
+		return &InvalidTransitionError{From: from, To: to}
+	}
+	return nil
+}
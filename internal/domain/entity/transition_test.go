@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]TxStatus]bool{
+		{TxStatusPending, TxStatusSigned}:    true,
+		{TxStatusPending, TxStatusFailed}:    true,
+		{TxStatusPending, TxStatusCancelled}: true,
+		{TxStatusSigned, TxStatusSent}:       true,
+		{TxStatusSigned, TxStatusFailed}:     true,
+		{TxStatusSent, TxStatusSent}:         true,
+		{TxStatusSent, TxStatusConfirmed}:    true,
+		{TxStatusSent, TxStatusFailed}:       true,
+		{TxStatusSent, TxStatusCancelled}:    true,
+	}
+	all := []TxStatus{TxStatusPending, TxStatusSigned, TxStatusSent, TxStatusConfirmed, TxStatusFailed, TxStatusCancelled}
+	for _, from := range all {
+		for _, to := range append([]TxStatus{TxStatusUnknown}, all...) {
+			if got := CanTransition(from, to); got != allowed[[2]TxStatus{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v", from, to, got)
+			}
+		}
+		if !CanTransition(TxStatusUnknown, from) {
+			t.Errorf("expected unknown to move to %s", from)
+		}
+		if from.IsTerminal() != (len(transitions[from]) == 0) {
+			t.Errorf("IsTerminal(%s) disagrees with the graph", from)
+		}
+	}
+	if CanTransition(TxStatusUnknown, TxStatusUnknown) {
+		t.Errorf("expected unknown to unknown to be rejected")
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	if err := CheckTransition(TxStatusSent, TxStatusConfirmed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := CheckTransition(TxStatusConfirmed, TxStatusFailed)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	var ite *InvalidTransitionError
+	if !errors.As(err, &ite) || ite.From != TxStatusConfirmed || ite.To != TxStatusFailed {
+		t.Fatalf("expected typed error, got %#v", err)
+	}
+	if err.Error() != "invalid status transition from confirmed to failed" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"context"
+)
+
+// APIKeyStore looks up API keys kept outside the configuration file.
+type APIKeyStore interface {
+	// FindAPIKey returns the unrevoked key whose hex SHA-256 is keyHash, or
+	// nil when there is none.
+	FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error)
+}
@@ -3,33 +3,44 @@ package ports
 import (
 	"ChainConnector/internal/domain/entity"
 	"context"
+	"errors"
 	"math/big"
 )
 
-// BlockchainPort provides read-only blockchain operations used by domain.
+// ErrTxRejected is matched (via errors.Is) by broadcast errors meaning the node
+// refused the transaction, e.g. for a used nonce or insufficient funds. Other
+// broadcast errors, such as timeouts, leave open whether the node accepted it.
+var ErrTxRejected = errors.New("transaction rejected by node")
+
+// BlockchainPort provides blockchain operations used by domain. Every method takes the
+// logical chain name (e.g. "ETH", "POLYGON"); an empty chain selects the default chain.
 type BlockchainPort interface {
 	// GetBalance returns the native balance for an address.
-	GetBalance(ctx context.Context, address string) (*big.Int, error)
+	GetBalance(ctx context.Context, chain string, address string) (*big.Int, error)
 
 	// GetNonce returns the pending nonce for an address (for tx creation).
-	GetNonce(ctx context.Context, address string) (uint64, error)
+	GetNonce(ctx context.Context, chain string, address string) (uint64, error)
 
 	// GetTransactionReceipt returns the receipt for a txHash if available.
-	GetTransactionReceipt(ctx context.Context, txHash string) (*entity.Receipt, error)
+	GetTransactionReceipt(ctx context.Context, chain string, txHash string) (*entity.Receipt, error)
 
 	// GetLogs returns logs matching the provided filter (blocks, topics, address).
-	GetLogs(ctx context.Context, f entity.LogFilter) ([]entity.Log, error)
+	GetLogs(ctx context.Context, chain string, f entity.LogFilter) ([]entity.Log, error)
+
+	// GetChainID returns the EIP-155 chain id of the chain.
+	GetChainID(ctx context.Context, chain string) (*big.Int, error)
 
 	// GetBlockNumber returns the latest block number.
-	GetBlockNumber(ctx context.Context) (uint64, error)
+	GetBlockNumber(ctx context.Context, chain string) (uint64, error)
 
 	// EstimateFees returns an estimated priority fee (tip) and max fee (fee cap) in wei for
-	// EIP-1559 transactions. The `chain` parameter may be used by implementations that route
-	// multiple chains. If unsupported, return an error (e.g., ErrUnsupported).
-	EstimateFees(ctx context.Context, chain string) (*big.Int, *big.Int, error)
+	// EIP-1559 transactions, priced for tier. On chains without EIP-1559 the tip is nil and
+	// the fee cap holds the legacy gas price. If unsupported, return an error (e.g., ErrUnsupported).
+	EstimateFees(ctx context.Context, chain string, tier entity.FeeTier) (*big.Int, *big.Int, error)
 
 	// SendRawTransaction sends a fully-signed transaction bytes to the node for the given chain.
-	// Returns the transaction hash (hex, with 0x) or an error.
+	// Returns the transaction hash (hex, with 0x) or an error, matching ErrTxRejected when the
+	// node refused the transaction.
 	SendRawTransaction(ctx context.Context, chain string, signedTx []byte) (txHash string, err error)
 
 	// Optional: convenience when you already have hex-encoded signed tx.
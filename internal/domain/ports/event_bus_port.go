@@ -1,11 +1,20 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrBusFull is returned by Publish when the bus cannot take another event.
+// The event was not delivered to any handler and may be published again
+// later.
+var ErrBusFull = errors.New("event bus is full")
 
 type EventHandler func(ctx context.Context, payload interface{}) error
 
+// EventBus delivers domain events, published with their Type() as topic.
 type EventBus interface {
-	Publish(ctx context.Context, topic string, payload interface{})
+	Publish(ctx context.Context, topic string, payload interface{}) error
 	Subscribe(topic string, handler EventHandler) func()
 	Close() error
 }
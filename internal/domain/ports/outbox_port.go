@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxMessage is an event stored by a repository write and waiting to be
+// published on the EventBus.
+type OutboxMessage struct {
+	ID int64
+	// Topic is the entity.Event.Type of the event.
+	Topic string
+	// Payload is the JSON encoding of the event; entity.DecodeEvent
+	// restores it.
+	Payload []byte
+	// Attempts counts the claims of the message, including the current one.
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// OutboxPort gives the relay access to the events appended by
+// TxRepositoryPort writes. Delivery is at least once: a message is published
+// again when its claim expires before MarkPublished is called.
+type OutboxPort interface {
+	// Claim leases up to limit unpublished messages, oldest first. Leased
+	// messages are not returned again until lease has passed.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	// MarkPublished removes the message from the unpublished set.
+	MarkPublished(ctx context.Context, id int64) error
+	// MarkFailed records why the message could not be published. It is
+	// retried once its lease expires.
+	MarkFailed(ctx context.Context, id int64, reason string) error
+}
@@ -3,12 +3,96 @@ package ports
 import (
 	"ChainConnector/internal/domain/entity"
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrConflict is returned by Save and UpdateStatus when the stored transaction
+// is not at the version the write was based on.
+var ErrConflict = errors.New("transaction version conflict")
+
+// UpdateMeta is what UpdateStatus records besides the column updates.
+type UpdateMeta struct {
+	// Actor names who or what changed the status; empty records
+	// entity.ActorSystem.
+	Actor string
+	// Reason explains the change.
+	Reason string
+	// Version is the Transaction.Version the update is based on. When it is
+	// not zero, UpdateStatus fails with ErrConflict if the stored version
+	// differs.
+	Version int64
+	// Events are appended to the outbox together with the update.
+	Events []entity.Event
+}
+
+// ErrDuplicateIdempotencyKey is returned by Save when another transaction
+// already has tx.IdempotencyKey.
+var ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+
+// ErrInvalidCursor is returned by List for a TxFilter.Cursor it did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// List page sizes: a TxFilter.Limit of zero selects DefaultListLimit and
+// larger limits are capped at MaxListLimit.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// TxFilter selects the transactions returned by List. Zero fields match
+// everything; Chain, From and To are compared case-insensitively.
+type TxFilter struct {
+	// Statuses matches any of the given statuses.
+	Statuses []entity.TxStatus
+	Chain    string
+	From     string
+	To       string
+	// CreatedAfter is inclusive and CreatedBefore exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Limit         int
+	// Cursor is the TxPage.NextCursor of the previous page.
+	Cursor string
+}
+
+// TxPage is one page of List results, newest first. NextCursor is empty on
+// the last page.
+type TxPage struct {
+	Transactions []*entity.Transaction
+	NextCursor   string
+}
+
 type TxRepositoryPort interface {
-	Save(ctx context.Context, tx *entity.Transaction) error
+	// Save inserts tx when tx.Version is zero and otherwise overwrites the
+	// stored row at that version, then sets tx.Version to the stored version.
+	// Inserting an existing id or overwriting another version fails with
+	// ErrConflict, and storing the IdempotencyKey of another transaction
+	// with ErrDuplicateIdempotencyKey. events are appended to the outbox in
+	// the same write, so they are published if and only if tx is stored.
+	Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error
 	FindByID(ctx context.Context, id string) (*entity.Transaction, error)
+	// FindByHash matches the current hash and the hash of any attempt.
 	FindByHash(ctx context.Context, hash string) (*entity.Transaction, error)
-	UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}) error
+	// FindByIdempotencyKey returns nil when no transaction has key.
+	FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error)
+	// UpdateStatus sets the status of txID and applies updates, keyed by the
+	// `db` column name of the Transaction field: "from", "nonce", "gas",
+	// "chain_id", "gas_price", "max_fee_per_gas", "max_priority_fee_per_gas",
+	// "raw_tx_hex", "tx_hash", "sent_at", "confirmed_at", "error_message",
+	// "receipt" and "attempts" (which replaces the whole list).
+	// A StatusChange is appended to the history when the status changes or
+	// meta.Reason is set; Save appends one when it stores a new status.
+	// Both return an *entity.InvalidTransitionError for a move the status
+	// graph forbids. Every successful write increments the version.
+	UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta UpdateMeta) error
+	// ListPending and ListByStatus return the oldest transactions first.
 	ListPending(ctx context.Context, limit int) ([]*entity.Transaction, error)
+	// ListByStatus returns up to limit transactions in status (all when limit <= 0).
+	ListByStatus(ctx context.Context, status entity.TxStatus, limit int) ([]*entity.Transaction, error)
+	// List returns the transactions matching filter ordered by creation time
+	// and id, newest first, one page at a time.
+	List(ctx context.Context, filter TxFilter) (TxPage, error)
+	// History returns the status changes of txID, oldest first.
+	History(ctx context.Context, txID string) ([]entity.StatusChange, error)
 }
@@ -7,8 +7,9 @@ import (
 
 // WalletSignerPort abstracts signing operations. Domain asks for signature, not key management.
 type WalletSignerPort interface {
-	// SignTransaction signs the domain Transaction and returns raw signed bytes (RLP).
-	SignTransaction(ctx context.Context, tx *entity.Transaction) ([]byte, error)
+	// SignTransaction signs the domain Transaction and returns raw signed bytes (RLP or
+	// EIP-2718 typed envelope) together with the transaction hash (hex, with 0x).
+	SignTransaction(ctx context.Context, tx *entity.Transaction) (raw []byte, txHash string, err error)
 
 	// Address returns the address controlled by this signer (for tx.From or metadata).
 	Address(ctx context.Context) (string, error)
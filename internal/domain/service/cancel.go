@@ -0,0 +1,47 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+var ErrNotCancellable = errors.New("transaction cannot be cancelled")
+
+// Cancel pulls back a transaction. A pending transaction is cancelled right
+// away. A sent one is replaced by a zero-value self-transfer at the same nonce
+// with bumped fees; it stays sent until the tracker sees which attempt is
+// mined and marks it cancelled if the replacement won. It returns the
+// transaction as stored after the call.
+func (s *TransactionService) Cancel(ctx context.Context, txID string) (*entity.Transaction, error) {
+	tx, err := s.repo.FindByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	switch tx.Status {
+	case entity.TxStatusPending:
+		if err := setStatus(ctx, s.repo, tx, entity.TxStatusCancelled, nil,
+			audit(actorAPI, "cancelled before signing", entity.TxCancelledEvent{BaseEvent: now(), TxID: tx.ID})); err != nil {
+			return nil, err
+		}
+		s.logger.Info("transaction cancelled", zap.String("tx_id", tx.ID))
+	case entity.TxStatusSent:
+		if s.signer == nil {
+			return nil, ErrSignerUnavailable
+		}
+		if err := s.replace(ctx, tx, true); err != nil {
+			return nil, err
+		}
+	default:
+		// signed transactions are mid-broadcast; once sent they can be replaced
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotCancellable, tx.ID, tx.Status)
+	}
+	return s.repo.FindByID(ctx, tx.ID)
+}
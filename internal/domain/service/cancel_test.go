@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ChainConnector/internal/domain/entity"
+
+	"go.uber.org/zap"
+)
+
+func TestCancel_pending(t *testing.T) {
+	svc, _, chain, _, bus := newSignAndSendFixture(pendingTx("t1"))
+	got, err := svc.Cancel(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != entity.TxStatusCancelled || len(chain.sent) != 0 {
+		t.Fatalf("expected cancelled without broadcast, got %v", got.Status)
+	}
+	if len(bus.topics) != 1 || bus.topics[0] != "TxCancelled" {
+		t.Fatalf("expected TxCancelled event, got %v", bus.topics)
+	}
+}
+
+func TestCancel_sentBroadcastsSelfTransfer(t *testing.T) {
+	to := "0xpayee"
+	tx := &entity.Transaction{
+		ID: "t1", Chain: "ETH", From: "0xSigner", To: &to, Value: big.NewInt(1000), Data: []byte{1},
+		Gas: 90000, Nonce: 4, TxHash: "0xsent", MaxFeePerGas: big.NewInt(50), MaxPriorityFeePerGas: big.NewInt(2),
+		Status: entity.TxStatusSent,
+	}
+	svc, repo, chain, signer, _ := newSignAndSendFixture(tx)
+	chain.sendHash = "0xcancel"
+
+	got, err := svc.Cancel(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != entity.TxStatusSent || got.TxHash != "0xcancel" {
+		t.Fatalf("expected tx to stay sent under the cancel hash, got %v %s", got.Status, got.TxHash)
+	}
+	c := signer.signed[0]
+	if *c.To != "0xSigner" || c.Value.Sign() != 0 || c.Data != nil || c.Gas != transferGas || c.Nonce != 4 {
+		t.Fatalf("unexpected cancel payload: %+v", c)
+	}
+	if c.MaxFeePerGas.Int64() != 55 || c.MaxPriorityFeePerGas.Int64() != 3 {
+		t.Fatalf("expected bumped fees, got %v %v", c.MaxFeePerGas, c.MaxPriorityFeePerGas)
+	}
+	attempts := repo.updates["t1"][0]["attempts"].([]entity.TxAttempt)
+	if len(attempts) != 2 || attempts[0].Cancel || !attempts[1].Cancel {
+		t.Fatalf("expected cancel attempt after the original, got %+v", attempts)
+	}
+	if repo.metas["t1"][0].Actor != actorAPI {
+		t.Fatalf("expected cancel to be attributed to the api, got %v", repo.updates["t1"][0])
+	}
+
+	// speeding up a cancellation keeps the cancel payload
+	tx.Attempts = attempts
+	chain.sendHash = "0xcancel2"
+	if err := svc.SpeedUp(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c := signer.signed[1]; *c.To != "0xSigner" || c.Value.Sign() != 0 {
+		t.Fatalf("expected sped up cancel to stay a self-transfer, got %+v", c)
+	}
+	// each replacement is stored before and after its broadcast
+	if !repo.updates["t1"][3]["attempts"].([]entity.TxAttempt)[2].Cancel {
+		t.Fatalf("expected sped up attempt to be a cancel")
+	}
+}
+
+func TestCancel_errors(t *testing.T) {
+	ctx := context.Background()
+	tx := pendingTx("t1")
+	svc, _, chain, _, _ := newSignAndSendFixture(tx)
+
+	if _, err := svc.Cancel(ctx, "missing"); err == nil {
+		t.Fatalf("expected lookup error")
+	}
+	for _, status := range []entity.TxStatus{entity.TxStatusSigned, entity.TxStatusConfirmed, entity.TxStatusCancelled} {
+		tx.Status = status
+		if _, err := svc.Cancel(ctx, "t1"); !errors.Is(err, ErrNotCancellable) {
+			t.Fatalf("%v: expected ErrNotCancellable, got %v", status, err)
+		}
+	}
+
+	tx.Status, tx.TxHash = entity.TxStatusSent, "0xsent"
+	chain.sendErr = rejected("replacement transaction underpriced")
+	if _, err := svc.Cancel(ctx, "t1"); err == nil {
+		t.Fatalf("expected broadcast error")
+	}
+	noSigner := NewTransactionService(&mockRepo{byID: map[string]*entity.Transaction{"t1": tx}}, chain, nil, zap.NewNop())
+	if _, err := noSigner.Cancel(ctx, "t1"); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("expected ErrSignerUnavailable, got %v", err)
+	}
+}
+
+func TestConfirmationTracker_cancelMined(t *testing.T) {
+	tx := sentTx("t1", "ETH", "0xc")
+	tx.Attempts = []entity.TxAttempt{{TxHash: "0xo"}, {TxHash: "0xc", Cancel: true}}
+	tracker, _, chain, bus := newTrackerFixture(tx)
+	chain.receipts["0xc"] = &entity.Receipt{BlockNumber: 5, Status: entity.ReceiptStatusSuccess}
+	chain.heads["ETH"] = 10
+
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Status != entity.TxStatusCancelled {
+		t.Fatalf("expected cancelled, got %v", tx.Status)
+	}
+	if evt, ok := bus.events[0].(entity.TxCancelledEvent); !ok || evt.TxHash != "0xc" {
+		t.Fatalf("expected TxCancelled event, got %+v", bus.events[0])
+	}
+}
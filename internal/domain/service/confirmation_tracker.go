@@ -0,0 +1,170 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ConfirmationTracker polls receipts of sent transactions and finalises them
+// once they are buried under the confirmation depth required by their chain.
+type ConfirmationTracker struct {
+	repo   ports.TxRepositoryPort
+	chain  ports.BlockchainPort
+	logger *zap.Logger
+
+	confirmations        map[string]uint64
+	defaultConfirmations uint64
+	batchSize            int
+}
+
+// NewConfirmationTracker builds a tracker. confirmations maps upper-case chain
+// names to their required depth; transactions without a chain, or on a chain
+// missing from the map, use defaultConfirmations.
+func NewConfirmationTracker(
+	repo ports.TxRepositoryPort,
+	chain ports.BlockchainPort,
+	logger *zap.Logger,
+	confirmations map[string]uint64,
+	defaultConfirmations uint64,
+	batchSize int,
+) *ConfirmationTracker {
+	if defaultConfirmations == 0 {
+		defaultConfirmations = 1
+	}
+	return &ConfirmationTracker{
+		repo:                 repo,
+		chain:                chain,
+		logger:               logger,
+		confirmations:        confirmations,
+		defaultConfirmations: defaultConfirmations,
+		batchSize:            batchSize,
+	}
+}
+
+// Poll checks every sent transaction once, reading them batchSize at a time
+// so a backlog of dropped transactions cannot hide newer ones. Errors for
+// individual transactions are logged and joined so one bad node does not
+// block others.
+func (t *ConfirmationTracker) Poll(ctx context.Context) error {
+	filter := ports.TxFilter{Statuses: []entity.TxStatus{entity.TxStatusSent}, Limit: t.batchSize}
+	heads := map[string]uint64{}
+	var errs []error
+	for {
+		page, err := t.repo.List(ctx, filter)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for _, tx := range page.Transactions {
+			if err := t.check(ctx, tx, heads); err != nil {
+				t.logger.Warn("confirmation check failed", zap.String("tx_id", tx.ID), zap.Error(err))
+				errs = append(errs, err)
+			}
+		}
+		if page.NextCursor == "" || ctx.Err() != nil {
+			return errors.Join(errs...)
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// check finalises tx when the receipt of one of its attempts has enough
+// confirmations. heads caches the latest block per chain for the current poll.
+func (t *ConfirmationTracker) check(ctx context.Context, tx *entity.Transaction, heads map[string]uint64) error {
+	var rc *entity.Receipt
+	var mined string
+	for _, hash := range tx.AttemptHashes() {
+		r, err := t.chain.GetTransactionReceipt(ctx, tx.Chain, hash)
+		if err != nil {
+			return err
+		}
+		if r != nil {
+			rc, mined = r, hash
+			break
+		}
+	}
+	if rc == nil {
+		return nil
+	}
+
+	head, ok := heads[tx.Chain]
+	if !ok {
+		var err error
+		if head, err = t.chain.GetBlockNumber(ctx, tx.Chain); err != nil {
+			return err
+		}
+		heads[tx.Chain] = head
+	}
+	if head < rc.BlockNumber || head-rc.BlockNumber+1 < t.required(tx.Chain) {
+		return nil
+	}
+	return t.finalise(ctx, tx, rc, mined)
+}
+
+// finalise stores rc for the attempt with hash mined, marking every other
+// attempt as replaced. A mined cancel attempt cancels the transaction.
+func (t *ConfirmationTracker) finalise(ctx context.Context, tx *entity.Transaction, rc *entity.Receipt, mined string) error {
+	at := time.Now().UTC()
+	updates := map[string]interface{}{
+		"tx_hash":      mined,
+		"receipt":      rc,
+		"confirmed_at": at,
+	}
+	cancelled := false
+	if len(tx.Attempts) > 0 {
+		attempts := append([]entity.TxAttempt(nil), tx.Attempts...)
+		for i := range attempts {
+			if attempts[i].TxHash == mined {
+				attempts[i].Status = entity.AttemptStatusMined
+				cancelled = attempts[i].Cancel
+			} else {
+				attempts[i].Status = entity.AttemptStatusReplaced
+			}
+		}
+		updates["attempts"] = attempts
+	}
+	if cancelled {
+		if err := setStatus(ctx, t.repo, tx, entity.TxStatusCancelled, updates,
+			audit(actorTracker, fmt.Sprintf("cancel attempt %s mined in block %d", mined, rc.BlockNumber),
+				entity.TxCancelledEvent{BaseEvent: entity.BaseEvent{When: at}, TxID: tx.ID, TxHash: mined})); err != nil {
+			return err
+		}
+		t.logger.Info("transaction cancelled",
+			zap.String("tx_id", tx.ID), zap.String("tx_hash", mined), zap.Uint64("block", rc.BlockNumber))
+		return nil
+	}
+	if rc.Status == entity.ReceiptStatusSuccess {
+		if err := setStatus(ctx, t.repo, tx, entity.TxStatusConfirmed, updates,
+			audit(actorTracker, fmt.Sprintf("%s mined in block %d", mined, rc.BlockNumber),
+				entity.TxConfirmedEvent{BaseEvent: entity.BaseEvent{When: at}, TxID: tx.ID, TxHash: mined, Receipt: *rc})); err != nil {
+			return err
+		}
+		t.logger.Info("transaction confirmed",
+			zap.String("tx_id", tx.ID), zap.String("tx_hash", mined), zap.Uint64("block", rc.BlockNumber))
+		return nil
+	}
+
+	msg := "transaction reverted"
+	updates["error_message"] = msg
+	if err := setStatus(ctx, t.repo, tx, entity.TxStatusFailed, updates,
+		audit(actorTracker, fmt.Sprintf("%s reverted in block %d", mined, rc.BlockNumber),
+			entity.TxFailedEvent{BaseEvent: entity.BaseEvent{When: at}, TxID: tx.ID, Error: msg})); err != nil {
+		return err
+	}
+	t.logger.Info("transaction reverted",
+		zap.String("tx_id", tx.ID), zap.String("tx_hash", mined), zap.Uint64("block", rc.BlockNumber))
+	return nil
+}
+
+func (t *ConfirmationTracker) required(chain string) uint64 {
+	if n, ok := t.confirmations[strings.ToUpper(strings.TrimSpace(chain))]; ok && n > 0 {
+		return n
+	}
+	return t.defaultConfirmations
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ChainConnector/internal/domain/entity"
+
+	"go.uber.org/zap"
+)
+
+func sentTx(id, chain, hash string) *entity.Transaction {
+	return &entity.Transaction{ID: id, Chain: chain, TxHash: hash, Status: entity.TxStatusSent}
+}
+
+func newTrackerFixture(txs ...*entity.Transaction) (*ConfirmationTracker, *mockRepo, *fakeChain, *fakeBus) {
+	bus := &fakeBus{}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{}, outbox: bus}
+	for _, tx := range txs {
+		repo.byID[tx.ID] = tx
+	}
+	chain := &fakeChain{receipts: map[string]*entity.Receipt{}, heads: map[string]uint64{}}
+	tracker := NewConfirmationTracker(repo, chain, zap.NewNop(),
+		map[string]uint64{"ETH": 3, "POLYGON": 1}, 2, 100)
+	return tracker, repo, chain, bus
+}
+
+func TestConfirmationTracker_waitsForDepth(t *testing.T) {
+	tx := sentTx("t1", "ETH", "0xa")
+	tracker, repo, chain, bus := newTrackerFixture(tx)
+	chain.receipts["0xa"] = &entity.Receipt{TxHash: "0xa", BlockNumber: 100, Status: entity.ReceiptStatusSuccess}
+
+	// two confirmations (blocks 100 and 101) out of three
+	chain.heads["ETH"] = 101
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Status != entity.TxStatusSent || len(bus.topics) != 0 {
+		t.Fatalf("tx finalised too early: %v %v", tx.Status, bus.topics)
+	}
+
+	chain.heads["ETH"] = 102
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Status != entity.TxStatusConfirmed {
+		t.Fatalf("expected confirmed, got %v", tx.Status)
+	}
+	upd := repo.updates["t1"][0]
+	if upd["receipt"].(*entity.Receipt).BlockNumber != 100 || upd["confirmed_at"] == nil {
+		t.Fatalf("expected receipt and confirmed_at updates, got %v", upd)
+	}
+	if meta := repo.metas["t1"][0]; meta.Actor != actorTracker || meta.Reason != "0xa mined in block 100" {
+		t.Fatalf("expected tracker audit fields, got %+v", meta)
+	}
+	evt, ok := bus.events[0].(entity.TxConfirmedEvent)
+	if !ok || evt.TxID != "t1" || evt.Receipt.BlockNumber != 100 {
+		t.Fatalf("unexpected event: %+v", bus.events[0])
+	}
+}
+
+func TestConfirmationTracker_revertedAndPerChainDepth(t *testing.T) {
+	poly := sentTx("p1", "polygon", "0xp")
+	other := sentTx("o1", "BASE", "0xo")
+	tracker, repo, chain, bus := newTrackerFixture(poly, other)
+	chain.receipts["0xp"] = &entity.Receipt{BlockNumber: 50, Status: entity.ReceiptStatusFailed}
+	chain.receipts["0xo"] = &entity.Receipt{BlockNumber: 10, Status: entity.ReceiptStatusSuccess}
+	chain.heads["polygon"] = 50 // depth 1 is enough on POLYGON
+	chain.heads["BASE"] = 10    // BASE uses the default depth of 2
+
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if poly.Status != entity.TxStatusFailed {
+		t.Fatalf("expected reverted tx to fail, got %v", poly.Status)
+	}
+	if repo.updates["p1"][0]["error_message"] == nil {
+		t.Fatalf("expected error message for reverted tx")
+	}
+	if other.Status != entity.TxStatusSent {
+		t.Fatalf("expected BASE tx to wait for default depth, got %v", other.Status)
+	}
+	if len(bus.topics) != 1 || bus.topics[0] != "TxFailed" {
+		t.Fatalf("unexpected events: %v", bus.topics)
+	}
+}
+
+func TestConfirmationTracker_skipsAndErrors(t *testing.T) {
+	noHash := sentTx("n1", "ETH", "")
+	unmined := sentTx("u1", "ETH", "0xu")
+	a := sentTx("a1", "ETH", "0xa1")
+	b := sentTx("b1", "ETH", "0xb1")
+	tracker, _, chain, _ := newTrackerFixture(noHash, unmined, a, b)
+	chain.receipts["0xa1"] = &entity.Receipt{BlockNumber: 1, Status: entity.ReceiptStatusSuccess}
+	chain.receipts["0xb1"] = &entity.Receipt{BlockNumber: 1, Status: entity.ReceiptStatusSuccess}
+	chain.heads["ETH"] = 10
+
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chain.headCalls != 1 {
+		t.Fatalf("expected head to be fetched once per chain, got %d", chain.headCalls)
+	}
+	if unmined.Status != entity.TxStatusSent || noHash.Status != entity.TxStatusSent {
+		t.Fatalf("unmined transactions must stay sent")
+	}
+
+	// head lookup failure is reported
+	c := sentTx("c1", "SOLANA", "0xc")
+	tracker, _, chain, _ = newTrackerFixture(c)
+	chain.receipts["0xc"] = &entity.Receipt{BlockNumber: 1}
+	if err := tracker.Poll(context.Background()); err == nil {
+		t.Fatalf("expected error when head is unavailable")
+	}
+
+	chain.receiptErr = errors.New("rpc down")
+	if err := tracker.Poll(context.Background()); err == nil {
+		t.Fatalf("expected receipt error to be reported")
+	}
+
+	failing := NewConfirmationTracker(&repoErr{}, chain, zap.NewNop(), nil, 0, 10)
+	if err := failing.Poll(context.Background()); err == nil {
+		t.Fatalf("expected list error to be returned")
+	}
+	if failing.required("ANY") != 1 {
+		t.Fatalf("expected default depth of 1")
+	}
+}
+
+func TestConfirmationTracker_confirmsMinedAttempt(t *testing.T) {
+	tx := sentTx("t1", "ETH", "0xb")
+	tx.Attempts = []entity.TxAttempt{{TxHash: "0xa"}, {TxHash: "0xb"}}
+	tracker, repo, chain, bus := newTrackerFixture(tx)
+	// the original attempt was mined after its replacement was broadcast
+	chain.receipts["0xa"] = &entity.Receipt{TxHash: "0xa", BlockNumber: 7, Status: entity.ReceiptStatusSuccess}
+	chain.heads["ETH"] = 20
+	read := tx.Attempts
+
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	upd := repo.updates["t1"][0]
+	if upd["tx_hash"] != "0xa" {
+		t.Fatalf("expected mined hash to become the tx hash, got %v", upd["tx_hash"])
+	}
+	attempts := upd["attempts"].([]entity.TxAttempt)
+	if attempts[0].Status != entity.AttemptStatusMined || attempts[1].Status != entity.AttemptStatusReplaced {
+		t.Fatalf("unexpected attempt statuses: %+v", attempts)
+	}
+	if read[0].Status != entity.AttemptStatusPending {
+		t.Fatalf("tracker must not mutate attempts in place")
+	}
+	if evt := bus.events[0].(entity.TxConfirmedEvent); evt.TxHash != "0xa" {
+		t.Fatalf("expected confirmed event for mined hash, got %s", evt.TxHash)
+	}
+}
+
+func TestConfirmationTracker_pagesThroughAllSent(t *testing.T) {
+	// five dropped txs fill the first pages, the mined one sorts last
+	var txs []*entity.Transaction
+	for i := 0; i < 5; i++ {
+		txs = append(txs, sentTx(fmt.Sprintf("dropped-%d", i), "ETH", fmt.Sprintf("0xd%d", i)))
+	}
+	mined := sentTx("mined", "ETH", "0xm")
+	tracker, repo, chain, _ := newTrackerFixture(append(txs, mined)...)
+	tracker.batchSize = 2
+	chain.receipts["0xm"] = &entity.Receipt{TxHash: "0xm", BlockNumber: 10, Status: entity.ReceiptStatusSuccess}
+	chain.heads["ETH"] = 20
+
+	if err := tracker.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mined.Status != entity.TxStatusConfirmed {
+		t.Fatalf("expected the tx past the first batch to be confirmed, got %v", mined.Status)
+	}
+	if repo.lists != 3 || repo.filter.Limit != 2 {
+		t.Fatalf("expected three pages of two, got %d calls with %+v", repo.lists, repo.filter)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("daily value quota exceeded")
+)
+
+// maxBuckets bounds each bucket map: beyond it, full buckets are dropped,
+// which is equivalent to keeping them.
+const maxBuckets = 10000
+
+// LimitError is returned by Limiter for a request over a limit. It wraps
+// ErrRateLimited or ErrQuotaExceeded.
+type LimitError struct {
+	Err error
+	// Limit names the bucket or quota, e.g. "sender 0xabc".
+	Limit string
+	// RetryAfter is how long until the request would be admitted.
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v for %s, retry in %s", e.Err, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// Rate is a token bucket holding Burst requests and refilled at PerSecond.
+// A zero PerSecond disables it.
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+// LimitPolicy configures a Limiter. The zero value admits everything.
+type LimitPolicy struct {
+	// PerKey limits the requests of each API client.
+	PerKey Rate
+	// PerSender limits the submissions of each sender address.
+	PerSender Rate
+	// DailyValue caps the wei each sender may submit per UTC day, by chain.
+	// Chains without an entry are unlimited.
+	DailyValue map[string]*big.Int
+}
+
+// Limiter enforces a LimitPolicy in memory: token buckets per API client and
+// per sender, and daily value quotas per sender and chain. Quotas count the
+// value of accepted submissions, whatever becomes of them, and restart at
+// midnight UTC and when the process restarts.
+type Limiter struct {
+	policy LimitPolicy
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*bucket
+	senders map[string]*bucket
+	day     string // UTC date the spent amounts belong to
+	spent   map[quotaKey]*big.Int
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+type quotaKey struct {
+	chain  string
+	sender string
+}
+
+func NewLimiter(policy LimitPolicy) *Limiter {
+	for _, r := range []*Rate{&policy.PerKey, &policy.PerSender} {
+		r.Burst = max(r.Burst, 1)
+	}
+	return &Limiter{
+		policy:  policy,
+		now:     time.Now,
+		keys:    map[string]*bucket{},
+		senders: map[string]*bucket{},
+		spent:   map[quotaKey]*big.Int{},
+	}
+}
+
+// AllowKey takes a request of the API client key from its bucket.
+func (l *Limiter) AllowKey(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if wait := take(l.keys, l.policy.PerKey, key, l.now()); wait > 0 {
+		return &LimitError{Err: ErrRateLimited, Limit: "key " + key, RetryAfter: wait}
+	}
+	return nil
+}
+
+// Reserve admits a submission of value by sender on chain: it takes a token
+// from the sender's bucket and counts value against the sender's quota for
+// the day. release gives the value back when the submission is dropped.
+func (l *Limiter) Reserve(sender, chain string, value *big.Int) (release func(), err error) {
+	sender = strings.ToLower(sender)
+	if value == nil {
+		value = new(big.Int)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.rollover(now)
+
+	key := quotaKey{chain: chain, sender: sender}
+	limit := l.policy.DailyValue[chain]
+	var total *big.Int
+	if limit != nil {
+		total = new(big.Int).Add(l.spentOf(key), value)
+		if total.Cmp(limit) > 0 {
+			return nil, &LimitError{Err: ErrQuotaExceeded, Limit: fmt.Sprintf("sender %s on %s", sender, chain),
+				RetryAfter: untilMidnight(now)}
+		}
+	}
+	if wait := take(l.senders, l.policy.PerSender, sender, now); wait > 0 {
+		return nil, &LimitError{Err: ErrRateLimited, Limit: "sender " + sender, RetryAfter: wait}
+	}
+	if total == nil {
+		return func() {}, nil
+	}
+	l.spent[key] = total
+	day := l.day
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if spent := l.spent[key]; spent != nil && l.day == day {
+			spent.Sub(spent, value)
+		}
+	}, nil
+}
+
+// BucketUsage is the state of one token bucket.
+type BucketUsage struct {
+	Key    string
+	Tokens float64
+}
+
+// QuotaUsage is the value a sender submitted on a chain today.
+type QuotaUsage struct {
+	Chain  string
+	Sender string
+	Day    string
+	Spent  *big.Int
+	Limit  *big.Int
+}
+
+// LimitUsage reports the policy and every bucket that is not full and every
+// quota used today, sorted by key.
+type LimitUsage struct {
+	PerKey    Rate
+	PerSender Rate
+	Keys      []BucketUsage
+	Senders   []BucketUsage
+	Quotas    []QuotaUsage
+}
+
+// Usage returns the current usage of the limits.
+func (l *Limiter) Usage() LimitUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.rollover(now)
+	u := LimitUsage{
+		PerKey:    l.policy.PerKey,
+		PerSender: l.policy.PerSender,
+		Keys:      usage(l.keys, l.policy.PerKey, now),
+		Senders:   usage(l.senders, l.policy.PerSender, now),
+		Quotas:    []QuotaUsage{},
+	}
+	for key, spent := range l.spent {
+		u.Quotas = append(u.Quotas, QuotaUsage{
+			Chain:  key.chain,
+			Sender: key.sender,
+			Day:    l.day,
+			Spent:  new(big.Int).Set(spent),
+			Limit:  l.policy.DailyValue[key.chain],
+		})
+	}
+	sort.Slice(u.Quotas, func(i, j int) bool {
+		a, b := u.Quotas[i], u.Quotas[j]
+		return a.Chain < b.Chain || (a.Chain == b.Chain && a.Sender < b.Sender)
+	})
+	return u
+}
+
+// rollover starts a new day of quotas at midnight UTC.
+func (l *Limiter) rollover(now time.Time) {
+	if day := now.UTC().Format(time.DateOnly); day != l.day {
+		l.day = day
+		l.spent = map[quotaKey]*big.Int{}
+	}
+}
+
+func (l *Limiter) spentOf(key quotaKey) *big.Int {
+	if spent := l.spent[key]; spent != nil {
+		return spent
+	}
+	return new(big.Int)
+}
+
+// take removes a token from the bucket of key, refilled up to now, and
+// returns how long until one is available when the bucket is empty.
+func take(buckets map[string]*bucket, rate Rate, key string, now time.Time) time.Duration {
+	if rate.PerSecond <= 0 {
+		return 0
+	}
+	b := buckets[key]
+	if b == nil {
+		if len(buckets) >= maxBuckets {
+			prune(buckets, rate, now)
+		}
+		b = &bucket{tokens: float64(rate.Burst), at: now}
+		buckets[key] = b
+	}
+	refill(b, rate, now)
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate.PerSecond * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+func refill(b *bucket, rate Rate, now time.Time) {
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens = min(float64(rate.Burst), b.tokens+elapsed.Seconds()*rate.PerSecond)
+		b.at = now
+	}
+}
+
+// prune drops the buckets that refilled completely.
+func prune(buckets map[string]*bucket, rate Rate, now time.Time) {
+	for key, b := range buckets {
+		if refill(b, rate, now); b.tokens >= float64(rate.Burst) {
+			delete(buckets, key)
+		}
+	}
+}
+
+func usage(buckets map[string]*bucket, rate Rate, now time.Time) []BucketUsage {
+	prune(buckets, rate, now)
+	out := make([]BucketUsage, 0, len(buckets))
+	for key, b := range buckets {
+		out = append(out, BucketUsage{Key: key, Tokens: b.tokens})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// untilMidnight is the time left until the next UTC day.
+func untilMidnight(now time.Time) time.Duration {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(policy LimitPolicy, now *time.Time) *Limiter {
+	l := NewLimiter(policy)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiter_AllowKey(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(LimitPolicy{PerKey: Rate{PerSecond: 2, Burst: 3}}, &now)
+
+	for i := 0; i < 3; i++ {
+		if err := l.AllowKey("a"); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	err := l.AllowKey("a")
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) || limitErr.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected to retry after one token refills, got %v", err)
+	}
+	if err := l.AllowKey("b"); err != nil {
+		t.Fatalf("expected keys to have their own buckets, got %v", err)
+	}
+
+	now = now.Add(time.Second) // two tokens back
+	for i := 0; i < 2; i++ {
+		if err := l.AllowKey("a"); err != nil {
+			t.Fatalf("refilled request %d: unexpected error %v", i, err)
+		}
+	}
+	if err := l.AllowKey("a"); err == nil {
+		t.Fatalf("expected the bucket to be empty again")
+	}
+
+	unlimited := NewLimiter(LimitPolicy{})
+	for i := 0; i < 100; i++ {
+		if err := unlimited.AllowKey("a"); err != nil {
+			t.Fatalf("expected a zero policy to admit everything, got %v", err)
+		}
+	}
+}
+
+func TestLimiter_ReserveQuota(t *testing.T) {
+	now := time.Date(2026, 1, 2, 18, 0, 0, 0, time.UTC)
+	l := newTestLimiter(LimitPolicy{
+		PerSender:  Rate{PerSecond: 1, Burst: 10},
+		DailyValue: map[string]*big.Int{"ETH": big.NewInt(100)},
+	}, &now)
+
+	if _, err := l.Reserve("0xAB", "ETH", big.NewInt(60)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release, err := l.Reserve("0xab", "ETH", big.NewInt(40))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = l.Reserve("0xab", "ETH", big.NewInt(1))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrQuotaExceeded) || limitErr.RetryAfter != 6*time.Hour {
+		t.Fatalf("expected the quota to be exhausted until midnight, got %v", err)
+	}
+	if _, err := l.Reserve("0xab", "POLYGON", big.NewInt(1000)); err != nil {
+		t.Fatalf("expected chains without a quota to be unlimited, got %v", err)
+	}
+
+	release()
+	if _, err := l.Reserve("0xab", "ETH", big.NewInt(40)); err != nil {
+		t.Fatalf("expected released value to be available again, got %v", err)
+	}
+
+	u := l.Usage()
+	if len(u.Quotas) != 1 || u.Quotas[0].Sender != "0xab" || u.Quotas[0].Spent.Int64() != 100 || u.Quotas[0].Day != "2026-01-02" {
+		t.Fatalf("unexpected quota usage %+v", u.Quotas)
+	}
+	if len(u.Senders) != 1 || u.Senders[0].Key != "0xab" || u.Senders[0].Tokens != 6 {
+		t.Fatalf("unexpected sender usage %+v", u.Senders)
+	}
+
+	now = now.Add(6 * time.Hour)
+	if _, err := l.Reserve("0xab", "ETH", big.NewInt(100)); err != nil {
+		t.Fatalf("expected a new quota after midnight, got %v", err)
+	}
+}
+
+func TestLimiter_ReserveRate(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(LimitPolicy{
+		PerSender:  Rate{PerSecond: 0.5},
+		DailyValue: map[string]*big.Int{"ETH": big.NewInt(100)},
+	}, &now)
+
+	if _, err := l.Reserve("0xab", "ETH", big.NewInt(10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := l.Reserve("0xab", "ETH", big.NewInt(10))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) || limitErr.RetryAfter != 2*time.Second {
+		t.Fatalf("expected the sender to be rate limited, got %v", err)
+	}
+	// a rejected submission does not count against the quota
+	if u := l.Usage(); u.Quotas[0].Spent.Int64() != 10 {
+		t.Fatalf("expected 10 spent, got %v", u.Quotas[0].Spent)
+	}
+}
+
+func TestLimiter_PrunesFullBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(LimitPolicy{PerKey: Rate{PerSecond: 1, Burst: 1}}, &now)
+	for i := 0; i < maxBuckets; i++ {
+		_ = l.AllowKey(strconv.Itoa(i))
+	}
+	now = now.Add(time.Second)
+	_ = l.AllowKey("new")
+	if len(l.keys) != 1 {
+		t.Fatalf("expected refilled buckets to be dropped, got %d", len(l.keys))
+	}
+}
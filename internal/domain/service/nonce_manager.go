@@ -0,0 +1,300 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// NonceManager hands out nonces per (chain, address) so concurrent sends from
+// the same sender never reuse a nonce. Each sender is synced lazily from the
+// node's pending nonce and the repository's signed and sent transactions, and
+// resynced after Reset. A nonce handed out stays in flight until Release, so
+// the reconciler does not mistake it for a gap before its tx is stored.
+type NonceManager struct {
+	repo  ports.TxRepositoryPort
+	chain ports.BlockchainPort
+
+	mu      sync.Mutex
+	senders map[nonceKey]*nonceSlot
+}
+
+type nonceKey struct {
+	chain   string
+	address string
+}
+
+type nonceSlot struct {
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	inFlight map[uint64]bool
+}
+
+func NewNonceManager(repo ports.TxRepositoryPort, chain ports.BlockchainPort) *NonceManager {
+	return &NonceManager{
+		repo:    repo,
+		chain:   chain,
+		senders: map[nonceKey]*nonceSlot{},
+	}
+}
+
+// Next reserves and returns the next nonce for address on chain. The nonce is
+// in flight until it is released.
+func (m *NonceManager) Next(ctx context.Context, chain, address string) (uint64, error) {
+	slot := m.slot(chain, address)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if !slot.synced {
+		next, err := m.sync(ctx, chain, address)
+		if err != nil {
+			return 0, err
+		}
+		slot.next, slot.synced = next, true
+	}
+	n := slot.next
+	slot.next++
+	slot.inFlight[n] = true
+	return n, nil
+}
+
+// Release ends the flight of a nonce returned by Next, once its transaction
+// was stored as sent or failed.
+func (m *NonceManager) Release(chain, address string, nonce uint64) {
+	slot := m.slot(chain, address)
+	slot.mu.Lock()
+	delete(slot.inFlight, nonce)
+	slot.mu.Unlock()
+}
+
+// InFlight reports whether nonce was handed out for address on chain and not
+// released yet.
+func (m *NonceManager) InFlight(chain, address string, nonce uint64) bool {
+	slot := m.slot(chain, address)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	return slot.inFlight[nonce]
+}
+
+// Observe raises the next nonce for address to at least next, e.g. after the
+// node reports a higher pending nonce than handed out so far.
+func (m *NonceManager) Observe(chain, address string, next uint64) {
+	slot := m.slot(chain, address)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if slot.synced && next > slot.next {
+		slot.next = next
+	}
+}
+
+// Reset forgets the local counter so the next call to Next resyncs with the
+// node. It is called when a reserved nonce may not have reached the network.
+func (m *NonceManager) Reset(chain, address string) {
+	slot := m.slot(chain, address)
+	slot.mu.Lock()
+	slot.synced = false
+	slot.mu.Unlock()
+}
+
+func (m *NonceManager) slot(chain, address string) *nonceSlot {
+	key := nonceKey{chain: strings.ToUpper(chain), address: strings.ToLower(address)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	slot, ok := m.senders[key]
+	if !ok {
+		slot = &nonceSlot{inFlight: map[uint64]bool{}}
+		m.senders[key] = slot
+	}
+	return slot
+}
+
+// sync returns the higher of the node's pending nonce and one past the
+// highest nonce of the sender's signed or sent transactions.
+func (m *NonceManager) sync(ctx context.Context, chain, address string) (uint64, error) {
+	next, err := m.chain.GetNonce(ctx, chain, address)
+	if err != nil {
+		return 0, err
+	}
+	for _, status := range []entity.TxStatus{entity.TxStatusSigned, entity.TxStatusSent} {
+		txs, err := m.repo.ListByStatus(ctx, status, 0)
+		if err != nil {
+			return 0, err
+		}
+		for _, tx := range txs {
+			if sameSender(tx, chain, address) && tx.Nonce+1 > next {
+				next = tx.Nonce + 1
+			}
+		}
+	}
+	return next, nil
+}
+
+func sameSender(tx *entity.Transaction, chain, address string) bool {
+	return strings.EqualFold(tx.Chain, chain) && strings.EqualFold(tx.From, address)
+}
+
+// ReconcileNonces compares the pending nonce the node reports for every
+// sender with sent transactions against the repository. Sent transactions the
+// node no longer knows about are rebroadcast and nonces missing below them,
+// left by dropped or failed transactions, are filled with zero-value
+// self-transfers so the later ones can be mined. A nonce is not missing while
+// a pending or signed transaction holds it or the NonceManager has it in
+// flight; a signed transaction no send is working on any more is broadcast
+// and recorded as sent.
+func (s *TransactionService) ReconcileNonces(ctx context.Context) error {
+	sent, err := s.repo.ListByStatus(ctx, entity.TxStatusSent, 0)
+	if err != nil {
+		return err
+	}
+	groups := map[nonceKey][]*entity.Transaction{}
+	for _, tx := range sent {
+		key := nonceKey{chain: strings.ToUpper(tx.Chain), address: strings.ToLower(tx.From)}
+		groups[key] = append(groups[key], tx)
+	}
+	// pending transactions get their nonce when they are signed, unless it
+	// was fixed when they were created, like gap fillers
+	held := map[nonceKey]map[uint64]*entity.Transaction{}
+	for _, status := range []entity.TxStatus{entity.TxStatusPending, entity.TxStatusSigned} {
+		txs, err := s.repo.ListByStatus(ctx, status, 0)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if tx.From == "" || (status == entity.TxStatusPending && !tx.FixedNonce) {
+				continue
+			}
+			key := nonceKey{chain: strings.ToUpper(tx.Chain), address: strings.ToLower(tx.From)}
+			if held[key] == nil {
+				held[key] = map[uint64]*entity.Transaction{}
+			}
+			held[key][tx.Nonce] = tx
+		}
+	}
+
+	var errs []error
+	for key, txs := range groups {
+		chain, from := txs[0].Chain, txs[0].From
+		if err := s.reconcileSender(ctx, chain, from, txs, held[key]); err != nil {
+			s.logger.Warn("nonce reconciliation failed",
+				zap.String("chain", chain), zap.String("from", from), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TransactionService) reconcileSender(ctx context.Context, chain, from string, txs []*entity.Transaction, held map[uint64]*entity.Transaction) error {
+	pending, err := s.chain.GetNonce(ctx, chain, from)
+	if err != nil {
+		return err
+	}
+	unknown := map[uint64]*entity.Transaction{}
+	highest := pending
+	for _, tx := range txs {
+		if tx.Nonce >= pending {
+			unknown[tx.Nonce] = tx
+			if tx.Nonce+1 > highest {
+				highest = tx.Nonce + 1
+			}
+		}
+	}
+
+	var errs []error
+	for n := pending; n < highest; n++ {
+		if tx, ok := unknown[n]; ok {
+			errs = append(errs, s.rebroadcast(ctx, tx))
+			continue
+		}
+		if s.nonces.InFlight(chain, from, n) {
+			continue
+		}
+		if tx, ok := held[n]; ok {
+			if tx.Status == entity.TxStatusSigned {
+				errs = append(errs, s.resumeSigned(ctx, tx))
+			}
+			continue
+		}
+		errs = append(errs, s.fillNonceGap(ctx, chain, from, n))
+	}
+	s.nonces.Observe(chain, from, highest)
+	return errors.Join(errs...)
+}
+
+// rebroadcast re-queues the signed bytes of a sent transaction.
+func (s *TransactionService) rebroadcast(ctx context.Context, tx *entity.Transaction) error {
+	raw, err := hex.DecodeString(strings.TrimPrefix(tx.RawTxHex, "0x"))
+	if err != nil || len(raw) == 0 {
+		return fmt.Errorf("rebroadcast %s: no signed payload", tx.ID)
+	}
+	if _, err := s.chain.SendRawTransaction(ctx, tx.Chain, raw); err != nil && !alreadyKnown(err) {
+		return fmt.Errorf("rebroadcast %s: %w", tx.ID, err)
+	}
+	s.logger.Info("rebroadcast dropped transaction",
+		zap.String("tx_id", tx.ID), zap.Uint64("nonce", tx.Nonce), zap.String("tx_hash", tx.TxHash))
+	return nil
+}
+
+// resumeSigned broadcasts a signed transaction whose send stopped before it
+// was recorded as sent, e.g. on a restart, and records it as sent.
+func (s *TransactionService) resumeSigned(ctx context.Context, tx *entity.Transaction) error {
+	if err := s.rebroadcast(ctx, tx); err != nil {
+		return err
+	}
+	sentAt := time.Now().UTC()
+	return setStatus(ctx, s.repo, tx, entity.TxStatusSent, map[string]interface{}{
+		"sent_at":  sentAt,
+		"attempts": []entity.TxAttempt{newAttempt(tx, tx.TxHash, tx.RawTxHex, sentAt)},
+	}, audit(actorSigner, "resumed broadcast "+tx.TxHash,
+		entity.TxSentEvent{BaseEvent: entity.BaseEvent{When: sentAt}, TxID: tx.ID, TxHash: tx.TxHash}))
+}
+
+// fillNonceGap sends a zero-value self-transfer with nonce from the signer's
+// own address.
+func (s *TransactionService) fillNonceGap(ctx context.Context, chain, from string, nonce uint64) error {
+	if s.signer == nil {
+		return ErrSignerUnavailable
+	}
+	addr, err := s.signer.Address(ctx)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(addr, from) {
+		return fmt.Errorf("nonce gap %d for %s: sender is not the signer address", nonce, from)
+	}
+	to := from
+	filler := &entity.Transaction{
+		ID:         uuid.NewString(),
+		Chain:      chain,
+		From:       from,
+		To:         &to,
+		Value:      new(big.Int),
+		Gas:        transferGas,
+		Nonce:      nonce,
+		FixedNonce: true,
+		Status:     entity.TxStatusPending,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.repo.Save(ctx, filler); err != nil {
+		return err
+	}
+	s.logger.Info("filling nonce gap",
+		zap.String("chain", chain), zap.String("from", from), zap.Uint64("nonce", nonce), zap.String("tx_id", filler.ID))
+	return s.signAndSend(ctx, filler)
+}
+
+// alreadyKnown reports whether a broadcast error means the node already has
+// the transaction.
+func alreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
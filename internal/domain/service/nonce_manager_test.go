@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ChainConnector/internal/domain/entity"
+
+	"go.uber.org/zap"
+)
+
+func sentFrom(id, from string, nonce uint64, raw string) *entity.Transaction {
+	return &entity.Transaction{ID: id, Chain: "ETH", From: from, Nonce: nonce, RawTxHex: raw, TxHash: "0x" + id, Status: entity.TxStatusSent}
+}
+
+func TestNonceManager_NextIsUniqueUnderConcurrency(t *testing.T) {
+	nm := NewNonceManager(&mockRepo{}, &fakeChain{nonce: 3})
+	const n = 50
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		seen = map[uint64]bool{}
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := nm.Next(context.Background(), "eth", "0xAbC")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			seen[nonce] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for i := uint64(3); i < 3+n; i++ {
+		if !seen[i] {
+			t.Fatalf("nonce %d was not handed out: %v", i, seen)
+		}
+	}
+	// other senders and chains have their own counters
+	if got, _ := nm.Next(context.Background(), "POLYGON", "0xabc"); got != 3 {
+		t.Fatalf("expected independent counter per chain, got %d", got)
+	}
+}
+
+func TestNonceManager_SyncResetObserve(t *testing.T) {
+	repo := &mockRepo{byID: map[string]*entity.Transaction{
+		"a": sentFrom("a", "0xsigner", 9, ""),
+		"b": sentFrom("b", "0xother", 20, ""),
+	}}
+	chain := &fakeChain{nonce: 7}
+	nm := NewNonceManager(repo, chain)
+	ctx := context.Background()
+
+	if got, _ := nm.Next(ctx, "ETH", "0xSigner"); got != 10 {
+		t.Fatalf("expected nonce after highest sent tx, got %d", got)
+	}
+	nm.Observe("ETH", "0xSigner", 15)
+	if got, _ := nm.Next(ctx, "ETH", "0xSigner"); got != 15 {
+		t.Fatalf("expected observed nonce, got %d", got)
+	}
+	nm.Observe("ETH", "0xSigner", 2)
+	if got, _ := nm.Next(ctx, "ETH", "0xSigner"); got != 16 {
+		t.Fatalf("observe must never lower the counter, got %d", got)
+	}
+
+	delete(repo.byID, "a")
+	nm.Reset("ETH", "0xSigner")
+	if got, _ := nm.Next(ctx, "ETH", "0xSigner"); got != 7 {
+		t.Fatalf("expected resync with node after reset, got %d", got)
+	}
+
+	chain.nonceErr = errors.New("rpc down")
+	nm.Reset("ETH", "0xSigner")
+	if _, err := nm.Next(ctx, "ETH", "0xSigner"); err == nil {
+		t.Fatalf("expected node error")
+	}
+	chain.nonceErr = nil
+	if _, err := NewNonceManager(&repoErr{}, chain).Next(ctx, "ETH", "0xSigner"); err == nil {
+		t.Fatalf("expected repository error")
+	}
+}
+
+func TestSignAndSend_reservesSequentialNonces(t *testing.T) {
+	first, second := pendingTx("t1"), pendingTx("t2")
+	svc, repo, chain, _, _ := newSignAndSendFixture(first)
+	repo.byID["t2"] = second
+	ctx := context.Background()
+
+	if err := svc.SignAndSend(ctx, "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.SignAndSend(ctx, "t2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Nonce != 7 || second.Nonce != 8 {
+		t.Fatalf("expected nonces 7 and 8, got %d and %d", first.Nonce, second.Nonce)
+	}
+
+	// a rejected broadcast resets the counter so the next send resyncs
+	third := pendingTx("t3")
+	repo.byID["t3"] = third
+	chain.sendErr = rejected("nonce too low")
+	chain.nonce = 20
+	if err := svc.SignAndSend(ctx, "t3"); err == nil {
+		t.Fatalf("expected broadcast error")
+	}
+	chain.sendErr = nil
+	fourth := pendingTx("t4")
+	repo.byID["t4"] = fourth
+	if err := svc.SignAndSend(ctx, "t4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fourth.Nonce != 20 {
+		t.Fatalf("expected resynced nonce 20, got %d", fourth.Nonce)
+	}
+}
+
+func TestReconcileNonces_rebroadcastsAndFillsGaps(t *testing.T) {
+	svc, repo, chain, signer, _ := newSignAndSendFixture(sentFrom("n5", "0xsigner", 5, "0x05"))
+	repo.byID["n7"] = sentFrom("n7", "0xSigner", 7, "0x07")
+	repo.byID["old"] = sentFrom("old", "0xSigner", 2, "0x02") // mined, not yet confirmed
+	chain.nonce = 5
+	ctx := context.Background()
+
+	if err := svc.ReconcileNonces(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chain.sent) != 3 {
+		t.Fatalf("expected 2 rebroadcasts and 1 filler, got %d sends", len(chain.sent))
+	}
+	if chain.sent[0][0] != 0x05 || chain.sent[2][0] != 0x07 {
+		t.Fatalf("unexpected rebroadcast order: %x", chain.sent)
+	}
+	if len(signer.signed) != 1 {
+		t.Fatalf("expected one filler signed, got %d", len(signer.signed))
+	}
+	filler := signer.signed[0]
+	if filler.Nonce != 6 || !strings.EqualFold(*filler.To, "0xSigner") || filler.Value.Sign() != 0 || filler.Gas != transferGas {
+		t.Fatalf("unexpected filler: %+v", filler)
+	}
+	if saved := repo.byID[filler.ID]; saved == nil || saved.Status != entity.TxStatusSent {
+		t.Fatalf("expected filler to be stored as sent, got %+v", saved)
+	}
+	if got, _ := svc.nonces.Next(ctx, "ETH", "0xSigner"); got != 8 {
+		t.Fatalf("expected allocator to move past reconciled nonces, got %d", got)
+	}
+}
+
+func TestReconcileNonces_skipsNoncesInFlight(t *testing.T) {
+	svc, repo, chain, signer, _ := newSignAndSendFixture(pendingTx("unrelated"))
+	chain.nonce = 6
+	ctx := context.Background()
+
+	// nonce 6 is being signed while the tx with nonce 7 was already sent
+	if n, err := svc.nonces.Next(ctx, "ETH", "0xSigner"); err != nil || n != 6 {
+		t.Fatalf("expected nonce 6, got %d %v", n, err)
+	}
+	repo.byID["n7"] = sentFrom("n7", "0xSigner", 7, "0x07")
+	if err := svc.ReconcileNonces(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signer.signed) != 0 || len(chain.sent) != 1 || chain.sent[0][0] != 0x07 {
+		t.Fatalf("expected only the rebroadcast of nonce 7, got %d signed and %x sent", len(signer.signed), chain.sent)
+	}
+
+	// a pending tx saved with its nonce holds it too
+	filler := sentFrom("p6", "0xSigner", 6, "")
+	filler.Status, filler.FixedNonce = entity.TxStatusPending, true
+	repo.byID["p6"] = filler
+	svc.nonces.Release("ETH", "0xSigner", 6)
+	if err := svc.ReconcileNonces(ctx); err != nil || len(signer.signed) != 0 {
+		t.Fatalf("expected the pending tx to hold nonce 6, got %d signed %v", len(signer.signed), err)
+	}
+
+	// once neither holds it the gap is filled
+	delete(repo.byID, "p6")
+	if svc.nonces.InFlight("ETH", "0xSigner", 6) {
+		t.Fatalf("expected nonce 6 to be released")
+	}
+	if err := svc.ReconcileNonces(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signer.signed) != 1 || signer.signed[0].Nonce != 6 {
+		t.Fatalf("expected a filler at nonce 6, got %+v", signer.signed)
+	}
+}
+
+func TestReconcileNonces_unsignedPendingHoldsNoNonce(t *testing.T) {
+	// submissions waiting to be signed, one of them already failed a lookup
+	waiting := pendingTx("w1")
+	waiting.From = "0xSigner"
+	svc, repo, chain, signer, _ := newSignAndSendFixture(waiting)
+	other := pendingTx("w2")
+	other.From = "0xSigner"
+	repo.byID["w2"] = other
+	repo.byID["n1"] = sentFrom("n1", "0xSigner", 1, "0x01")
+	chain.nonce = 0
+
+	if err := svc.ReconcileNonces(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signer.signed) != 1 || signer.signed[0].Nonce != 0 || signer.signed[0].ID == "w1" || signer.signed[0].ID == "w2" {
+		t.Fatalf("expected the gap at nonce 0 to be filled, got %+v", signer.signed)
+	}
+}
+
+func TestReconcileNonces_retriedFillerKeepsNonce(t *testing.T) {
+	svc, repo, chain, signer, _ := newSignAndSendFixture(sentFrom("n7", "0xSigner", 7, "0x07"))
+	chain.nonce = 6
+	chain.feeErr = errors.New("fee history unavailable")
+	ctx := context.Background()
+
+	if err := svc.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected the filler's fee lookup to fail")
+	}
+	var filler *entity.Transaction
+	for _, tx := range repo.byID {
+		if tx.ID != "n7" {
+			filler = tx
+		}
+	}
+	if filler == nil || filler.Status != entity.TxStatusPending || !filler.FixedNonce || filler.Nonce != 6 {
+		t.Fatalf("expected the filler left pending at nonce 6, got %+v", filler)
+	}
+	// the pending filler holds its nonce, so no second filler is created
+	if err := svc.ReconcileNonces(ctx); err != nil || len(repo.byID) != 2 {
+		t.Fatalf("expected no second filler, got %d txs %v", len(repo.byID), err)
+	}
+
+	chain.feeErr = nil
+	chain.nonce = 9 // the allocator would hand out 9
+	filler.CreatedAt = time.Now().Add(-time.Hour)
+	if err := svc.RetryPending(ctx, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signer.signed) != 1 || signer.signed[0].Nonce != 6 || filler.Status != entity.TxStatusSent {
+		t.Fatalf("expected the filler signed at its nonce 6, got %+v", signer.signed)
+	}
+}
+
+func TestReconcileNonces_resumesSigned(t *testing.T) {
+	orphan := sentFrom("s5", "0xSigner", 5, "0x05")
+	orphan.Status = entity.TxStatusSigned
+	svc, repo, chain, signer, bus := newSignAndSendFixture(orphan)
+	repo.byID["n6"] = sentFrom("n6", "0xSigner", 6, "0x06")
+	chain.nonce = 5
+
+	if err := svc.ReconcileNonces(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signer.signed) != 0 || len(chain.sent) != 2 || chain.sent[0][0] != 0x05 {
+		t.Fatalf("expected the signed tx to be broadcast instead of filled, got %x", chain.sent)
+	}
+	if orphan.Status != entity.TxStatusSent || repo.updates["s5"][0]["attempts"].([]entity.TxAttempt)[0].TxHash != "0xs5" {
+		t.Fatalf("expected the signed tx to be recorded as sent, got %s %v", orphan.Status, repo.updates["s5"])
+	}
+	if bus.topics[0] != "TxSent" {
+		t.Fatalf("expected TxSent event, got %v", bus.topics)
+	}
+}
+
+func TestReconcileNonces_errors(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, chain, _, _ := newSignAndSendFixture(sentFrom("a", "0xsomeone", 3, "0x03"))
+	chain.nonce = 1
+	// nonce 1 and 2 are missing but the sender is not ours
+	if err := svc.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected error filling gap for foreign sender")
+	}
+
+	delete(repo.byID, "a")
+	repo.byID["b"] = sentFrom("b", "0xSigner", 1, "")
+	if err := svc.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected error rebroadcasting tx without payload")
+	}
+
+	repo.byID["b"].RawTxHex = "0x01"
+	chain.sendErr = errors.New("already known")
+	if err := svc.ReconcileNonces(ctx); err != nil {
+		t.Fatalf("already known must not be an error: %v", err)
+	}
+	chain.sendErr = errors.New("txpool is full")
+	if err := svc.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected broadcast error")
+	}
+
+	chain.nonceErr = errors.New("rpc down")
+	if err := svc.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected node error")
+	}
+
+	noSigner := NewTransactionService(repo, &fakeChain{nonce: 0}, nil, zap.NewNop())
+	if err := noSigner.ReconcileNonces(ctx); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("expected ErrSignerUnavailable, got %v", err)
+	}
+	failing := NewTransactionService(&repoErr{}, chain, nil, zap.NewNop())
+	if err := failing.ReconcileNonces(ctx); err == nil {
+		t.Fatalf("expected list error")
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OutboxRelay publishes the events that repository writes appended to the
+// outbox. A message is marked published only after it was handed to the bus,
+// so a relay that stops in between publishes it again once its claim expires:
+// subscribers must tolerate duplicates.
+type OutboxRelay struct {
+	outbox    ports.OutboxPort
+	bus       ports.EventBus
+	logger    *zap.Logger
+	batchSize int
+	lease     time.Duration
+}
+
+// NewOutboxRelay builds a relay that claims batchSize messages at a time for
+// lease.
+func NewOutboxRelay(outbox ports.OutboxPort, bus ports.EventBus, logger *zap.Logger, batchSize int, lease time.Duration) *OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+	return &OutboxRelay{outbox: outbox, bus: bus, logger: logger, batchSize: batchSize, lease: lease}
+}
+
+// Drain publishes claimed messages until no unclaimed message is left.
+// Messages that cannot be decoded are marked failed and retried after their
+// lease; their errors are joined into the result. Drain stops at the first
+// message the bus refuses, which is retried the same way.
+func (r *OutboxRelay) Drain(ctx context.Context) error {
+	var errs []error
+	for ctx.Err() == nil {
+		msgs, err := r.outbox.Claim(ctx, r.batchSize, r.lease)
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("claim outbox: %w", err))...)
+		}
+		for _, m := range msgs {
+			evt, err := entity.DecodeEvent(m.Topic, m.Payload)
+			if err != nil {
+				err = fmt.Errorf("outbox message %d: %w", m.ID, err)
+				r.logger.Error("undeliverable outbox message",
+					zap.Int64("id", m.ID), zap.String("topic", m.Topic), zap.Int("attempts", m.Attempts), zap.Error(err))
+				if markErr := r.outbox.MarkFailed(ctx, m.ID, err.Error()); markErr != nil {
+					err = errors.Join(err, markErr)
+				}
+				errs = append(errs, err)
+				continue
+			}
+			if err := r.bus.Publish(ctx, m.Topic, evt); err != nil {
+				// the claim expires and the message is published again; the
+				// rest of the batch would not fit either
+				err = fmt.Errorf("publish outbox message %d: %w", m.ID, err)
+				if markErr := r.outbox.MarkFailed(ctx, m.ID, err.Error()); markErr != nil {
+					err = errors.Join(err, markErr)
+				}
+				return errors.Join(append(errs, err)...)
+			}
+			if err := r.outbox.MarkPublished(ctx, m.ID); err != nil {
+				// the claim expires and the message is published again
+				return errors.Join(append(errs, fmt.Errorf("mark outbox message %d published: %w", m.ID, err))...)
+			}
+		}
+		if len(msgs) < r.batchSize {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
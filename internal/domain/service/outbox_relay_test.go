@@ -0,0 +1,115 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeOutbox hands out its messages in batches and records what happens to
+// them.
+type fakeOutbox struct {
+	pending   []ports.OutboxMessage
+	published []int64
+	failed    map[int64]string
+	claimErr  error
+	markErr   error
+}
+
+func (o *fakeOutbox) Claim(ctx context.Context, limit int, lease time.Duration) ([]ports.OutboxMessage, error) {
+	if o.claimErr != nil {
+		return nil, o.claimErr
+	}
+	n := min(limit, len(o.pending))
+	batch := o.pending[:n]
+	o.pending = o.pending[n:]
+	return batch, nil
+}
+func (o *fakeOutbox) MarkPublished(ctx context.Context, id int64) error {
+	if o.markErr != nil {
+		return o.markErr
+	}
+	o.published = append(o.published, id)
+	return nil
+}
+func (o *fakeOutbox) MarkFailed(ctx context.Context, id int64, reason string) error {
+	if o.failed == nil {
+		o.failed = map[int64]string{}
+	}
+	o.failed[id] = reason
+	return nil
+}
+
+func outboxMessage(t *testing.T, id int64, evt entity.Event) ports.OutboxMessage {
+	t.Helper()
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return ports.OutboxMessage{ID: id, Topic: evt.Type(), Payload: payload}
+}
+
+func TestOutboxRelay_Drain(t *testing.T) {
+	outbox := &fakeOutbox{pending: []ports.OutboxMessage{
+		outboxMessage(t, 1, entity.TxCreatedEvent{TxID: "t1"}),
+		{ID: 2, Topic: "TxExploded", Payload: []byte(`{}`)},
+		outboxMessage(t, 3, entity.TxSentEvent{TxID: "t1", TxHash: "0xa"}),
+	}}
+	bus := &fakeBus{}
+	relay := NewOutboxRelay(outbox, bus, zap.NewNop(), 2, time.Minute)
+
+	err := relay.Drain(context.Background())
+	if !errors.Is(err, entity.ErrUnknownEvent) {
+		t.Fatalf("expected the undecodable message to be reported, got %v", err)
+	}
+	if len(bus.topics) != 2 || bus.topics[0] != "TxCreated" || bus.topics[1] != "TxSent" {
+		t.Fatalf("unexpected published topics %v", bus.topics)
+	}
+	if evt, ok := bus.events[1].(entity.TxSentEvent); !ok || evt.TxHash != "0xa" {
+		t.Fatalf("expected a decoded TxSentEvent, got %#v", bus.events[1])
+	}
+	if len(outbox.published) != 2 || outbox.published[1] != 3 || outbox.failed[2] == "" {
+		t.Fatalf("unexpected acknowledgements %v %v", outbox.published, outbox.failed)
+	}
+}
+
+func TestOutboxRelay_DrainErrors(t *testing.T) {
+	failing := &fakeOutbox{claimErr: errors.New("db down")}
+	if err := NewOutboxRelay(failing, &fakeBus{}, zap.NewNop(), 0, 0).Drain(context.Background()); err == nil {
+		t.Fatalf("expected claim error")
+	}
+
+	// the message was published but stays claimed, so it is delivered again
+	unacked := &fakeOutbox{
+		pending: []ports.OutboxMessage{outboxMessage(t, 1, entity.TxCreatedEvent{TxID: "t1"})},
+		markErr: errors.New("db down"),
+	}
+	bus := &fakeBus{}
+	if err := NewOutboxRelay(unacked, bus, zap.NewNop(), 10, time.Minute).Drain(context.Background()); err == nil {
+		t.Fatalf("expected mark error")
+	}
+	if len(bus.topics) != 1 {
+		t.Fatalf("expected the event to be published once, got %v", bus.topics)
+	}
+}
+
+func TestOutboxRelay_DrainBusFull(t *testing.T) {
+	outbox := &fakeOutbox{pending: []ports.OutboxMessage{
+		outboxMessage(t, 1, entity.TxCreatedEvent{TxID: "t1"}),
+		outboxMessage(t, 2, entity.TxCreatedEvent{TxID: "t2"}),
+	}}
+	err := NewOutboxRelay(outbox, &fakeBus{full: true}, zap.NewNop(), 10, time.Minute).Drain(context.Background())
+	if !errors.Is(err, ports.ErrBusFull) {
+		t.Fatalf("expected ErrBusFull, got %v", err)
+	}
+	// the refused message is retried after its lease and the batch stops
+	if len(outbox.published) != 0 || outbox.failed[1] == "" || len(outbox.failed) != 1 {
+		t.Fatalf("unexpected acknowledgements %v %v", outbox.published, outbox.failed)
+	}
+}
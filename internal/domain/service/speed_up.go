@@ -0,0 +1,298 @@
+package service
+
+import (
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// replacementBumpPercent is the minimum fee increase nodes require before they
+// accept a replacement for a pending transaction with the same nonce.
+const replacementBumpPercent = 10
+
+var ErrNotSent = errors.New("transaction is not sent")
+
+// SpeedUp re-signs a sent transaction at the same nonce with fees raised by at
+// least the replacement minimum, or to the current estimate when that is
+// higher, and broadcasts it. The new hash is recorded as another attempt.
+func (s *TransactionService) SpeedUp(ctx context.Context, txID string) error {
+	if s.signer == nil {
+		return ErrSignerUnavailable
+	}
+	tx, err := s.repo.FindByID(ctx, txID)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		return ErrTransactionNotFound
+	}
+	if tx.Status != entity.TxStatusSent {
+		return fmt.Errorf("%w: %s is %s", ErrNotSent, tx.ID, tx.Status)
+	}
+	return s.replace(ctx, tx, false)
+}
+
+// SpeedUpStuck speeds up every sent transaction whose latest attempt has
+// waited longer than stuckAfter without any of its hashes being mined.
+func (s *TransactionService) SpeedUpStuck(ctx context.Context, stuckAfter time.Duration) error {
+	if s.signer == nil {
+		return nil
+	}
+	sent, err := s.repo.ListByStatus(ctx, entity.TxStatusSent, 0)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-stuckAfter)
+	var errs []error
+	for _, tx := range sent {
+		last := lastSentAt(tx)
+		if last.IsZero() || last.After(cutoff) {
+			continue
+		}
+		mined, err := s.anyMined(ctx, tx)
+		if err == nil && !mined {
+			err = s.replace(ctx, tx, false)
+		}
+		if err != nil {
+			s.logger.Warn("speed up failed", zap.String("tx_id", tx.ID), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// attemptWriteTries bounds how often an attempt update is retried when a
+// concurrent writer bumps the version of the transaction first.
+const attemptWriteTries = 5
+
+// replace signs a fee-bumped copy of tx and broadcasts it as the latest
+// attempt. With cancel, or when the latest attempt already cancels tx, the
+// copy is a zero-value self-transfer. tx itself is left untouched until the
+// repository update.
+//
+// The attempt is stored with its hash and raw tx before the broadcast, so
+// the tracker knows every hash that can be mined even when a concurrent write
+// wins afterwards. Once broadcast it gets its SentAt and becomes the current
+// hash of tx; an attempt the node rejects is dropped again.
+func (s *TransactionService) replace(ctx context.Context, tx *entity.Transaction, cancel bool) error {
+	actor := actorSpeedUp
+	if cancel {
+		actor = actorAPI
+	}
+	attempts := attemptsOf(tx)
+	oldHash := tx.TxHash
+	replacement := *tx
+	if n := len(attempts); n > 0 && attempts[n-1].Cancel {
+		cancel = true
+	}
+	if cancel {
+		self := tx.From
+		replacement.To = &self
+		replacement.Value = new(big.Int)
+		replacement.Data = nil
+		replacement.AccessList = nil
+		replacement.Gas = transferGas
+	}
+	s.bumpFees(ctx, &replacement)
+
+	raw, hash, err := s.signer.SignTransaction(ctx, &replacement)
+	if err != nil {
+		return fmt.Errorf("sign replacement: %w", err)
+	}
+	rawHex := "0x" + hex.EncodeToString(raw)
+	// a zero SentAt marks the attempt as not broadcast yet
+	attempt := newAttempt(&replacement, hash, rawHex, time.Time{})
+	attempt.Cancel = cancel
+	if err := setStatus(ctx, s.repo, tx, entity.TxStatusSent, map[string]interface{}{
+		"attempts": append(attempts, attempt),
+	}, audit(actor, "signed replacement "+hash)); err != nil {
+		return err
+	}
+
+	sentHash, sendErr := s.chain.SendRawTransaction(ctx, tx.Chain, raw)
+	if errors.Is(sendErr, ports.ErrTxRejected) {
+		err := s.updateAttempt(ctx, tx.ID, hash, func(_ *entity.Transaction, attempts []entity.TxAttempt, i int) (map[string]interface{}, ports.UpdateMeta) {
+			return map[string]interface{}{
+				"attempts": append(attempts[:i:i], attempts[i+1:]...),
+			}, audit(actor, fmt.Sprintf("replacement %s rejected: %v", hash, sendErr))
+		})
+		return errors.Join(fmt.Errorf("broadcast replacement: %w", sendErr), err)
+	}
+	if sendErr != nil && alreadyKnown(sendErr) {
+		sendErr = nil
+	}
+	if sentHash == "" {
+		sentHash = hash
+	}
+	reason := fmt.Sprintf("replaced %s with %s", oldHash, sentHash)
+	if cancel {
+		reason = "cancel: " + reason
+	}
+	if sendErr != nil {
+		// as in signAndSend, the node may hold the replacement anyway
+		reason = fmt.Sprintf("%s unconfirmed: %v", reason, sendErr)
+	}
+	sentAt := time.Now().UTC()
+	err = s.updateAttempt(ctx, tx.ID, hash, func(stored *entity.Transaction, attempts []entity.TxAttempt, i int) (map[string]interface{}, ports.UpdateMeta) {
+		attempts[i].TxHash = sentHash
+		attempts[i].SentAt = sentAt
+		updates := map[string]interface{}{
+			"tx_hash":    sentHash,
+			"raw_tx_hex": rawHex,
+			"attempts":   attempts,
+		}
+		if replacement.MaxFeePerGas != nil {
+			updates["max_fee_per_gas"] = replacement.MaxFeePerGas
+			updates["max_priority_fee_per_gas"] = replacement.MaxPriorityFeePerGas
+		} else {
+			updates["gas_price"] = replacement.GasPrice
+		}
+		return updates, audit(actor, reason,
+			entity.TxReplacedEvent{BaseEvent: now(), TxID: stored.ID, OldHash: stored.TxHash, NewHash: sentHash})
+	})
+	if err != nil {
+		return err
+	}
+
+	if sendErr != nil {
+		s.logger.Warn("replacement outcome unknown, attempt kept",
+			zap.String("tx_id", tx.ID), zap.String("tx_hash", sentHash), zap.Error(sendErr))
+		return nil
+	}
+	s.logger.Info("transaction replaced",
+		zap.String("tx_id", tx.ID), zap.Uint64("nonce", tx.Nonce), zap.Bool("cancel", cancel),
+		zap.String("old_hash", oldHash), zap.String("new_hash", sentHash))
+	return nil
+}
+
+// updateAttempt stores the updates change makes to the attempt with hash,
+// at index i of a copy of the stored attempts, with its audit entry and events. It
+// reloads the transaction and tries again when a concurrent write bumped its
+// version. Once the transaction is no longer sent, or no longer has the
+// attempt, there is nothing left to update.
+func (s *TransactionService) updateAttempt(ctx context.Context, txID, hash string,
+	change func(tx *entity.Transaction, attempts []entity.TxAttempt, i int) (map[string]interface{}, ports.UpdateMeta)) error {
+	var err error
+	for try := 0; try < attemptWriteTries; try++ {
+		tx, ferr := s.repo.FindByID(ctx, txID)
+		if ferr != nil {
+			return ferr
+		}
+		if tx == nil || tx.Status != entity.TxStatusSent {
+			return nil
+		}
+		attempts := attemptsOf(tx)
+		i := slices.IndexFunc(attempts, func(a entity.TxAttempt) bool { return a.TxHash == hash })
+		if i < 0 {
+			return nil
+		}
+		updates, meta := change(tx, attempts, i)
+		err = setStatus(ctx, s.repo, tx, entity.TxStatusSent, updates, meta)
+		if !errors.Is(err, ports.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// bumpFees raises the fees of tx for a replacement. Estimation errors only
+// cost the market comparison; the minimum bump is always applied.
+func (s *TransactionService) bumpFees(ctx context.Context, tx *entity.Transaction) {
+	tip, maxFee, err := s.chain.EstimateFees(ctx, tx.Chain, tx.FeeTier)
+	if err != nil {
+		s.logger.Warn("fee estimate for replacement failed", zap.String("tx_id", tx.ID), zap.Error(err))
+		tip, maxFee = nil, nil
+	}
+	if tx.MaxFeePerGas == nil {
+		// legacy chains report their gas price as maxFee
+		tx.GasPrice = bumpFee(tx.GasPrice, maxFee)
+		return
+	}
+	tx.MaxPriorityFeePerGas = bumpFee(tx.MaxPriorityFeePerGas, tip)
+	tx.MaxFeePerGas = bumpFee(tx.MaxFeePerGas, maxFee)
+	if tx.MaxFeePerGas.Cmp(tx.MaxPriorityFeePerGas) < 0 {
+		tx.MaxFeePerGas = new(big.Int).Set(tx.MaxPriorityFeePerGas)
+	}
+}
+
+// anyMined reports whether any attempt of tx has a receipt.
+func (s *TransactionService) anyMined(ctx context.Context, tx *entity.Transaction) (bool, error) {
+	for _, hash := range tx.AttemptHashes() {
+		rc, err := s.chain.GetTransactionReceipt(ctx, tx.Chain, hash)
+		if err != nil {
+			return false, err
+		}
+		if rc != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bumpFee raises old by replacementBumpPercent, rounding up, and returns
+// market instead when it is higher.
+func bumpFee(old, market *big.Int) *big.Int {
+	bumped := new(big.Int)
+	if old != nil {
+		bumped.Mul(old, big.NewInt(100+replacementBumpPercent))
+		bumped.Add(bumped, big.NewInt(99))
+		bumped.Div(bumped, big.NewInt(100))
+		if bumped.Cmp(old) <= 0 {
+			bumped.Add(old, big.NewInt(1))
+		}
+	}
+	if market != nil && market.Cmp(bumped) > 0 {
+		return new(big.Int).Set(market)
+	}
+	return bumped
+}
+
+func newAttempt(tx *entity.Transaction, hash, rawHex string, sentAt time.Time) entity.TxAttempt {
+	return entity.TxAttempt{
+		TxHash:               hash,
+		RawTxHex:             rawHex,
+		GasPrice:             tx.GasPrice,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		SentAt:               sentAt,
+		Status:               entity.AttemptStatusPending,
+	}
+}
+
+// attemptsOf returns a copy of the attempts of tx. Transactions sent before
+// attempts were recorded get one synthesised from their current fields.
+func attemptsOf(tx *entity.Transaction) []entity.TxAttempt {
+	if len(tx.Attempts) > 0 {
+		return append([]entity.TxAttempt(nil), tx.Attempts...)
+	}
+	if tx.TxHash == "" {
+		return nil
+	}
+	var sentAt time.Time
+	if tx.SentAt != nil {
+		sentAt = *tx.SentAt
+	}
+	return []entity.TxAttempt{newAttempt(tx, tx.TxHash, tx.RawTxHex, sentAt)}
+}
+
+// lastSentAt returns when the latest broadcast attempt of tx was sent.
+// Attempts stored but not broadcast yet are skipped.
+func lastSentAt(tx *entity.Transaction) time.Time {
+	for i := len(tx.Attempts) - 1; i >= 0; i-- {
+		if !tx.Attempts[i].SentAt.IsZero() {
+			return tx.Attempts[i].SentAt
+		}
+	}
+	if tx.SentAt != nil {
+		return *tx.SentAt
+	}
+	return time.Time{}
+}
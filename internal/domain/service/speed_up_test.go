@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
+
+	"go.uber.org/zap"
+)
+
+func TestBumpFee(t *testing.T) {
+	tests := []struct {
+		old, market *big.Int
+		want        int64
+	}{
+		{big.NewInt(100), nil, 110},
+		{big.NewInt(21), nil, 24}, // 23.1 rounds up
+		{big.NewInt(1), nil, 2},
+		{big.NewInt(0), nil, 1},
+		{big.NewInt(100), big.NewInt(200), 200},
+		{big.NewInt(100), big.NewInt(105), 110},
+		{nil, big.NewInt(5), 5},
+		{nil, nil, 0},
+	}
+	for _, tc := range tests {
+		if got := bumpFee(tc.old, tc.market); got.Int64() != tc.want {
+			t.Errorf("bumpFee(%v, %v) = %v, want %d", tc.old, tc.market, got, tc.want)
+		}
+	}
+}
+
+func TestSpeedUp_dynamicFee(t *testing.T) {
+	tx := pendingTx("t1")
+	svc, repo, chain, _, bus := newSignAndSendFixture(tx)
+	ctx := context.Background()
+	if err := svc.SignAndSend(ctx, "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := repo.updates["t1"][1]["attempts"].([]entity.TxAttempt)
+	if len(first) != 1 || first[0].TxHash != "0xsent" || first[0].RawTxHex == "" {
+		t.Fatalf("expected first attempt recorded on send, got %+v", first)
+	}
+	tx.Attempts = first
+
+	chain.sendHash = "0xreplaced"
+	if err := svc.SpeedUp(ctx, "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the signed replacement is stored before it is broadcast
+	signed := repo.updates["t1"][2]["attempts"].([]entity.TxAttempt)
+	if len(signed) != 2 || signed[1].TxHash != "0xsigned" || signed[1].RawTxHex == "" || !signed[1].SentAt.IsZero() {
+		t.Fatalf("expected the unsent replacement recorded, got %+v", signed)
+	}
+	upd := repo.updates["t1"][3]
+	if upd["max_priority_fee_per_gas"].(*big.Int).Int64() != 3 || upd["max_fee_per_gas"].(*big.Int).Int64() != 55 {
+		t.Fatalf("expected fees bumped by 10%%, got %v", upd)
+	}
+	attempts := upd["attempts"].([]entity.TxAttempt)
+	if len(attempts) != 2 || attempts[0].TxHash != "0xsent" || attempts[1].TxHash != "0xreplaced" || attempts[1].SentAt.IsZero() {
+		t.Fatalf("unexpected attempts: %+v", attempts)
+	}
+	if tx.TxHash != "0xreplaced" || tx.Status != entity.TxStatusSent {
+		t.Fatalf("expected tx to stay sent under the new hash, got %s %v", tx.TxHash, tx.Status)
+	}
+	// the original keeps its fees until the repository applies the update
+	if tx.MaxFeePerGas.Int64() != 50 {
+		t.Fatalf("original transaction must not be mutated")
+	}
+	evt, ok := bus.events[len(bus.events)-1].(entity.TxReplacedEvent)
+	if !ok || evt.OldHash != "0xsent" || evt.NewHash != "0xreplaced" {
+		t.Fatalf("expected TxReplaced event, got %+v", bus.events[len(bus.events)-1])
+	}
+}
+
+func TestSpeedUp_legacyUsesMarketPrice(t *testing.T) {
+	sentAt := time.Now().Add(-time.Hour)
+	tx := &entity.Transaction{
+		ID: "t1", Chain: "ETH", From: "0xSigner", TxHash: "0xold", RawTxHex: "0x01",
+		GasPrice: big.NewInt(100), Status: entity.TxStatusSent, SentAt: &sentAt,
+	}
+	svc, repo, chain, _, _ := newSignAndSendFixture(tx)
+	chain.tip, chain.maxFee = nil, big.NewInt(150)
+	if err := svc.SpeedUp(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	upd := repo.updates["t1"][1]
+	if upd["gas_price"].(*big.Int).Int64() != 150 {
+		t.Fatalf("expected market gas price, got %v", upd["gas_price"])
+	}
+	attempts := upd["attempts"].([]entity.TxAttempt)
+	if len(attempts) != 2 || attempts[0].TxHash != "0xold" || !attempts[0].SentAt.Equal(sentAt) {
+		t.Fatalf("expected synthesised first attempt, got %+v", attempts)
+	}
+}
+
+func TestSpeedUp_errors(t *testing.T) {
+	ctx := context.Background()
+	if err := NewTransactionService(&mockRepo{}, nil, nil, zap.NewNop()).SpeedUp(ctx, "x"); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("expected ErrSignerUnavailable, got %v", err)
+	}
+
+	tx := pendingTx("t1")
+	svc, repo, chain, signer, _ := newSignAndSendFixture(tx)
+	if err := svc.SpeedUp(ctx, "missing"); err == nil {
+		t.Fatalf("expected lookup error")
+	}
+	if err := svc.SpeedUp(ctx, "t1"); !errors.Is(err, ErrNotSent) {
+		t.Fatalf("expected ErrNotSent, got %v", err)
+	}
+
+	tx.Status, tx.TxHash, tx.MaxFeePerGas, tx.MaxPriorityFeePerGas = entity.TxStatusSent, "0xsent", big.NewInt(50), big.NewInt(2)
+	chain.feeErr = errors.New("no estimate")
+	chain.sendErr = rejected("replacement transaction underpriced")
+	if err := svc.SpeedUp(ctx, "t1"); !errors.Is(err, ports.ErrTxRejected) {
+		t.Fatalf("expected broadcast error, got %v", err)
+	}
+	// the rejected attempt is recorded and dropped again
+	if n := len(repo.updates["t1"]); n != 2 || len(tx.Attempts) != 1 || tx.Attempts[0].TxHash != "0xsent" || tx.TxHash != "0xsent" {
+		t.Fatalf("expected only the original attempt after %d updates, got %s %+v", n, tx.TxHash, tx.Attempts)
+	}
+	signer.signErr = errors.New("hsm offline")
+	if err := svc.SpeedUp(ctx, "t1"); err == nil {
+		t.Fatalf("expected sign error")
+	}
+	if tx.Status != entity.TxStatusSent || len(repo.updates["t1"]) != 2 {
+		t.Fatalf("failed replacements must leave the tx sent")
+	}
+}
+
+func TestSpeedUp_unclearBroadcastKeepsAttempt(t *testing.T) {
+	tx := &entity.Transaction{ID: "t1", Chain: "ETH", From: "0xSigner", TxHash: "0xsent", GasPrice: big.NewInt(10), Status: entity.TxStatusSent}
+	svc, _, chain, _, _ := newSignAndSendFixture(tx)
+	chain.sendHash, chain.sendErr = "", errors.New("connection reset")
+	if err := svc.SpeedUp(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tx.Attempts) != 2 || tx.Attempts[1].TxHash != "0xsigned" || tx.Attempts[1].SentAt.IsZero() || tx.TxHash != "0xsigned" {
+		t.Fatalf("expected the replacement kept as the latest attempt, got %s %+v", tx.TxHash, tx.Attempts)
+	}
+}
+
+func TestSpeedUp_conflictAfterBroadcast(t *testing.T) {
+	tx := &entity.Transaction{ID: "t1", Chain: "ETH", From: "0xSigner", TxHash: "0xsent", GasPrice: big.NewInt(10), Status: entity.TxStatusSent}
+	svc, repo, chain, _, bus := newSignAndSendFixture(tx)
+	chain.sendHash = "0xreplaced"
+	// the tracker writes the tx while the replacement is on the wire
+	chain.onSend = func() { repo.conflicts = 2 }
+	if err := svc.SpeedUp(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tx.Attempts) != 2 || tx.Attempts[1].TxHash != "0xreplaced" || tx.Attempts[1].SentAt.IsZero() || tx.TxHash != "0xreplaced" {
+		t.Fatalf("expected the broadcast replacement recorded despite the conflicts, got %s %+v", tx.TxHash, tx.Attempts)
+	}
+	if evt, ok := bus.events[len(bus.events)-1].(entity.TxReplacedEvent); !ok || evt.NewHash != "0xreplaced" {
+		t.Fatalf("expected TxReplaced event, got %+v", bus.events)
+	}
+
+	// a writer that keeps winning still leaves the signed hash for the tracker
+	chain.sendHash = ""
+	chain.onSend = func() { repo.conflicts = attemptWriteTries }
+	if err := svc.SpeedUp(context.Background(), "t1"); !errors.Is(err, ports.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if hashes := tx.AttemptHashes(); len(hashes) != 3 || hashes[2] != "0xsigned" {
+		t.Fatalf("expected the signed replacement to stay tracked, got %v", hashes)
+	}
+}
+
+func TestSpeedUpStuck(t *testing.T) {
+	old := time.Now().Add(-10 * time.Minute)
+	recent := time.Now()
+	stuck := &entity.Transaction{ID: "stuck", Chain: "ETH", TxHash: "0xs", GasPrice: big.NewInt(10), Status: entity.TxStatusSent,
+		Attempts: []entity.TxAttempt{{TxHash: "0xs", SentAt: old}}}
+	fresh := &entity.Transaction{ID: "fresh", Chain: "ETH", TxHash: "0xf", GasPrice: big.NewInt(10), Status: entity.TxStatusSent, SentAt: &recent}
+	mined := &entity.Transaction{ID: "mined", Chain: "ETH", TxHash: "0xm", GasPrice: big.NewInt(10), Status: entity.TxStatusSent, SentAt: &old}
+	unsent := &entity.Transaction{ID: "unsent", Chain: "ETH", Status: entity.TxStatusSent}
+
+	svc, repo, chain, _, _ := newSignAndSendFixture(stuck)
+	for _, tx := range []*entity.Transaction{fresh, mined, unsent} {
+		repo.byID[tx.ID] = tx
+	}
+	chain.receipts = map[string]*entity.Receipt{"0xm": {BlockNumber: 1}}
+	chain.tip = nil
+	chain.sendHash = "0xbumped"
+
+	if err := svc.SpeedUpStuck(context.Background(), 5*time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chain.sent) != 1 || stuck.TxHash != "0xbumped" {
+		t.Fatalf("expected only the stuck tx to be replaced, sent %d", len(chain.sent))
+	}
+	if fresh.TxHash != "0xf" || mined.TxHash != "0xm" {
+		t.Fatalf("fresh and mined transactions must not be replaced")
+	}
+
+	chain.receiptErr = errors.New("rpc down")
+	if err := svc.SpeedUpStuck(context.Background(), time.Nanosecond); err == nil {
+		t.Fatalf("expected receipt error")
+	}
+	if err := NewTransactionService(&repoErr{}, chain, &fakeSigner{}, zap.NewNop()).SpeedUpStuck(context.Background(), time.Minute); err == nil {
+		t.Fatalf("expected list error")
+	}
+	if err := NewTransactionService(&repoErr{}, chain, nil, zap.NewNop()).SpeedUpStuck(context.Background(), time.Minute); err != nil {
+		t.Fatalf("without a signer nothing is replaced: %v", err)
+	}
+}
@@ -4,31 +4,89 @@ import (
 	"ChainConnector/internal/domain/entity"
 	"ChainConnector/internal/domain/ports"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// transferGas is the gas limit of a plain value transfer without calldata.
+const transferGas = 21000
+
+// Actors recorded in the status history for changes made by the service.
+const (
+	actorAPI     = "api"
+	actorSigner  = "signer"
+	actorTracker = "confirmation-tracker"
+	actorSpeedUp = "speed-up"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrSignerUnavailable   = errors.New("no wallet signer configured")
+	ErrNotPending          = errors.New("transaction is not pending")
+	// ErrIdempotencyKeyReused is returned for a request whose idempotency
+	// key was already used by a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+	// ErrForeignSender is returned for a transaction whose From is not the
+	// signer's address; the signer cannot sign for it.
+	ErrForeignSender = errors.New("sender is not the signer address")
+)
+
 type TransactionService struct {
 	repo   ports.TxRepositoryPort
+	chain  ports.BlockchainPort
+	signer ports.WalletSignerPort
+	nonces *NonceManager
 	logger *zap.Logger
 }
 
-func NewTransactionService(repo ports.TxRepositoryPort, logger *zap.Logger) *TransactionService {
+func NewTransactionService(
+	repo ports.TxRepositoryPort,
+	chain ports.BlockchainPort,
+	signer ports.WalletSignerPort,
+	logger *zap.Logger,
+) *TransactionService {
 	return &TransactionService{
 		repo:   repo,
+		chain:  chain,
+		signer: signer,
+		nonces: NewNonceManager(repo, chain),
 		logger: logger,
 	}
 }
 
+// CreateTransaction stores tx as pending, assigning it a UUID when it has no
+// ID yet. A tx whose IdempotencyKey was already used by the same request is
+// not stored again: *tx is replaced with the original transaction instead. A
+// key used by a different request fails with ErrIdempotencyKeyReused.
 func (s *TransactionService) CreateTransaction(ctx context.Context, tx *entity.Transaction) error {
 	if tx == nil {
 		return errors.New("transaction is nil")
 	}
+	if replayed, err := s.replay(ctx, tx); replayed || err != nil {
+		return err
+	}
 
+	if tx.ID == "" {
+		tx.ID = uuid.NewString()
+	}
 	tx.Status = entity.TxStatusPending
 
-	if err := s.repo.Save(ctx, tx); err != nil {
+	if err := s.repo.Save(ctx, tx, entity.TxCreatedEvent{BaseEvent: now(), TxID: tx.ID}); err != nil {
+		// a concurrent request with the same key was stored first
+		if errors.Is(err, ports.ErrDuplicateIdempotencyKey) {
+			if replayed, rerr := s.replay(ctx, tx); replayed || rerr != nil {
+				return rerr
+			}
+		}
 		return err
 	}
 
@@ -36,3 +94,370 @@ func (s *TransactionService) CreateTransaction(ctx context.Context, tx *entity.T
 
 	return nil
 }
+
+// FindIdempotent returns the transaction created earlier with the
+// IdempotencyKey of tx, or nil when tx has no key or the key is unused. It
+// fails with ErrIdempotencyKeyReused when the earlier request differs from tx.
+func (s *TransactionService) FindIdempotent(ctx context.Context, tx *entity.Transaction) (*entity.Transaction, error) {
+	if tx.IdempotencyKey == "" {
+		return nil, nil
+	}
+	tx.RequestHash = requestFingerprint(tx)
+	existing, err := s.repo.FindByIdempotencyKey(ctx, tx.IdempotencyKey)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.RequestHash != tx.RequestHash {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, tx.IdempotencyKey)
+	}
+	return existing, nil
+}
+
+// replay replaces *tx with the transaction created earlier by the same
+// request, if any.
+func (s *TransactionService) replay(ctx context.Context, tx *entity.Transaction) (bool, error) {
+	existing, err := s.FindIdempotent(ctx, tx)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	*tx = *existing
+	s.logger.Info("idempotent request replayed",
+		zap.String("tx_id", tx.ID), zap.String("idempotency_key", tx.IdempotencyKey))
+	return true, nil
+}
+
+// requestFingerprint hashes the fields of tx that a client submits and the
+// principal submitting them, so that two requests with the same idempotency
+// key can be compared and one caller cannot replay another's transaction.
+func requestFingerprint(tx *entity.Transaction) string {
+	to := ""
+	if tx.To != nil {
+		to = strings.ToLower(*tx.To)
+	}
+	h := sha256.New()
+	for _, field := range []string{
+		strings.ToUpper(strings.TrimSpace(tx.Chain)),
+		strings.ToLower(tx.From),
+		to,
+		bigString(tx.Value),
+		strconv.FormatUint(tx.Gas, 10),
+		bigString(tx.GasPrice),
+		bigString(tx.MaxFeePerGas),
+		bigString(tx.MaxPriorityFeePerGas),
+		hex.EncodeToString(tx.Data),
+		tx.RequestedBy,
+	} {
+		// the separator keeps ("ab", "c") and ("a", "bc") apart
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	// the standard tier adds nothing, so requests stored before tiers could
+	// be chosen keep their fingerprint
+	if tx.FeeTier != "" && tx.FeeTier != entity.FeeTierStandard {
+		h.Write([]byte(tx.FeeTier))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func bigString(b *big.Int) string {
+	if b == nil {
+		return ""
+	}
+	return b.String()
+}
+
+// SignAndSend moves a pending transaction through Signed and Sent: it fills in
+// the sender, chain id, nonce and fees, signs the transaction, broadcasts it
+// and persists each step together with the matching domain event. Only a
+// transaction the node rejected, or that cannot be signed, is marked failed: a
+// failed node lookup leaves it pending for RetryPending, and a broadcast error
+// that does not prove a rejection records it as sent for the tracker and the
+// nonce reconciler to settle.
+func (s *TransactionService) SignAndSend(ctx context.Context, txID string) error {
+	if s.signer == nil {
+		return ErrSignerUnavailable
+	}
+	tx, err := s.repo.FindByID(ctx, txID)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		return ErrTransactionNotFound
+	}
+	if tx.Status != entity.TxStatusPending {
+		return fmt.Errorf("%w: %s is %s", ErrNotPending, tx.ID, tx.Status)
+	}
+	return s.signAndSend(ctx, tx)
+}
+
+// signAndSend runs the signing and broadcast pipeline for a pending tx. A tx
+// with a FixedNonce keeps its nonce instead of reserving a new one.
+func (s *TransactionService) signAndSend(ctx context.Context, tx *entity.Transaction) error {
+	allocate := !tx.FixedNonce
+	updates, err := s.prepare(ctx, tx, allocate)
+	if err != nil {
+		var retry *retryableError
+		if errors.As(err, &retry) {
+			// no nonce was reserved and nothing stored
+			s.logger.Warn("transaction left pending", zap.String("tx_id", tx.ID), zap.Error(err))
+			return fmt.Errorf("prepare: %w", err)
+		}
+		return s.fail(ctx, tx, fmt.Errorf("prepare: %w", err))
+	}
+	if allocate {
+		defer s.nonces.Release(tx.Chain, tx.From, tx.Nonce)
+	}
+
+	raw, hash, err := s.signer.SignTransaction(ctx, tx)
+	if err != nil {
+		return s.fail(ctx, tx, fmt.Errorf("sign: %w", err))
+	}
+	rawHex := "0x" + hex.EncodeToString(raw)
+	updates["raw_tx_hex"] = rawHex
+	updates["tx_hash"] = hash
+	if err := setStatus(ctx, s.repo, tx, entity.TxStatusSigned, updates,
+		audit(actorSigner, fmt.Sprintf("signed with nonce %d", tx.Nonce),
+			entity.TxSignedEvent{BaseEvent: now(), TxID: tx.ID, TxHash: hash})); err != nil {
+		s.nonces.Reset(tx.Chain, tx.From)
+		return err
+	}
+
+	sentHash, sendErr := s.chain.SendRawTransaction(ctx, tx.Chain, raw)
+	if errors.Is(sendErr, ports.ErrTxRejected) {
+		return s.fail(ctx, tx, fmt.Errorf("broadcast: %w", sendErr))
+	}
+	if sendErr != nil && alreadyKnown(sendErr) {
+		sendErr = nil
+	}
+	if sentHash == "" {
+		sentHash = hash
+	}
+	reason := "broadcast " + sentHash
+	if sendErr != nil {
+		// The node may have taken the tx before the call failed, so it is kept
+		// with its nonce: the tracker finds its receipt if it was mined and the
+		// nonce reconciler broadcasts it again if the node does not know it.
+		reason = fmt.Sprintf("broadcast %s unconfirmed: %v", sentHash, sendErr)
+	}
+	sentAt := time.Now().UTC()
+	if err := setStatus(ctx, s.repo, tx, entity.TxStatusSent, map[string]interface{}{
+		"tx_hash":  sentHash,
+		"sent_at":  sentAt,
+		"attempts": []entity.TxAttempt{newAttempt(tx, sentHash, rawHex, sentAt)},
+	}, audit(actorSigner, reason,
+		entity.TxSentEvent{BaseEvent: entity.BaseEvent{When: sentAt}, TxID: tx.ID, TxHash: sentHash})); err != nil {
+		return err
+	}
+
+	if sendErr != nil {
+		s.logger.Warn("broadcast outcome unknown, transaction kept as sent",
+			zap.String("tx_id", tx.ID), zap.String("chain", tx.Chain), zap.String("tx_hash", sentHash), zap.Error(sendErr))
+		return nil
+	}
+	s.logger.Info("transaction sent",
+		zap.String("tx_id", tx.ID), zap.String("chain", tx.Chain), zap.String("tx_hash", sentHash))
+	return nil
+}
+
+// RetryPending signs and sends the pending transactions created more than
+// retryAfter ago: those an earlier attempt left pending after a failed node
+// lookup, and those whose TxCreated event was never handled.
+func (s *TransactionService) RetryPending(ctx context.Context, retryAfter time.Duration) error {
+	if s.signer == nil {
+		return nil
+	}
+	pending, err := s.repo.ListByStatus(ctx, entity.TxStatusPending, 0)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-retryAfter)
+	var errs []error
+	for _, tx := range pending {
+		if tx.CreatedAt.After(cutoff) {
+			continue
+		}
+		if err := s.signAndSend(ctx, tx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryableError wraps a node or repository lookup that failed while
+// preparing a transaction and may succeed when tried again.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// prepare fills the fields needed for signing on tx and returns them as
+// repository updates. The nonce is reserved last so earlier failures do not
+// consume one. Failed lookups are returned as *retryableError.
+func (s *TransactionService) prepare(ctx context.Context, tx *entity.Transaction, allocate bool) (map[string]interface{}, error) {
+	from, err := s.signer.Address(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.From == "" {
+		tx.From = from
+	} else if !strings.EqualFold(tx.From, from) {
+		return nil, fmt.Errorf("%w %s", ErrForeignSender, from)
+	}
+	if tx.ChainID == nil {
+		if tx.ChainID, err = s.chain.GetChainID(ctx, tx.Chain); err != nil {
+			return nil, &retryableError{fmt.Errorf("chain id: %w", err)}
+		}
+	}
+	if tx.Gas == 0 {
+		if len(tx.Data) > 0 {
+			return nil, errors.New("gas limit is required for transactions with data")
+		}
+		tx.Gas = transferGas
+	}
+
+	updates := map[string]interface{}{
+		"from":     tx.From,
+		"chain_id": tx.ChainID,
+		"gas":      tx.Gas,
+	}
+	// fees are only estimated when the caller did not price the transaction
+	if tx.MaxFeePerGas == nil && (tx.GasPrice == nil || tx.GasPrice.Sign() == 0) {
+		tip, maxFee, err := s.chain.EstimateFees(ctx, tx.Chain, tx.FeeTier)
+		if err != nil {
+			return nil, &retryableError{fmt.Errorf("fees: %w", err)}
+		}
+		if tip == nil {
+			tx.GasPrice = maxFee
+		} else {
+			tx.GasPrice = nil
+			tx.MaxPriorityFeePerGas, tx.MaxFeePerGas = tip, maxFee
+		}
+	}
+	if tx.MaxFeePerGas != nil {
+		updates["max_fee_per_gas"] = tx.MaxFeePerGas
+		updates["max_priority_fee_per_gas"] = tx.MaxPriorityFeePerGas
+	} else {
+		updates["gas_price"] = tx.GasPrice
+	}
+	if allocate {
+		if tx.Nonce, err = s.nonces.Next(ctx, tx.Chain, tx.From); err != nil {
+			return nil, &retryableError{fmt.Errorf("nonce: %w", err)}
+		}
+	}
+	updates["nonce"] = tx.Nonce
+	return updates, nil
+}
+
+// fail marks tx as failed with cause together with a TxFailedEvent and
+// returns cause. It is only called when tx cannot be in a node's pool: before
+// it was broadcast, or after the node rejected it. The sender's nonce counter
+// is reset so the nonce tx reserved is handed out again.
+func (s *TransactionService) fail(ctx context.Context, tx *entity.Transaction, cause error) error {
+	s.nonces.Reset(tx.Chain, tx.From)
+	msg := cause.Error()
+	if err := setStatus(ctx, s.repo, tx, entity.TxStatusFailed, map[string]interface{}{
+		"error_message": msg,
+	}, audit(actorSigner, msg, entity.TxFailedEvent{BaseEvent: now(), TxID: tx.ID, Error: msg})); err != nil {
+		s.logger.Error("failed to mark transaction failed", zap.String("tx_id", tx.ID), zap.Error(err))
+	}
+	s.logger.Warn("transaction failed", zap.String("tx_id", tx.ID), zap.Error(cause))
+	return cause
+}
+
+// setStatus moves tx to status in repo with updates after checking the
+// transition against the status graph, and mirrors the new status and version
+// on tx. A stored tx is only updated at the version it was read at, so a
+// concurrent writer makes the call fail with ports.ErrConflict. The events of
+// meta are written to the outbox with the update and published by the
+// OutboxRelay.
+func setStatus(ctx context.Context, repo ports.TxRepositoryPort, tx *entity.Transaction, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
+	if err := entity.CheckTransition(tx.Status, status); err != nil {
+		return err
+	}
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	meta.Version = tx.Version
+	if err := repo.UpdateStatus(ctx, tx.ID, status, updates, meta); err != nil {
+		return err
+	}
+	tx.Status = status
+	if tx.Version > 0 {
+		tx.Version++
+	}
+	return nil
+}
+
+// GetTransaction returns the transaction with the given ID or
+// ErrTransactionNotFound.
+func (s *TransactionService) GetTransaction(ctx context.Context, txID string) (*entity.Transaction, error) {
+	tx, err := s.repo.FindByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// Sender returns the address tx will be sent from: its From, or else the
+// signer's address, which signing fills in. A From that is not the signer's
+// address is reported with ErrForeignSender and the signer's address.
+func (s *TransactionService) Sender(ctx context.Context, tx *entity.Transaction) (string, error) {
+	if s.signer == nil {
+		if tx.From != "" {
+			return tx.From, nil
+		}
+		return "", ErrSignerUnavailable
+	}
+	addr, err := s.signer.Address(ctx)
+	if err != nil {
+		return tx.From, err
+	}
+	if tx.From != "" && !strings.EqualFold(tx.From, addr) {
+		return addr, ErrForeignSender
+	}
+	return addr, nil
+}
+
+// FindByHash returns the transaction that broadcast hash, as its current hash
+// or as an earlier attempt, or ErrTransactionNotFound.
+func (s *TransactionService) FindByHash(ctx context.Context, hash string) (*entity.Transaction, error) {
+	tx, err := s.repo.FindByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// History returns the status changes of a transaction, oldest first.
+func (s *TransactionService) History(ctx context.Context, txID string) ([]entity.StatusChange, error) {
+	tx, err := s.GetTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.History(ctx, tx.ID)
+}
+
+// List returns one page of the transactions matching filter, newest first.
+func (s *TransactionService) List(ctx context.Context, filter ports.TxFilter) (ports.TxPage, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// audit returns the status history actor and reason of an update together
+// with its events.
+func audit(actor, reason string, events ...entity.Event) ports.UpdateMeta {
+	return ports.UpdateMeta{Actor: actor, Reason: reason, Events: events}
+}
+
+func now() entity.BaseEvent {
+	return entity.BaseEvent{When: time.Now().UTC()}
+}
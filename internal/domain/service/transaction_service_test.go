@@ -2,10 +2,19 @@ package service
 
 import (
 	"ChainConnector/internal/domain/entity"
+	"ChainConnector/internal/domain/ports"
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -14,15 +23,36 @@ type mockRepo struct {
 	byID    map[string]*entity.Transaction
 	byHash  map[string]*entity.Transaction
 	updated map[string][]interface{}
+	// updates and metas record the update maps and metadata passed to
+	// UpdateStatus, per tx id
+	updates map[string][]map[string]interface{}
+	metas   map[string][]ports.UpdateMeta
+	// filter records the last List filter and lists counts the calls
+	filter ports.TxFilter
+	lists  int
+	// outbox receives the events of successful writes, as if relayed at once
+	outbox *fakeBus
+	// conflicts fails the next UpdateStatus calls with ports.ErrConflict
+	conflicts int
+}
+
+// relay passes events to m.outbox, if set.
+func (m *mockRepo) relay(events []entity.Event) {
+	if m.outbox == nil {
+		return
+	}
+	for _, evt := range events {
+		_ = m.outbox.Publish(context.Background(), evt.Type(), evt)
+	}
 }
 
 // repoErr is a test repo implementation that returns an error on Save.
 type repoErr struct{}
 
-func (r *repoErr) Save(ctx context.Context, tx *entity.Transaction) error {
+func (r *repoErr) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
 	return errors.New("save failed")
 }
-func (r *repoErr) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}) error {
+func (r *repoErr) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
 	return nil
 }
 func (r *repoErr) FindByID(ctx context.Context, id string) (*entity.Transaction, error) {
@@ -31,11 +61,23 @@ func (r *repoErr) FindByID(ctx context.Context, id string) (*entity.Transaction,
 func (r *repoErr) FindByHash(ctx context.Context, hash string) (*entity.Transaction, error) {
 	return nil, nil
 }
+func (r *repoErr) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	return nil, errors.New("lookup failed")
+}
 func (r *repoErr) ListPending(ctx context.Context, limit int) ([]*entity.Transaction, error) {
 	return nil, nil
 }
+func (r *repoErr) ListByStatus(ctx context.Context, status entity.TxStatus, limit int) ([]*entity.Transaction, error) {
+	return nil, errors.New("list failed")
+}
+func (r *repoErr) List(ctx context.Context, filter ports.TxFilter) (ports.TxPage, error) {
+	return ports.TxPage{}, errors.New("list failed")
+}
+func (r *repoErr) History(ctx context.Context, txID string) ([]entity.StatusChange, error) {
+	return nil, errors.New("history failed")
+}
 
-func (m *mockRepo) Save(ctx context.Context, tx *entity.Transaction) error {
+func (m *mockRepo) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
 	if m.saved == nil {
 		m.saved = tx
 	}
@@ -43,16 +85,36 @@ func (m *mockRepo) Save(ctx context.Context, tx *entity.Transaction) error {
 		m.byID = map[string]*entity.Transaction{}
 	}
 	m.byID[tx.ID] = tx
+	m.relay(events)
 	return nil
 }
-func (m *mockRepo) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}) error {
+func (m *mockRepo) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
+	if m.conflicts > 0 {
+		m.conflicts--
+		return ports.ErrConflict
+	}
 	if m.updated == nil {
 		m.updated = map[string][]interface{}{}
 	}
 	m.updated[txID] = append(m.updated[txID], status)
+	if m.updates == nil {
+		m.updates = map[string][]map[string]interface{}{}
+	}
+	m.updates[txID] = append(m.updates[txID], updates)
+	if m.metas == nil {
+		m.metas = map[string][]ports.UpdateMeta{}
+	}
+	m.metas[txID] = append(m.metas[txID], meta)
 	if tx, ok := m.byID[txID]; ok {
 		tx.Status = status
+		if h, ok := updates["tx_hash"].(string); ok {
+			tx.TxHash = h
+		}
+		if a, ok := updates["attempts"].([]entity.TxAttempt); ok {
+			tx.Attempts = a
+		}
 	}
+	m.relay(meta.Events)
 	return nil
 }
 func (m *mockRepo) FindByID(ctx context.Context, id string) (*entity.Transaction, error) {
@@ -71,10 +133,21 @@ func (m *mockRepo) FindByHash(ctx context.Context, hash string) (*entity.Transac
 	}
 	return m.byHash[hash], nil
 }
+func (m *mockRepo) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	for _, tx := range m.byID {
+		if tx.IdempotencyKey == key {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
 func (m *mockRepo) ListPending(ctx context.Context, limit int) ([]*entity.Transaction, error) {
+	return m.ListByStatus(ctx, entity.TxStatusPending, limit)
+}
+func (m *mockRepo) ListByStatus(ctx context.Context, status entity.TxStatus, limit int) ([]*entity.Transaction, error) {
 	var out []*entity.Transaction
 	for _, tx := range m.byID {
-		if tx.Status == entity.TxStatusPending {
+		if tx.Status == status {
 			out = append(out, tx)
 			if limit > 0 && len(out) >= limit {
 				break
@@ -84,16 +157,47 @@ func (m *mockRepo) ListPending(ctx context.Context, limit int) ([]*entity.Transa
 	return out, nil
 }
 
+// List matches filter.Statuses and pages by id, using the last id of a page
+// as its cursor.
+func (m *mockRepo) List(ctx context.Context, filter ports.TxFilter) (ports.TxPage, error) {
+	m.filter = filter
+	m.lists++
+	var out []*entity.Transaction
+	for _, tx := range m.byID {
+		if (len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, tx.Status)) && tx.ID > filter.Cursor {
+			out = append(out, tx)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	page := ports.TxPage{Transactions: out}
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		page.Transactions = out[:filter.Limit]
+		page.NextCursor = out[filter.Limit-1].ID
+	}
+	return page, nil
+}
+
+// History derives the status changes from the recorded UpdateStatus calls.
+func (m *mockRepo) History(ctx context.Context, txID string) ([]entity.StatusChange, error) {
+	var out []entity.StatusChange
+	for i, st := range m.updated[txID] {
+		meta := m.metas[txID][i]
+		out = append(out, entity.StatusChange{TxID: txID, To: st.(entity.TxStatus), Actor: meta.Actor, Reason: meta.Reason})
+	}
+	return out, nil
+}
+
 func TestCreateTransaction_nil(t *testing.T) {
-	svc := NewTransactionService(&mockRepo{}, zap.NewNop())
+	svc := NewTransactionService(&mockRepo{}, nil, nil, zap.NewNop())
 	if err := svc.CreateTransaction(context.Background(), nil); err == nil {
 		t.Fatal("expected error for nil tx")
 	}
 }
 
 func TestCreateTransaction_success(t *testing.T) {
-	repo := &mockRepo{byID: map[string]*entity.Transaction{}}
-	svc := NewTransactionService(repo, zap.NewNop())
+	bus := &fakeBus{}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{}, outbox: bus}
+	svc := NewTransactionService(repo, nil, nil, zap.NewNop())
 
 	tx := &entity.Transaction{ID: "t1"}
 	if err := svc.CreateTransaction(context.Background(), tx); err != nil {
@@ -102,15 +206,564 @@ func TestCreateTransaction_success(t *testing.T) {
 	if tx.Status != entity.TxStatusPending {
 		t.Fatalf("expected pending, got %v", tx.Status)
 	}
-	// if len(pub.published) == 0 {
-	// 	t.Fatalf("expected event published")
-	// }
+	if len(bus.topics) != 1 || bus.topics[0] != "TxCreated" {
+		t.Fatalf("expected TxCreated event published, got %v", bus.topics)
+	}
+
+	anonymous := &entity.Transaction{}
+	if err := svc.CreateTransaction(context.Background(), anonymous); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uuid.Parse(anonymous.ID); err != nil || repo.byID[anonymous.ID] == nil {
+		t.Fatalf("expected a stored transaction with a generated UUID, got %q", anonymous.ID)
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	repo := &mockRepo{byID: map[string]*entity.Transaction{"t1": pendingTx("t1")}}
+	svc := NewTransactionService(repo, nil, nil, zap.NewNop())
+	if tx, err := svc.GetTransaction(context.Background(), "t1"); err != nil || tx.ID != "t1" {
+		t.Fatalf("unexpected result %+v %v", tx, err)
+	}
+	missing := NewTransactionService(&repoErr{}, nil, nil, zap.NewNop())
+	if _, err := missing.GetTransaction(context.Background(), "nope"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+
+	repo.byHash = map[string]*entity.Transaction{"0xa": repo.byID["t1"]}
+	if tx, err := svc.FindByHash(context.Background(), "0xa"); err != nil || tx.ID != "t1" {
+		t.Fatalf("unexpected result %+v %v", tx, err)
+	}
+	if _, err := svc.FindByHash(context.Background(), "0xb"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestCreateTransaction_idempotencyKey(t *testing.T) {
+	bus := &fakeBus{}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{}, outbox: bus}
+	svc := NewTransactionService(repo, nil, nil, zap.NewNop())
+	ctx := context.Background()
+	request := func(id string, value int64) *entity.Transaction {
+		to := "0xTo"
+		return &entity.Transaction{ID: id, Chain: "eth", To: &to, Value: big.NewInt(value), IdempotencyKey: "k1"}
+	}
+
+	first := request("t1", 5)
+	if err := svc.CreateTransaction(ctx, first); err != nil || first.RequestHash == "" {
+		t.Fatalf("create: %v %+v", err, first)
+	}
+	retry := request("t2", 5)
+	*retry.To = "0xto" // addresses compare case-insensitively
+	if err := svc.CreateTransaction(ctx, retry); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if retry.ID != "t1" || len(repo.byID) != 1 || len(bus.topics) != 1 {
+		t.Fatalf("expected the retry to return the original, got %+v (%d stored, events %v)", retry, len(repo.byID), bus.topics)
+	}
+	if err := svc.CreateTransaction(ctx, request("t3", 6)); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	if got, err := svc.FindIdempotent(ctx, &entity.Transaction{}); got != nil || err != nil {
+		t.Fatalf("expected no lookup without a key, got %v %v", got, err)
+	}
+	failing := NewTransactionService(&repoErr{}, nil, nil, zap.NewNop())
+	if err := failing.CreateTransaction(ctx, request("t4", 5)); err == nil || err.Error() != "lookup failed" {
+		t.Fatalf("expected lookup error, got %v", err)
+	}
+}
+
+// racingRepo stores a transaction with the same key just before Save, like a
+// concurrent request would.
+type racingRepo struct {
+	mockRepo
+	winner *entity.Transaction
+}
+
+func (r *racingRepo) Save(ctx context.Context, tx *entity.Transaction, events ...entity.Event) error {
+	if r.winner != nil {
+		_ = r.mockRepo.Save(ctx, r.winner)
+		r.winner = nil
+		return ports.ErrDuplicateIdempotencyKey
+	}
+	return r.mockRepo.Save(ctx, tx, events...)
+}
+
+func TestCreateTransaction_idempotencyRace(t *testing.T) {
+	tx := &entity.Transaction{ID: "loser", Chain: "ETH", IdempotencyKey: "k"}
+	winner := &entity.Transaction{ID: "winner", Chain: "ETH", IdempotencyKey: "k", RequestHash: requestFingerprint(tx)}
+	repo := &racingRepo{mockRepo: mockRepo{byID: map[string]*entity.Transaction{}}, winner: winner}
+	svc := NewTransactionService(repo, nil, nil, zap.NewNop())
+	if err := svc.CreateTransaction(context.Background(), tx); err != nil || tx.ID != "winner" {
+		t.Fatalf("expected the stored transaction, got %+v %v", tx, err)
+	}
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	to := "0xto"
+	base := entity.Transaction{Chain: "ETH", To: &to, Value: big.NewInt(1), Gas: 21000}
+	other := base
+	other.Value = big.NewInt(2)
+	split := base
+	split.Chain, split.From = "ET", "H"
+	caller := base
+	caller.RequestedBy = "api-key:other"
+	if requestFingerprint(&base) == requestFingerprint(&other) || requestFingerprint(&base) == requestFingerprint(&split) ||
+		requestFingerprint(&base) == requestFingerprint(&caller) {
+		t.Fatalf("expected different requests to have different fingerprints")
+	}
+	same := base
+	same.ID, same.Nonce, same.Status = "x", 9, entity.TxStatusSent
+	if requestFingerprint(&base) != requestFingerprint(&same) {
+		t.Fatalf("expected server-assigned fields to be ignored")
+	}
+	fast, standard := base, base
+	fast.FeeTier, standard.FeeTier = entity.FeeTierFast, entity.FeeTierStandard
+	if requestFingerprint(&base) == requestFingerprint(&fast) || requestFingerprint(&base) != requestFingerprint(&standard) {
+		t.Fatalf("expected only a non-standard fee tier to change the fingerprint")
+	}
 }
 
 func TestCreateTransaction_SaveError(t *testing.T) {
-	svc := NewTransactionService(&repoErr{}, zap.NewNop())
+	svc := NewTransactionService(&repoErr{}, nil, nil, zap.NewNop())
 	tx := &entity.Transaction{ID: "t2"}
 	if err := svc.CreateTransaction(context.Background(), tx); err == nil {
 		t.Fatalf("expected save error propagated")
 	}
 }
+
+// fakeChain is a BlockchainPort double with canned answers.
+type fakeChain struct {
+	nonce    uint64
+	nonceErr error
+	chainID  *big.Int
+	tip      *big.Int
+	maxFee   *big.Int
+	feeErr   error
+	// tiers lists the fee tier of every estimate
+	tiers    []entity.FeeTier
+	sendHash string
+	sendErr  error
+	// onSend runs on every broadcast, before it answers
+	onSend func()
+	sent   [][]byte
+	sentTo []string
+	// receipts by tx hash and latest block per chain, for the tracker
+	receipts   map[string]*entity.Receipt
+	receiptErr error
+	heads      map[string]uint64
+	headCalls  int
+}
+
+func (f *fakeChain) GetBalance(ctx context.Context, chain, address string) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (f *fakeChain) GetNonce(ctx context.Context, chain, address string) (uint64, error) {
+	return f.nonce, f.nonceErr
+}
+func (f *fakeChain) GetTransactionReceipt(ctx context.Context, chain, txHash string) (*entity.Receipt, error) {
+	if f.receiptErr != nil {
+		return nil, f.receiptErr
+	}
+	return f.receipts[txHash], nil
+}
+func (f *fakeChain) GetLogs(ctx context.Context, chain string, flt entity.LogFilter) ([]entity.Log, error) {
+	return nil, nil
+}
+func (f *fakeChain) GetChainID(ctx context.Context, chain string) (*big.Int, error) {
+	if f.chainID == nil {
+		return nil, errors.New("no chain id")
+	}
+	return f.chainID, nil
+}
+func (f *fakeChain) GetBlockNumber(ctx context.Context, chain string) (uint64, error) {
+	f.headCalls++
+	head, ok := f.heads[chain]
+	if !ok {
+		return 0, errors.New("unknown chain")
+	}
+	return head, nil
+}
+func (f *fakeChain) EstimateFees(ctx context.Context, chain string, tier entity.FeeTier) (*big.Int, *big.Int, error) {
+	f.tiers = append(f.tiers, tier)
+	return f.tip, f.maxFee, f.feeErr
+}
+func (f *fakeChain) SendRawTransaction(ctx context.Context, chain string, signedTx []byte) (string, error) {
+	f.sent = append(f.sent, signedTx)
+	f.sentTo = append(f.sentTo, chain)
+	if f.onSend != nil {
+		f.onSend()
+	}
+	return f.sendHash, f.sendErr
+}
+func (f *fakeChain) SendRawTransactionHex(ctx context.Context, chain, signedTxHex string) (string, error) {
+	return f.sendHash, f.sendErr
+}
+
+// fakeSigner "signs" by returning a fixed payload derived from the nonce.
+type fakeSigner struct {
+	addr    string
+	signErr error
+	signed  []*entity.Transaction
+}
+
+func (f *fakeSigner) SignTransaction(ctx context.Context, tx *entity.Transaction) ([]byte, string, error) {
+	if f.signErr != nil {
+		return nil, "", f.signErr
+	}
+	cp := *tx
+	f.signed = append(f.signed, &cp)
+	return []byte{0xde, 0xad, byte(tx.Nonce)}, "0xsigned", nil
+}
+func (f *fakeSigner) Address(ctx context.Context) (string, error) { return f.addr, nil }
+func (f *fakeSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return nil, nil
+}
+
+type fakeBus struct {
+	mu     sync.Mutex
+	topics []string
+	events []interface{}
+	full   bool
+}
+
+func (b *fakeBus) Publish(ctx context.Context, topic string, payload interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.full {
+		return ports.ErrBusFull
+	}
+	b.topics = append(b.topics, topic)
+	b.events = append(b.events, payload)
+	return nil
+}
+func (b *fakeBus) Subscribe(topic string, handler ports.EventHandler) func() { return func() {} }
+func (b *fakeBus) Close() error                                              { return nil }
+
+func newSignAndSendFixture(tx *entity.Transaction) (*TransactionService, *mockRepo, *fakeChain, *fakeSigner, *fakeBus) {
+	bus := &fakeBus{}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{tx.ID: tx}, outbox: bus}
+	chain := &fakeChain{
+		nonce:    7,
+		chainID:  big.NewInt(11155111),
+		tip:      big.NewInt(2),
+		maxFee:   big.NewInt(50),
+		sendHash: "0xsent",
+	}
+	signer := &fakeSigner{addr: "0xSigner"}
+	return NewTransactionService(repo, chain, signer, zap.NewNop()), repo, chain, signer, bus
+}
+
+func pendingTx(id string) *entity.Transaction {
+	to := "0xto"
+	return &entity.Transaction{ID: id, Chain: "ETH", To: &to, Value: big.NewInt(1), Status: entity.TxStatusPending}
+}
+
+func TestSignAndSend_success(t *testing.T) {
+	tx := pendingTx("t1")
+	tx.FeeTier = entity.FeeTierFast
+	svc, repo, chain, signer, bus := newSignAndSendFixture(tx)
+
+	if err := svc.SignAndSend(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.updated["t1"]; len(got) != 2 || got[0] != entity.TxStatusSigned || got[1] != entity.TxStatusSent {
+		t.Fatalf("expected Signed then Sent, got %v", got)
+	}
+	signedUpd := repo.updates["t1"][0]
+	if signedUpd["raw_tx_hex"] != "0xdead07" || signedUpd["tx_hash"] != "0xsigned" || signedUpd["nonce"] != uint64(7) {
+		t.Fatalf("unexpected signed updates: %v", signedUpd)
+	}
+	if signedUpd["from"] != "0xSigner" || signedUpd["gas"] != uint64(transferGas) {
+		t.Fatalf("expected from and gas to be filled: %v", signedUpd)
+	}
+	sentUpd := repo.updates["t1"][1]
+	if sentUpd["tx_hash"] != "0xsent" || sentUpd["sent_at"] == nil {
+		t.Fatalf("unexpected sent updates: %v", sentUpd)
+	}
+
+	// the signer saw the EIP-1559 fields from EstimateFees of the tx's tier
+	st := signer.signed[0]
+	if len(chain.tiers) != 1 || chain.tiers[0] != tx.FeeTier {
+		t.Fatalf("expected one estimate for the tx's tier, got %v", chain.tiers)
+	}
+	if st.MaxFeePerGas.Int64() != 50 || st.MaxPriorityFeePerGas.Int64() != 2 || st.GasPrice != nil {
+		t.Fatalf("unexpected fees on signed tx: %+v", st)
+	}
+	if st.ChainID.Int64() != 11155111 {
+		t.Fatalf("expected chain id to be filled, got %v", st.ChainID)
+	}
+	if len(chain.sent) != 1 || chain.sentTo[0] != "ETH" {
+		t.Fatalf("expected one broadcast on ETH, got %v", chain.sentTo)
+	}
+	if strings.Join(bus.topics, ",") != "TxSigned,TxSent" {
+		t.Fatalf("unexpected events: %v", bus.topics)
+	}
+	if svc.nonces.InFlight("ETH", "0xSigner", 7) {
+		t.Fatalf("expected the nonce to be released once sent")
+	}
+	if evt, ok := bus.events[1].(entity.TxSentEvent); !ok || evt.TxHash != "0xsent" || evt.TxID != "t1" {
+		t.Fatalf("unexpected sent event: %+v", bus.events[1])
+	}
+}
+
+func TestHistory(t *testing.T) {
+	svc, _, _, _, _ := newSignAndSendFixture(pendingTx("t1"))
+	if err := svc.SignAndSend(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history, err := svc.History(context.Background(), "t1")
+	if err != nil || len(history) != 2 {
+		t.Fatalf("expected two status changes, got %+v %v", history, err)
+	}
+	if history[0].Actor != actorSigner || history[0].Reason != "signed with nonce 7" ||
+		history[1].To != entity.TxStatusSent || history[1].Reason != "broadcast 0xsent" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	missing := NewTransactionService(&repoErr{}, nil, nil, zap.NewNop())
+	if _, err := missing.History(context.Background(), "nope"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	repo := &mockRepo{byID: map[string]*entity.Transaction{"t1": pendingTx("t1")}}
+	svc := NewTransactionService(repo, nil, nil, zap.NewNop())
+	filter := ports.TxFilter{Chain: "ETH", Limit: 10}
+	page, err := svc.List(context.Background(), filter)
+	if err != nil || len(page.Transactions) != 1 || repo.filter.Chain != "ETH" || repo.filter.Limit != 10 {
+		t.Fatalf("expected filter to reach the repo, got %+v %+v %v", repo.filter, page, err)
+	}
+	if _, err := NewTransactionService(&repoErr{}, nil, nil, zap.NewNop()).List(context.Background(), filter); err == nil {
+		t.Fatalf("expected repo error to propagate")
+	}
+}
+
+func TestSignAndSend_legacyFeesAndCallerPricing(t *testing.T) {
+	// legacy chain: EstimateFees returns no tip
+	tx := pendingTx("t1")
+	svc, repo, chain, signer, _ := newSignAndSendFixture(tx)
+	chain.tip, chain.maxFee = nil, big.NewInt(9)
+	chain.sendHash = "" // node returned no hash: the signer hash is kept
+	if err := svc.SignAndSend(context.Background(), "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signer.signed[0].GasPrice.Int64() != 9 || signer.signed[0].MaxFeePerGas != nil {
+		t.Fatalf("expected legacy gas price, got %+v", signer.signed[0])
+	}
+	if repo.updates["t1"][0]["gas_price"].(*big.Int).Int64() != 9 {
+		t.Fatalf("expected gas price update, got %v", repo.updates["t1"][0])
+	}
+	if repo.updates["t1"][1]["tx_hash"] != "0xsigned" {
+		t.Fatalf("expected signer hash, got %v", repo.updates["t1"][1])
+	}
+
+	// caller priced the tx: no estimation
+	tx2 := pendingTx("t2")
+	tx2.GasPrice = big.NewInt(3)
+	svc, _, chain, signer, _ = newSignAndSendFixture(tx2)
+	chain.feeErr = errors.New("must not be called")
+	if err := svc.SignAndSend(context.Background(), "t2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signer.signed[0].GasPrice.Int64() != 3 {
+		t.Fatalf("expected caller gas price to be kept")
+	}
+}
+
+// rejected is a broadcast error of a node refusing the transaction.
+func rejected(msg string) error {
+	return fmt.Errorf("%w: %s", ports.ErrTxRejected, msg)
+}
+
+func TestSignAndSend_broadcastRejectionMarksFailed(t *testing.T) {
+	tx := pendingTx("t1")
+	svc, repo, chain, _, bus := newSignAndSendFixture(tx)
+	chain.sendErr = rejected("nonce too low")
+
+	if err := svc.SignAndSend(context.Background(), "t1"); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	if got := repo.updated["t1"]; len(got) != 2 || got[1] != entity.TxStatusFailed {
+		t.Fatalf("expected Signed then Failed, got %v", got)
+	}
+	if msg := repo.updates["t1"][1]["error_message"].(string); !strings.Contains(msg, "nonce too low") {
+		t.Fatalf("unexpected error message %q", msg)
+	}
+	if bus.topics[len(bus.topics)-1] != "TxFailed" {
+		t.Fatalf("expected TxFailed event, got %v", bus.topics)
+	}
+}
+
+func TestSignAndSend_unclearBroadcastKeepsSent(t *testing.T) {
+	for name, sendErr := range map[string]error{
+		"timeout":       context.DeadlineExceeded,
+		"already known": errors.New("rpc error: -32000 already known"),
+	} {
+		tx := pendingTx("t1")
+		svc, repo, chain, _, bus := newSignAndSendFixture(tx)
+		chain.sendErr = sendErr
+		chain.sendHash = ""
+
+		if err := svc.SignAndSend(context.Background(), "t1"); err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if got := repo.updated["t1"]; len(got) != 2 || got[1] != entity.TxStatusSent {
+			t.Fatalf("%s: expected Signed then Sent, got %v", name, got)
+		}
+		sentUpd := repo.updates["t1"][1]
+		attempts := sentUpd["attempts"].([]entity.TxAttempt)
+		if sentUpd["tx_hash"] != "0xsigned" || len(attempts) != 1 || attempts[0].RawTxHex != "0xdead07" {
+			t.Fatalf("%s: expected the signed hash and payload to be kept, got %v", name, sentUpd)
+		}
+		reason := repo.metas["t1"][1].Reason
+		if unclear := strings.Contains(reason, "unconfirmed"); unclear != (name == "timeout") {
+			t.Fatalf("%s: unexpected reason %q", name, reason)
+		}
+		if bus.topics[len(bus.topics)-1] != "TxSent" {
+			t.Fatalf("%s: expected TxSent event, got %v", name, bus.topics)
+		}
+
+		// the nonce stays taken
+		chain.sendErr, chain.nonce = nil, 0
+		repo.byID["t2"] = pendingTx("t2")
+		if err := svc.SignAndSend(context.Background(), "t2"); err != nil || repo.byID["t2"].Nonce != 8 {
+			t.Fatalf("%s: expected the next nonce, got %d %v", name, repo.byID["t2"].Nonce, err)
+		}
+	}
+}
+
+func TestSignAndSend_preparationAndSigningFailures(t *testing.T) {
+	cases := map[string]func(tx *entity.Transaction, c *fakeChain, s *fakeSigner){
+		"gas":  func(tx *entity.Transaction, c *fakeChain, s *fakeSigner) { tx.Data = []byte{1} },
+		"sign": func(tx *entity.Transaction, c *fakeChain, s *fakeSigner) { s.signErr = errors.New("hsm offline") },
+		"from": func(tx *entity.Transaction, c *fakeChain, s *fakeSigner) { tx.From = "0xSomeoneElse" },
+	}
+	for name, mutate := range cases {
+		tx := pendingTx("t1")
+		svc, repo, chain, signer, _ := newSignAndSendFixture(tx)
+		mutate(tx, chain, signer)
+		if err := svc.SignAndSend(context.Background(), "t1"); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if got := repo.updated["t1"]; len(got) != 1 || got[0] != entity.TxStatusFailed {
+			t.Fatalf("%s: expected Failed, got %v", name, got)
+		}
+		if len(chain.sent) != 0 {
+			t.Fatalf("%s: nothing should be broadcast", name)
+		}
+	}
+}
+
+func TestSignAndSend_lookupFailuresLeavePending(t *testing.T) {
+	cases := map[string]func(c *fakeChain){
+		"nonce":    func(c *fakeChain) { c.nonceErr = errors.New("rpc down") },
+		"chain id": func(c *fakeChain) { c.chainID = nil },
+		"fees":     func(c *fakeChain) { c.feeErr = errors.New("no fees") },
+	}
+	for name, mutate := range cases {
+		tx := pendingTx("t1")
+		svc, repo, chain, _, _ := newSignAndSendFixture(tx)
+		mutate(chain)
+		if err := svc.SignAndSend(context.Background(), "t1"); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if got := repo.updated["t1"]; len(got) != 0 || tx.Status != entity.TxStatusPending {
+			t.Fatalf("%s: expected the tx to stay pending, got %v", name, got)
+		}
+		if len(chain.sent) != 0 {
+			t.Fatalf("%s: nothing should be broadcast", name)
+		}
+	}
+}
+
+func TestRetryPending(t *testing.T) {
+	stale := pendingTx("stale")
+	stale.CreatedAt = time.Now().Add(-time.Hour)
+	svc, repo, chain, _, _ := newSignAndSendFixture(stale)
+	fresh := pendingTx("fresh")
+	fresh.CreatedAt = time.Now()
+	repo.byID["fresh"] = fresh
+	ctx := context.Background()
+
+	chain.feeErr = errors.New("no fees")
+	if err := svc.RetryPending(ctx, time.Minute); err == nil || stale.Status != entity.TxStatusPending {
+		t.Fatalf("expected the lookup to fail again, got %v %s", err, stale.Status)
+	}
+	chain.feeErr = nil
+	if err := svc.RetryPending(ctx, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stale.Status != entity.TxStatusSent || fresh.Status != entity.TxStatusPending || len(chain.sent) != 1 {
+		t.Fatalf("expected only the stale tx to be sent, got %s %s", stale.Status, fresh.Status)
+	}
+
+	if err := NewTransactionService(&repoErr{}, chain, &fakeSigner{}, zap.NewNop()).RetryPending(ctx, 0); err == nil {
+		t.Fatalf("expected repository error")
+	}
+}
+
+func TestSignAndSend_rejections(t *testing.T) {
+	tx := pendingTx("t1")
+	svc, _, _, _, _ := newSignAndSendFixture(tx)
+	if err := svc.SignAndSend(context.Background(), "missing"); err == nil {
+		t.Fatal("expected error for unknown tx")
+	}
+
+	tx.Status = entity.TxStatusSent
+	if err := svc.SignAndSend(context.Background(), "t1"); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected ErrNotPending, got %v", err)
+	}
+
+	noSigner := NewTransactionService(&mockRepo{}, &fakeChain{}, nil, zap.NewNop())
+	if err := noSigner.SignAndSend(context.Background(), "t1"); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("expected ErrSignerUnavailable, got %v", err)
+	}
+
+	nilRepo := NewTransactionService(&repoErr{}, &fakeChain{}, &fakeSigner{}, zap.NewNop())
+	if err := nilRepo.SignAndSend(context.Background(), "t1"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestSetStatus_rejectsInvalidTransition(t *testing.T) {
+	tx := &entity.Transaction{ID: "t1", Status: entity.TxStatusConfirmed}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{"t1": tx}}
+	err := setStatus(context.Background(), repo, tx, entity.TxStatusFailed, nil, ports.UpdateMeta{})
+	if !errors.Is(err, entity.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if len(repo.updated["t1"]) != 0 || tx.Status != entity.TxStatusConfirmed {
+		t.Fatalf("expected no repository update, got %v", repo.updated["t1"])
+	}
+
+	if err := setStatus(context.Background(), repo, &entity.Transaction{ID: "t1", Status: entity.TxStatusSent}, entity.TxStatusSent, nil, ports.UpdateMeta{}); err != nil {
+		t.Fatalf("expected sent to sent to be allowed, got %v", err)
+	}
+}
+
+// conflictRepo fails every UpdateStatus as if another writer got there first.
+type conflictRepo struct{ mockRepo }
+
+func (r *conflictRepo) UpdateStatus(ctx context.Context, txID string, status entity.TxStatus, updates map[string]interface{}, meta ports.UpdateMeta) error {
+	return ports.ErrConflict
+}
+
+func TestSetStatus_versions(t *testing.T) {
+	tx := &entity.Transaction{ID: "t1", Status: entity.TxStatusSent, Version: 3}
+	repo := &mockRepo{byID: map[string]*entity.Transaction{"t1": tx}}
+	if err := setStatus(context.Background(), repo, tx, entity.TxStatusConfirmed, nil, ports.UpdateMeta{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.metas["t1"][0].Version != 3 || tx.Version != 4 {
+		t.Fatalf("expected update at version 3 and tx at 4, got %+v %d", repo.metas["t1"][0], tx.Version)
+	}
+
+	stale := &entity.Transaction{ID: "t2", Status: entity.TxStatusSent, Version: 1}
+	err := setStatus(context.Background(), &conflictRepo{}, stale, entity.TxStatusConfirmed, nil, ports.UpdateMeta{})
+	if !errors.Is(err, ports.ErrConflict) || stale.Status != entity.TxStatusSent || stale.Version != 1 {
+		t.Fatalf("expected conflict to leave tx untouched, got %v %+v", err, stale)
+	}
+}
@@ -0,0 +1,6 @@
+-- Migration: revert 0001_create_transactions
+-- Drops the transactions table with its trigger and trigger function. The
+-- pgcrypto extension is left installed as other schemas may use it.
+
+DROP TABLE IF EXISTS transactions;
+DROP FUNCTION IF EXISTS ecs_set_updated_at();
@@ -0,0 +1,26 @@
+-- Migration: revert 0002_align_transactions
+-- Moves the added columns back into payload before dropping them.
+
+DROP INDEX IF EXISTS idx_transactions_attempts;
+DROP INDEX IF EXISTS idx_transactions_chain_from_nonce;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+
+UPDATE transactions SET payload = COALESCE(payload, '{}'::jsonb) || jsonb_strip_nulls(jsonb_build_object(
+  'chain', chain,
+  'max_fee_per_gas', max_fee_per_gas,
+  'max_priority_fee_per_gas', max_priority_fee_per_gas,
+  'data', replace(encode(data, 'base64'), E'\n', ''),
+  'sent_at', sent_at,
+  'confirmed_at', confirmed_at,
+  'error_message', error_message
+));
+
+ALTER TABLE transactions
+  DROP COLUMN IF EXISTS chain,
+  DROP COLUMN IF EXISTS max_fee_per_gas,
+  DROP COLUMN IF EXISTS max_priority_fee_per_gas,
+  DROP COLUMN IF EXISTS data,
+  DROP COLUMN IF EXISTS sent_at,
+  DROP COLUMN IF EXISTS confirmed_at,
+  DROP COLUMN IF EXISTS error_message;
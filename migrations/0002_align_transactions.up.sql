@@ -0,0 +1,34 @@
+-- Migration: align transactions with entity.Transaction
+-- Adds the columns that were kept in payload, backfills them from payload and
+-- restricts status to the values of TxStatus.String().
+
+ALTER TABLE transactions
+  ADD COLUMN IF NOT EXISTS chain text,
+  ADD COLUMN IF NOT EXISTS max_fee_per_gas numeric,
+  ADD COLUMN IF NOT EXISTS max_priority_fee_per_gas numeric,
+  ADD COLUMN IF NOT EXISTS data bytea,
+  ADD COLUMN IF NOT EXISTS sent_at timestamptz,
+  ADD COLUMN IF NOT EXISTS confirmed_at timestamptz,
+  ADD COLUMN IF NOT EXISTS error_message text;
+
+UPDATE transactions SET
+  chain = COALESCE(chain, payload->>'chain'),
+  max_fee_per_gas = COALESCE(max_fee_per_gas, (payload->>'max_fee_per_gas')::numeric),
+  max_priority_fee_per_gas = COALESCE(max_priority_fee_per_gas, (payload->>'max_priority_fee_per_gas')::numeric),
+  data = COALESCE(data, decode(payload->>'data', 'base64')),
+  sent_at = COALESCE(sent_at, (payload->>'sent_at')::timestamptz),
+  confirmed_at = COALESCE(confirmed_at, (payload->>'confirmed_at')::timestamptz),
+  error_message = COALESCE(error_message, payload->>'error_message'),
+  payload = payload - 'chain' - 'max_fee_per_gas' - 'max_priority_fee_per_gas' - 'data'
+    - 'sent_at' - 'confirmed_at' - 'error_message'
+WHERE payload ?| ARRAY['chain', 'max_fee_per_gas', 'max_priority_fee_per_gas', 'data',
+  'sent_at', 'confirmed_at', 'error_message'];
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+  CHECK (status IN ('unknown', 'pending', 'signed', 'sent', 'confirmed', 'failed', 'cancelled'));
+
+-- nonce reconciliation looks up transactions per sender
+CREATE INDEX IF NOT EXISTS idx_transactions_chain_from_nonce ON transactions (chain, from_address, nonce);
+-- FindByHash also matches replaced attempts
+CREATE INDEX IF NOT EXISTS idx_transactions_attempts ON transactions USING gin ((payload->'attempts') jsonb_path_ops);
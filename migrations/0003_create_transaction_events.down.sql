@@ -0,0 +1,4 @@
+-- Migration: revert 0003_create_transaction_events
+
+DROP TABLE IF EXISTS transaction_events;
+DROP FUNCTION IF EXISTS ecs_forbid_event_change();
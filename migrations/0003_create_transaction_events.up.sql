@@ -0,0 +1,34 @@
+-- Migration: create transaction_events
+-- Append-only status history of transactions. Existing transactions get one
+-- entry for the status they have when the table is created.
+
+CREATE TABLE IF NOT EXISTS transaction_events (
+  id bigserial PRIMARY KEY,
+  tx_id uuid NOT NULL REFERENCES transactions (id),
+  from_status text NOT NULL,
+  to_status text NOT NULL,
+  actor text NOT NULL,
+  reason text,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_tx_id ON transaction_events (tx_id, id);
+
+-- Trigger to keep the history append-only
+CREATE OR REPLACE FUNCTION ecs_forbid_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'transaction_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transaction_events_append_only ON transaction_events;
+CREATE TRIGGER transaction_events_append_only
+BEFORE UPDATE OR DELETE ON transaction_events
+FOR EACH ROW
+EXECUTE FUNCTION ecs_forbid_event_change();
+
+INSERT INTO transaction_events (tx_id, from_status, to_status, actor, reason, created_at)
+SELECT t.id, 'unknown', t.status, 'migration', 'status before history was recorded', t.updated_at
+FROM transactions t
+WHERE NOT EXISTS (SELECT 1 FROM transaction_events e WHERE e.tx_id = t.id);
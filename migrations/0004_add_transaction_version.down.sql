@@ -0,0 +1,3 @@
+-- Migration: revert 0004_add_transaction_version
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS version;
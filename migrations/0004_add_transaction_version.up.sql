@@ -0,0 +1,4 @@
+-- Migration: add transactions.version
+-- Row version for optimistic concurrency control; every write increments it.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Migration: revert 0005_add_transaction_list_indexes
+
+DROP INDEX IF EXISTS idx_transactions_to_lower;
+DROP INDEX IF EXISTS idx_transactions_from_lower;
+DROP INDEX IF EXISTS idx_transactions_created_at_id;
@@ -0,0 +1,7 @@
+-- Migration: indexes for TxRepositoryPort.List
+-- List pages newest first on (created_at, id) and filters addresses
+-- case-insensitively.
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON transactions (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_from_lower ON transactions (lower(from_address), created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_to_lower ON transactions (lower(to_address), created_at DESC);
@@ -0,0 +1,3 @@
+-- Migration: revert 0006_create_outbox
+
+DROP TABLE IF EXISTS outbox;
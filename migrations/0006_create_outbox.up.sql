@@ -0,0 +1,16 @@
+-- Migration: create outbox
+-- Domain events are inserted in the same transaction as the transactions row
+-- they describe and published on the event bus by the outbox relay.
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id bigserial PRIMARY KEY,
+  topic text NOT NULL,
+  payload jsonb NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text,
+  locked_until timestamptz,
+  published_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
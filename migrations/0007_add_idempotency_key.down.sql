@@ -0,0 +1,5 @@
+-- Migration: revert 0007_add_idempotency_key
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_idempotency_key_key;
+ALTER TABLE transactions DROP COLUMN IF EXISTS request_hash;
+ALTER TABLE transactions DROP COLUMN IF EXISTS idempotency_key;
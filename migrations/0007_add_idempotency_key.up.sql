@@ -0,0 +1,10 @@
+-- Migration: add transactions.idempotency_key
+-- Client-chosen key of the request that created a transaction, and the
+-- fingerprint of that request. The constraint makes a retried request unable
+-- to create a second transaction.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key text;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS request_hash text;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_idempotency_key_key;
+ALTER TABLE transactions ADD CONSTRAINT transactions_idempotency_key_key UNIQUE (idempotency_key);
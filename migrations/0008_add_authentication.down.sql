@@ -0,0 +1,4 @@
+-- Migration: revert 0008_add_authentication
+
+DROP TABLE IF EXISTS api_keys;
+ALTER TABLE transactions DROP COLUMN IF EXISTS requested_by;
@@ -0,0 +1,14 @@
+-- Migration: add transactions.requested_by and the api_keys table
+-- requested_by records the authenticated caller that submitted a
+-- transaction. api_keys holds API keys managed outside the config file; only
+-- the hex SHA-256 of a key is stored and scopes are space separated.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS requested_by text;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_hash   text PRIMARY KEY,
+    name       text NOT NULL,
+    scopes     text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz
+);
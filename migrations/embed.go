@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations so the binary can apply
+// them without the files on disk.
+package migrations
+
+import "embed"
+
+// FS holds the NNNN_name.up.sql and NNNN_name.down.sql files of this directory.
+//
+//go:embed *.sql
+var FS embed.FS